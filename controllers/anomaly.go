@@ -0,0 +1,381 @@
+package controllers
+
+import (
+	"backend/database"
+	"backend/types"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Ambang batas deteksi anomali absensi
+const (
+	burstRedemptionThreshold = 3  // jumlah redeem oleh kiosk yang sama dalam detik yang sama
+	repeatedCheckThreshold   = 5  // jumlah pengecekan token yang sama
+	toleranceEdgeThreshold   = 5  // jumlah hari check-in tepat di menit tolerance_time
+	toleranceEdgeLookback    = 30 // jumlah hari ke belakang untuk pola tolerance_time
+)
+
+var (
+	ErrAnomalyNotFound     = errors.New("anomali tidak ditemukan")
+	ErrInvalidReviewStatus = errors.New("status harus 'confirmed' atau 'dismissed'")
+)
+
+// DetectAttendanceAnomalies menganalisis data absensi sejak waktu tertentu dan
+// menyimpan temuan baru ke tabel attendance_anomalies. Temuan yang sama tidak
+// disimpan dua kali (berdasarkan fingerprint), sehingga aman dijalankan berulang.
+func DetectAttendanceAnomalies(since time.Time) (int, error) {
+	detectors := []struct {
+		name   string
+		detect func(time.Time) (int, error)
+	}{
+		{"burst_redemption", detectBurstRedemptions},
+		{"repeated_token_check", detectRepeatedTokenChecks},
+		{"tolerance_edge", detectToleranceEdgeCheckIns},
+		{"non_working_day", detectNonWorkingDayCheckIns},
+	}
+
+	total := 0
+	for _, d := range detectors {
+		found, err := d.detect(since)
+		if err != nil {
+			return total, fmt.Errorf("gagal mendeteksi %s: %w", d.name, err)
+		}
+		total += found
+	}
+
+	slog.Info("Anomaly scan finished", "since", since.Format(time.RFC3339), "new_anomalies", total)
+	return total, nil
+}
+
+// detectBurstRedemptions mencari banyak token yang di-redeem oleh kiosk yang sama
+// (user dan IP yang sama) dalam detik yang sama
+func detectBurstRedemptions(since time.Time) (int, error) {
+	rows, err := database.DB.Query(`
+		SELECT
+			redeemed_by,
+			COALESCE(redeemed_ip, ''),
+			date_trunc('second', redeemed_at) AS redeemed_second,
+			COUNT(*)
+		FROM attendance_tokens
+		WHERE is_used = true
+		  AND redeemed_at >= $1
+		GROUP BY redeemed_by, redeemed_ip, redeemed_second
+		HAVING COUNT(*) >= $2
+	`, since, burstRedemptionThreshold)
+
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	found := 0
+	for rows.Next() {
+		var redeemedBy sql.NullInt64
+		var ip string
+		var redeemedSecond time.Time
+		var count int
+
+		if err := rows.Scan(&redeemedBy, &ip, &redeemedSecond, &count); err != nil {
+			return found, err
+		}
+
+		inserted, err := insertAnomaly(
+			"burst_redemption",
+			redeemedBy,
+			redeemedSecond.Format("2006-01-02"),
+			fmt.Sprintf("burst_redemption:%d:%s:%s", redeemedBy.Int64, ip, redeemedSecond.Format(time.RFC3339)),
+			fmt.Sprintf("%d token di-redeem oleh kiosk user ID %d (IP %s) pada %s",
+				count, redeemedBy.Int64, ip, redeemedSecond.Format("2006-01-02 15:04:05")),
+		)
+		if err != nil {
+			return found, err
+		}
+		if inserted {
+			found++
+		}
+	}
+
+	return found, rows.Err()
+}
+
+// detectRepeatedTokenChecks mencari satu token yang dicek berulang kali
+// melalui /api/attendance/token/check
+func detectRepeatedTokenChecks(since time.Time) (int, error) {
+	rows, err := database.DB.Query(`
+		SELECT
+			token,
+			MIN(user_id),
+			COUNT(*),
+			COUNT(DISTINCT checked_by),
+			MAX(checked_at)
+		FROM attendance_token_checks
+		WHERE checked_at >= $1
+		GROUP BY token
+		HAVING COUNT(*) >= $2
+	`, since, repeatedCheckThreshold)
+
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	found := 0
+	for rows.Next() {
+		var token string
+		var userID sql.NullInt64
+		var count, checkers int
+		var lastCheckedAt time.Time
+
+		if err := rows.Scan(&token, &userID, &count, &checkers, &lastCheckedAt); err != nil {
+			return found, err
+		}
+
+		inserted, err := insertAnomaly(
+			"repeated_token_check",
+			userID,
+			lastCheckedAt.Format("2006-01-02"),
+			fmt.Sprintf("repeated_token_check:%s", token),
+			fmt.Sprintf("Token %s dicek %d kali oleh %d user berbeda", token, count, checkers),
+		)
+		if err != nil {
+			return found, err
+		}
+		if inserted {
+			found++
+		}
+	}
+
+	return found, rows.Err()
+}
+
+// detectToleranceEdgeCheckIns mencari karyawan yang kebiasaan check-in
+// tepat di menit tolerance_time. Pola dihitung dari toleranceEdgeLookback hari
+// terakhir, tetapi hanya dilaporkan jika ada check-in seperti itu sejak since,
+// sehingga pola lama yang sudah berhenti tidak dilaporkan lagi di bulan berikutnya.
+func detectToleranceEdgeCheckIns(since time.Time) (int, error) {
+	var toleranceTime string
+	err := database.DB.QueryRow(`
+		SELECT tolerance_time
+		FROM work_hours
+		ORDER BY id DESC
+		LIMIT 1
+	`).Scan(&toleranceTime)
+
+	if err != nil {
+		return 0, err
+	}
+	if len(toleranceTime) < 5 {
+		return 0, fmt.Errorf("format tolerance_time tidak valid: %q", toleranceTime)
+	}
+
+	rows, err := database.DB.Query(`
+		WITH first_check_ins AS (
+			SELECT user_id, MIN(created_at) AS check_in
+			FROM attendance_tokens
+			WHERE is_used = true
+			  AND created_at >= NOW() - make_interval(days => $2)
+			GROUP BY user_id, DATE(created_at)
+		)
+		SELECT user_id, COUNT(*)
+		FROM first_check_ins
+		WHERE TO_CHAR(check_in, 'HH24:MI') = $1
+		GROUP BY user_id
+		HAVING COUNT(*) >= $3 AND MAX(check_in) >= $4
+	`, toleranceTime[:5], toleranceEdgeLookback, toleranceEdgeThreshold, since)
+
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	found := 0
+	month := time.Now().Format("2006-01")
+	for rows.Next() {
+		var userID sql.NullInt64
+		var count int
+
+		if err := rows.Scan(&userID, &count); err != nil {
+			return found, err
+		}
+
+		// Satu temuan per user per bulan
+		inserted, err := insertAnomaly(
+			"tolerance_edge",
+			userID,
+			time.Now().Format("2006-01-02"),
+			fmt.Sprintf("tolerance_edge:%d:%s", userID.Int64, month),
+			fmt.Sprintf("%d check-in tepat pada menit tolerance_time (%s) dalam %d hari terakhir",
+				count, toleranceTime[:5], toleranceEdgeLookback),
+		)
+		if err != nil {
+			return found, err
+		}
+		if inserted {
+			found++
+		}
+	}
+
+	return found, rows.Err()
+}
+
+// detectNonWorkingDayCheckIns mencari check-in pada akhir pekan atau hari libur
+func detectNonWorkingDayCheckIns(since time.Time) (int, error) {
+	rows, err := database.DB.Query(`
+		SELECT
+			at.user_id,
+			TO_CHAR(DATE(at.created_at), 'YYYY-MM-DD'),
+			TO_CHAR(MIN(at.created_at), 'HH24:MI:SS'),
+			COALESCE(h.name, '')
+		FROM attendance_tokens at
+		LEFT JOIN holidays h ON h.date = DATE(at.created_at)
+		WHERE at.is_used = true
+		  AND at.created_at >= $1
+		  AND (EXTRACT(ISODOW FROM at.created_at) IN (6, 7) OR h.id IS NOT NULL)
+		GROUP BY at.user_id, DATE(at.created_at), h.name
+	`, since)
+
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	found := 0
+	for rows.Next() {
+		var userID sql.NullInt64
+		var date, checkInTime, holidayName string
+
+		if err := rows.Scan(&userID, &date, &checkInTime, &holidayName); err != nil {
+			return found, err
+		}
+
+		reason := "akhir pekan"
+		if holidayName != "" {
+			reason = "hari libur " + holidayName
+		}
+
+		inserted, err := insertAnomaly(
+			"non_working_day",
+			userID,
+			date,
+			fmt.Sprintf("non_working_day:%d:%s", userID.Int64, date),
+			fmt.Sprintf("Check-in pukul %s pada %s (%s)", checkInTime, date, reason),
+		)
+		if err != nil {
+			return found, err
+		}
+		if inserted {
+			found++
+		}
+	}
+
+	return found, rows.Err()
+}
+
+// insertAnomaly menyimpan temuan baru, mengembalikan false jika fingerprint sudah ada
+func insertAnomaly(anomalyType string, userID sql.NullInt64, date, fingerprint, details string) (bool, error) {
+	result, err := database.DB.Exec(`
+		INSERT INTO attendance_anomalies (anomaly_type, user_id, anomaly_date, fingerprint, details)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (fingerprint) DO NOTHING
+	`, anomalyType, userID, date, fingerprint, details)
+
+	if err != nil {
+		return false, fmt.Errorf("gagal insert anomali: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetAttendanceAnomalies mengambil daftar anomali, opsional difilter status dan tipe
+func GetAttendanceAnomalies(status, anomalyType string) ([]types.AttendanceAnomaly, error) {
+	query := `
+		SELECT
+			a.id, a.anomaly_type, a.user_id, u.name,
+			TO_CHAR(a.anomaly_date, 'YYYY-MM-DD'), a.details, a.status,
+			a.review_note, a.reviewed_by, a.reviewed_at, a.detected_at
+		FROM attendance_anomalies a
+		LEFT JOIN users u ON a.user_id = u.id
+	`
+	conditions := []string{}
+	args := []interface{}{}
+
+	if status != "" {
+		args = append(args, status)
+		conditions = append(conditions, fmt.Sprintf("a.status = $%d", len(args)))
+	}
+	if anomalyType != "" {
+		args = append(args, anomalyType)
+		conditions = append(conditions, fmt.Sprintf("a.anomaly_type = $%d", len(args)))
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY a.detected_at DESC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	anomalies := []types.AttendanceAnomaly{}
+	for rows.Next() {
+		var anomaly types.AttendanceAnomaly
+		err := rows.Scan(
+			&anomaly.ID,
+			&anomaly.Type,
+			&anomaly.UserID,
+			&anomaly.UserName,
+			&anomaly.AnomalyDate,
+			&anomaly.Details,
+			&anomaly.Status,
+			&anomaly.ReviewNote,
+			&anomaly.ReviewedBy,
+			&anomaly.ReviewedAt,
+			&anomaly.DetectedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		anomalies = append(anomalies, anomaly)
+	}
+
+	return anomalies, rows.Err()
+}
+
+// ReviewAttendanceAnomaly menyimpan keputusan HR (confirm/dismiss) atas sebuah anomali
+func ReviewAttendanceAnomaly(anomalyID int, reviewerID int, req types.ReviewAnomalyRequest) error {
+	if req.Status != "confirmed" && req.Status != "dismissed" {
+		return ErrInvalidReviewStatus
+	}
+
+	result, err := database.DB.Exec(`
+		UPDATE attendance_anomalies
+		SET status = $1, review_note = NULLIF($2, ''), reviewed_by = $3, reviewed_at = NOW()
+		WHERE id = $4
+	`, req.Status, req.Note, reviewerID, anomalyID)
+
+	if err != nil {
+		return fmt.Errorf("gagal update anomali: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("gagal cek rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrAnomalyNotFound
+	}
+
+	slog.Info("Anomaly reviewed", "anomaly_id", anomalyID, "reviewer_id", reviewerID, "status", req.Status)
+	return nil
+}
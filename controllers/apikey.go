@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"backend/database"
+	"backend/types"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+const (
+	apiKeyPrefix         = "att_"
+	defaultAPIKeyTTLDays = 90
+	maxAPIKeyTTLDays     = 365
+)
+
+var (
+	ErrInvalidAPIKeyRequest = errors.New("nama, permission dan masa berlaku (1-365 hari) API key wajib valid")
+	ErrAPIKeyNotFound       = errors.New("API key tidak ditemukan")
+	ErrInvalidAPIKey        = errors.New("API key tidak valid, kedaluwarsa atau sudah dicabut")
+)
+
+// CreateAPIKey membuat personal access token (req.UserID diisi) atau service
+// API key. Key asli hanya dikembalikan sekali; database menyimpan hash-nya.
+func CreateAPIKey(req types.CreateAPIKeyRequest, createdBy int, ip string) (types.CreateAPIKeyResponse, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.ExpiresInDays == 0 {
+		req.ExpiresInDays = defaultAPIKeyTTLDays
+	}
+	if req.Name == "" || len(req.Permissions) == 0 || req.ExpiresInDays < 0 || req.ExpiresInDays > maxAPIKeyTTLDays {
+		return types.CreateAPIKeyResponse{}, ErrInvalidAPIKeyRequest
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return types.CreateAPIKeyResponse{}, fmt.Errorf("gagal memulai transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var known int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM permissions WHERE name = ANY($1)`, pq.Array(req.Permissions)).Scan(&known); err != nil {
+		return types.CreateAPIKeyResponse{}, fmt.Errorf("gagal cek permission: %w", err)
+	}
+	if known != len(uniqueStrings(req.Permissions)) {
+		return types.CreateAPIKeyResponse{}, ErrUnknownPermission
+	}
+
+	if req.UserID != nil {
+		var exists bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, *req.UserID).Scan(&exists); err != nil {
+			return types.CreateAPIKeyResponse{}, fmt.Errorf("gagal cek user: %w", err)
+		}
+		if !exists {
+			return types.CreateAPIKeyResponse{}, ErrUserNotFound
+		}
+	}
+
+	secret, err := generateSecureToken()
+	if err != nil {
+		return types.CreateAPIKeyResponse{}, fmt.Errorf("gagal membuat API key: %w", err)
+	}
+	key := apiKeyPrefix + secret
+
+	response := types.CreateAPIKeyResponse{Key: key}
+	response.Name = req.Name
+	response.Prefix = key[:len(apiKeyPrefix)+8]
+	response.UserID = req.UserID
+	response.CreatedBy = &createdBy
+
+	err = tx.QueryRow(`
+		INSERT INTO api_keys (name, key_prefix, key_hash, user_id, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, 0), NOW() + make_interval(days => $6))
+		RETURNING id, expires_at, created_at
+	`, response.Name, response.Prefix, hashToken(key), req.UserID, createdBy, req.ExpiresInDays).Scan(
+		&response.ID, &response.ExpiresAt, &response.CreatedAt,
+	)
+	if err != nil {
+		return types.CreateAPIKeyResponse{}, fmt.Errorf("gagal menyimpan API key: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO api_key_permissions (api_key_id, permission_id)
+		SELECT $1, id FROM permissions WHERE name = ANY($2)
+	`, response.ID, pq.Array(req.Permissions))
+	if err != nil {
+		return types.CreateAPIKeyResponse{}, fmt.Errorf("gagal menyimpan permission API key: %w", err)
+	}
+
+	err = recordAuditTx(tx, AuditEntry{
+		ActorID:    createdBy,
+		Action:     AuditActionAPIKeyCreate,
+		EntityType: AuditEntityAPIKey,
+		EntityID:   response.ID,
+		After: map[string]interface{}{
+			"name":            response.Name,
+			"prefix":          response.Prefix,
+			"user_id":         req.UserID,
+			"permissions":     uniqueStrings(req.Permissions),
+			"expires_in_days": req.ExpiresInDays,
+		},
+		IP: ip,
+	})
+	if err != nil {
+		return types.CreateAPIKeyResponse{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return types.CreateAPIKeyResponse{}, fmt.Errorf("gagal commit transaction: %w", err)
+	}
+
+	response.Permissions = req.Permissions
+	slog.Info("API key created", "api_key_id", response.ID, "prefix", response.Prefix, "actor_id", createdBy)
+	return response, nil
+}
+
+// GetAPIKeys mengembalikan semua API key (termasuk yang sudah dicabut/kedaluwarsa)
+func GetAPIKeys() ([]types.APIKey, error) {
+	rows, err := database.DB.Query(`
+		SELECT k.id, k.name, k.key_prefix, k.user_id, u.name,
+			COALESCE(ARRAY_AGG(p.name ORDER BY p.name) FILTER (WHERE p.name IS NOT NULL), '{}'),
+			k.created_by, k.expires_at, k.last_used_at, k.last_used_ip, k.revoked_at, k.created_at
+		FROM api_keys k
+		LEFT JOIN users u ON u.id = k.user_id
+		LEFT JOIN api_key_permissions kp ON kp.api_key_id = k.id
+		LEFT JOIN permissions p ON p.id = kp.permission_id
+		GROUP BY k.id, u.name
+		ORDER BY k.created_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("gagal query API key: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []types.APIKey{}
+	for rows.Next() {
+		var k types.APIKey
+		if err := rows.Scan(
+			&k.ID, &k.Name, &k.Prefix, &k.UserID, &k.UserName, pq.Array(&k.Permissions),
+			&k.CreatedBy, &k.ExpiresAt, &k.LastUsedAt, &k.LastUsedIP, &k.RevokedAt, &k.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("gagal scan API key: %w", err)
+		}
+		keys = append(keys, k)
+	}
+
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey mencabut API key; request berikutnya dengan key ini langsung ditolak
+func RevokeAPIKey(keyID int, actorID int, ip string) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("gagal memulai transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var name, prefix string
+	var userID sql.NullInt64
+	err = tx.QueryRow(`
+		UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL
+		RETURNING name, key_prefix, user_id
+	`, keyID).Scan(&name, &prefix, &userID)
+	if err == sql.ErrNoRows {
+		return ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("gagal mencabut API key: %w", err)
+	}
+
+	key := map[string]interface{}{"name": name, "prefix": prefix, "user_id": nil}
+	if userID.Valid {
+		key["user_id"] = userID.Int64
+	}
+	err = recordAuditTx(tx, AuditEntry{
+		ActorID:    actorID,
+		Action:     AuditActionAPIKeyRevoke,
+		EntityType: AuditEntityAPIKey,
+		EntityID:   keyID,
+		Before:     key,
+		After:      map[string]interface{}{"revoked": true},
+		IP:         ip,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("gagal commit transaction: %w", err)
+	}
+
+	slog.Info("API key revoked", "api_key_id", keyID, "actor_id", actorID)
+	return nil
+}
+
+// AuthenticateAPIKey memverifikasi key dari header Authorization: Bearer dan
+// mencatat waktu serta IP pemakaian terakhir (paling sering sekali per menit)
+func AuthenticateAPIKey(key string, ip string) (types.APIKeyPrincipal, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return types.APIKeyPrincipal{}, ErrInvalidAPIKey
+	}
+
+	var principal types.APIKeyPrincipal
+	var userID sql.NullInt64
+	err := database.DB.QueryRow(`
+		SELECT k.id, k.user_id,
+			COALESCE(ARRAY_AGG(p.name) FILTER (WHERE p.name IS NOT NULL), '{}')
+		FROM api_keys k
+		LEFT JOIN api_key_permissions kp ON kp.api_key_id = k.id
+		LEFT JOIN permissions p ON p.id = kp.permission_id
+		WHERE k.key_hash = $1 AND k.revoked_at IS NULL AND k.expires_at > NOW()
+		GROUP BY k.id
+	`, hashToken(key)).Scan(&principal.KeyID, &userID, pq.Array(&principal.Permissions))
+
+	if err == sql.ErrNoRows {
+		return types.APIKeyPrincipal{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return types.APIKeyPrincipal{}, fmt.Errorf("gagal memverifikasi API key: %w", err)
+	}
+	principal.UserID = int(userID.Int64)
+
+	_, err = database.DB.Exec(`
+		UPDATE api_keys SET last_used_at = NOW(), last_used_ip = NULLIF($2, '')
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+	`, principal.KeyID, ip)
+	if err != nil {
+		slog.Error("Failed to update last use of API key", "api_key_id", principal.KeyID, "error", err)
+	}
+
+	return principal, nil
+}
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"backend/database"
+	"backend/types"
+	"errors"
+	"testing"
+)
+
+func TestAPIKeyLifecycleIsAudited(t *testing.T) {
+	requireTestDB(t)
+
+	actorID, _ := createTestUser(t, "apikey-actor")
+	key, err := CreateAPIKey(types.CreateAPIKeyRequest{
+		Name:        "scanner test",
+		Permissions: []string{"attendance.scan"},
+	}, actorID, "192.0.2.10")
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := database.DB.Exec(`DELETE FROM api_keys WHERE id = $1`, key.ID); err != nil {
+			t.Errorf("gagal menghapus API key test: %v", err)
+		}
+	})
+
+	if err := RevokeAPIKey(key.ID, actorID, "192.0.2.10"); err != nil {
+		t.Fatalf("RevokeAPIKey: %v", err)
+	}
+	if err := RevokeAPIKey(key.ID, actorID, "192.0.2.10"); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Fatalf("revoke kedua err = %v, want ErrAPIKeyNotFound", err)
+	}
+
+	for _, action := range []string{AuditActionAPIKeyCreate, AuditActionAPIKeyRevoke} {
+		var count int
+		err := database.DB.QueryRow(`
+			SELECT COUNT(*) FROM audit_logs
+			WHERE action = $1 AND entity_type = $2 AND entity_id = $3 AND actor_id = $4 AND ip = '192.0.2.10'
+		`, action, AuditEntityAPIKey, key.ID, actorID).Scan(&count)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Errorf("audit %s = %d baris, want 1", action, count)
+		}
+	}
+}
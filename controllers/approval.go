@@ -0,0 +1,374 @@
+package controllers
+
+import (
+	"backend/database"
+	"backend/types"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Permission yang mengizinkan approver menyetujui pengajuan seluruh karyawan
+// (misalnya HR), bukan hanya anggota timnya sendiri
+const permissionApproveAllRequests = "requests.approve.all"
+
+var (
+	ErrInvalidLeaveRequest      = errors.New("pengajuan cuti tidak valid")
+	ErrInvalidCorrectionRequest = errors.New("pengajuan koreksi absensi tidak valid")
+	ErrRequestNotFound          = errors.New("pengajuan tidak ditemukan")
+	ErrRequestAlreadyReviewed   = errors.New("pengajuan sudah diproses")
+	ErrNotApprover              = errors.New("Anda bukan approver untuk pengajuan ini")
+	ErrInvalidApprovalStatus    = errors.New("status harus 'approved' atau 'rejected'")
+)
+
+// CreateLeaveRequest menyimpan pengajuan cuti baru milik user dengan status pending
+func CreateLeaveRequest(userID int, req types.CreateLeaveRequest) (types.LeaveRequest, error) {
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return types.LeaveRequest{}, fmt.Errorf("%w: start_date harus berformat YYYY-MM-DD", ErrInvalidLeaveRequest)
+	}
+	endDate, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		return types.LeaveRequest{}, fmt.Errorf("%w: end_date harus berformat YYYY-MM-DD", ErrInvalidLeaveRequest)
+	}
+	if endDate.Before(startDate) {
+		return types.LeaveRequest{}, fmt.Errorf("%w: end_date tidak boleh sebelum start_date", ErrInvalidLeaveRequest)
+	}
+
+	var leave types.LeaveRequest
+	err = database.DB.QueryRow(`
+		INSERT INTO leave_requests (user_id, start_date, end_date, reason)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+		RETURNING id, user_id, TO_CHAR(start_date, 'YYYY-MM-DD'), TO_CHAR(end_date, 'YYYY-MM-DD'),
+		          reason, status, created_at
+	`, userID, req.StartDate, req.EndDate, strings.TrimSpace(req.Reason)).Scan(
+		&leave.ID,
+		&leave.UserID,
+		&leave.StartDate,
+		&leave.EndDate,
+		&leave.Reason,
+		&leave.Status,
+		&leave.CreatedAt,
+	)
+
+	if err != nil {
+		return types.LeaveRequest{}, fmt.Errorf("gagal insert pengajuan cuti: %w", err)
+	}
+
+	slog.Info("Leave request created", "leave_request_id", leave.ID, "user_id", userID)
+	return leave, nil
+}
+
+// CreateAttendanceCorrection menyimpan pengajuan koreksi absensi dengan status pending
+func CreateAttendanceCorrection(userID int, req types.CreateAttendanceCorrectionRequest) (types.AttendanceCorrection, error) {
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return types.AttendanceCorrection{}, fmt.Errorf("%w: date harus berformat YYYY-MM-DD", ErrInvalidCorrectionRequest)
+	}
+	if date.After(time.Now()) {
+		return types.AttendanceCorrection{}, fmt.Errorf("%w: tanggal koreksi tidak boleh di masa depan", ErrInvalidCorrectionRequest)
+	}
+
+	checkIn := req.RequestedCheckIn
+	if len(checkIn) == len("15:04") {
+		checkIn += ":00"
+	}
+	if _, err := parseClock(checkIn); err != nil {
+		return types.AttendanceCorrection{}, fmt.Errorf("%w: requested_check_in harus berformat HH:MM", ErrInvalidCorrectionRequest)
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return types.AttendanceCorrection{}, fmt.Errorf("%w: alasan wajib diisi", ErrInvalidCorrectionRequest)
+	}
+
+	var correction types.AttendanceCorrection
+	err = database.DB.QueryRow(`
+		INSERT INTO attendance_corrections (user_id, date, requested_check_in, reason)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, user_id, TO_CHAR(date, 'YYYY-MM-DD'), TO_CHAR(requested_check_in, 'HH24:MI:SS'),
+		          reason, status, created_at
+	`, userID, req.Date, checkIn, reason).Scan(
+		&correction.ID,
+		&correction.UserID,
+		&correction.Date,
+		&correction.RequestedCheckIn,
+		&correction.Reason,
+		&correction.Status,
+		&correction.CreatedAt,
+	)
+
+	if err != nil {
+		return types.AttendanceCorrection{}, fmt.Errorf("gagal insert koreksi absensi: %w", err)
+	}
+
+	slog.Info("Attendance correction created", "correction_id", correction.ID, "user_id", userID)
+	return correction, nil
+}
+
+// GetReviewableLeaveRequests mengambil pengajuan cuti yang bisa diproses approver:
+// milik anggota timnya, atau semua karyawan jika approver memiliki requests.approve.all.
+// Pengajuan milik approver sendiri tidak pernah ditampilkan.
+func GetReviewableLeaveRequests(reviewerID int, status string) ([]types.LeaveRequest, error) {
+	approveAll, err := UserHasPermission(reviewerID, permissionApproveAllRequests)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT
+			lr.id, lr.user_id, u.name, TO_CHAR(lr.start_date, 'YYYY-MM-DD'), TO_CHAR(lr.end_date, 'YYYY-MM-DD'),
+			lr.reason, lr.status, lr.review_note, lr.reviewed_by, lr.reviewed_at, lr.created_at
+		FROM leave_requests lr
+		JOIN users u ON u.id = lr.user_id
+		JOIN departments d ON d.id = u.department_id
+		WHERE lr.user_id <> $1
+		  AND ($2 OR d.manager_id = $1)
+		  AND ($3 = '' OR lr.status = $3)
+		ORDER BY lr.created_at DESC
+	`, reviewerID, approveAll, status)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	leaves := []types.LeaveRequest{}
+	for rows.Next() {
+		var leave types.LeaveRequest
+		err := rows.Scan(
+			&leave.ID,
+			&leave.UserID,
+			&leave.UserName,
+			&leave.StartDate,
+			&leave.EndDate,
+			&leave.Reason,
+			&leave.Status,
+			&leave.ReviewNote,
+			&leave.ReviewedBy,
+			&leave.ReviewedAt,
+			&leave.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leave)
+	}
+
+	return leaves, rows.Err()
+}
+
+// GetReviewableAttendanceCorrections mengambil pengajuan koreksi absensi yang bisa
+// diproses approver (aturan cakupan sama dengan GetReviewableLeaveRequests)
+func GetReviewableAttendanceCorrections(reviewerID int, status string) ([]types.AttendanceCorrection, error) {
+	approveAll, err := UserHasPermission(reviewerID, permissionApproveAllRequests)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT
+			ac.id, ac.user_id, u.name, TO_CHAR(ac.date, 'YYYY-MM-DD'), TO_CHAR(ac.requested_check_in, 'HH24:MI:SS'),
+			ac.reason, ac.status, ac.review_note, ac.reviewed_by, ac.reviewed_at, ac.created_at
+		FROM attendance_corrections ac
+		JOIN users u ON u.id = ac.user_id
+		JOIN departments d ON d.id = u.department_id
+		WHERE ac.user_id <> $1
+		  AND ($2 OR d.manager_id = $1)
+		  AND ($3 = '' OR ac.status = $3)
+		ORDER BY ac.created_at DESC
+	`, reviewerID, approveAll, status)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	corrections := []types.AttendanceCorrection{}
+	for rows.Next() {
+		var correction types.AttendanceCorrection
+		err := rows.Scan(
+			&correction.ID,
+			&correction.UserID,
+			&correction.UserName,
+			&correction.Date,
+			&correction.RequestedCheckIn,
+			&correction.Reason,
+			&correction.Status,
+			&correction.ReviewNote,
+			&correction.ReviewedBy,
+			&correction.ReviewedAt,
+			&correction.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		corrections = append(corrections, correction)
+	}
+
+	return corrections, rows.Err()
+}
+
+// ReviewLeaveRequest menyetujui atau menolak pengajuan cuti.
+// Cuti yang disetujui otomatis dihitung sebagai "leave" pada rekap absensi.
+func ReviewLeaveRequest(leaveID int, reviewerID int, req types.ApprovalRequest) error {
+	if req.Status != "approved" && req.Status != "rejected" {
+		return ErrInvalidApprovalStatus
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("gagal memulai transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var requesterID int
+	var status string
+	err = tx.QueryRow(`
+		SELECT user_id, status FROM leave_requests WHERE id = $1 FOR UPDATE
+	`, leaveID).Scan(&requesterID, &status)
+
+	if err == sql.ErrNoRows {
+		return ErrRequestNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("gagal mengambil pengajuan cuti: %w", err)
+	}
+
+	if err := checkApprover(reviewerID, requesterID, status); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE leave_requests
+		SET status = $1, review_note = NULLIF($2, ''), reviewed_by = $3, reviewed_at = NOW()
+		WHERE id = $4
+	`, req.Status, req.Note, reviewerID, leaveID)
+
+	if err != nil {
+		return fmt.Errorf("gagal update pengajuan cuti: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("gagal commit transaction: %w", err)
+	}
+
+	slog.Info("Leave request reviewed", "leave_request_id", leaveID, "status", req.Status, "reviewer_id", reviewerID)
+	return nil
+}
+
+// ReviewAttendanceCorrection menyetujui atau menolak pengajuan koreksi absensi.
+// Koreksi yang disetujui dicatat sebagai check-in (attendance_tokens yang sudah
+// terpakai) pada tanggal dan jam yang diajukan.
+func ReviewAttendanceCorrection(correctionID int, reviewerID int, req types.ApprovalRequest) error {
+	if req.Status != "approved" && req.Status != "rejected" {
+		return ErrInvalidApprovalStatus
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("gagal memulai transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var requesterID int
+	var status, checkInAt string
+	err = tx.QueryRow(`
+		SELECT user_id, status, TO_CHAR(date + requested_check_in, 'YYYY-MM-DD HH24:MI:SS')
+		FROM attendance_corrections
+		WHERE id = $1
+		FOR UPDATE
+	`, correctionID).Scan(&requesterID, &status, &checkInAt)
+
+	if err == sql.ErrNoRows {
+		return ErrRequestNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("gagal mengambil koreksi absensi: %w", err)
+	}
+
+	if err := checkApprover(reviewerID, requesterID, status); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE attendance_corrections
+		SET status = $1, review_note = NULLIF($2, ''), reviewed_by = $3, reviewed_at = NOW()
+		WHERE id = $4
+	`, req.Status, req.Note, reviewerID, correctionID)
+
+	if err != nil {
+		return fmt.Errorf("gagal update koreksi absensi: %w", err)
+	}
+
+	if req.Status == "approved" {
+		var tokenID int
+		var redeemedAt time.Time
+		err = tx.QueryRow(`
+			INSERT INTO attendance_tokens (user_id, token, expired_at, is_used, created_at, redeemed_at, redeemed_by)
+			VALUES ($1, $2, $3::timestamp, true, $3::timestamp, NOW(), $4)
+			RETURNING id, redeemed_at
+		`, requesterID, fmt.Sprintf("correction-%d", correctionID), checkInAt, reviewerID).Scan(&tokenID, &redeemedAt)
+
+		if err != nil {
+			return fmt.Errorf("gagal mencatat check-in koreksi: %w", err)
+		}
+
+		// Check-in hasil koreksi ikut masuk audit chain seperti absensi biasa
+		err = recordAuditTx(tx, AuditEntry{
+			ActorID:    reviewerID,
+			Action:     AuditActionAttendanceCorrection,
+			EntityType: AuditEntityAttendanceToken,
+			EntityID:   tokenID,
+			After: map[string]interface{}{
+				"user_id":       requesterID,
+				"check_in":      checkInAt,
+				"correction_id": correctionID,
+				"redeemed_at":   redeemedAt.Format(auditTimeLayout),
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("gagal commit transaction: %w", err)
+	}
+
+	slog.Info("Attendance correction reviewed", "correction_id", correctionID, "status", req.Status, "reviewer_id", reviewerID)
+	return nil
+}
+
+// checkApprover memastikan pengajuan masih pending dan reviewer berhak memprosesnya:
+// manager dari departemen pemohon, atau pemilik permission requests.approve.all.
+// Tidak ada yang boleh menyetujui pengajuannya sendiri.
+func checkApprover(reviewerID int, requesterID int, status string) error {
+	if status != "pending" {
+		return ErrRequestAlreadyReviewed
+	}
+	if reviewerID == requesterID {
+		return ErrNotApprover
+	}
+
+	approveAll, err := UserHasPermission(reviewerID, permissionApproveAllRequests)
+	if err != nil {
+		return err
+	}
+	if approveAll {
+		return nil
+	}
+
+	isMember, err := IsTeamMember(reviewerID, requesterID)
+	if err != nil {
+		return err
+	}
+	if !isMember {
+		return ErrNotApprover
+	}
+
+	return nil
+}
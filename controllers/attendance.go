@@ -7,17 +7,25 @@ import (
 	"database/sql"
 	"encoding/hex"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 )
 
+// Status harian pada matriks absensi bulanan
+const (
+	DayStatusPresent = "present"
+	DayStatusLate    = "late"
+	DayStatusAbsent  = "absent"
+	DayStatusLeave   = "leave"
+	DayStatusHoliday = "holiday"
+)
+
 func GenerateToken() string {
 	bytes := make([]byte, 8) // 8 byte = 16 karakter hex
 	if _, err := rand.Read(bytes); err != nil {
 		return ""
 	}
 
-	fmt.Println("Generated token:", hex.EncodeToString(bytes))
 	return hex.EncodeToString(bytes)
 }
 
@@ -47,7 +55,7 @@ func GenerateUserAttendanceToken(userID int) (types.UserReceivedAttendanceToken,
 	defer func() {
 		if err != nil {
 			tx.Rollback()
-			log.Printf("Transaction rolled back for user ID %d", userID)
+			slog.Warn("Transaction rolled back", "user_id", userID)
 		}
 	}()
 
@@ -73,15 +81,40 @@ func GenerateUserAttendanceToken(userID int) (types.UserReceivedAttendanceToken,
 		ExpiredAt: attendanceToken.ExpiredAt,
 	}
 
-	log.Printf("Generated attendance token for user ID %d: %s", userReceivedToken.UserID, userReceivedToken.Token)
+	slog.Info("Generated attendance token", "user_id", userReceivedToken.UserID)
 
 	return userReceivedToken, nil
 }
 
-func CheckAttendanceToken(data types.CheckAttendanceToken) (types.CheckAttendanceTokenResponse, error) {
+// Kode hasil pengecekan dan penukaran token absensi. Kode ini stabil dan boleh
+// dipakai frontend/scanner untuk menentukan pesan yang ditampilkan.
+const (
+	TokenCodeValid     = "token_valid"
+	TokenCodeNotFound  = "token_not_found"
+	TokenCodeUsed      = "token_already_used"
+	TokenCodeExpired   = "token_expired"
+	TokenCodeForbidden = "token_forbidden"
+	TokenCodeSubmitted = "attendance_submitted"
+)
+
+// SecurityEventTokenOwnerMismatch dicatat jika user mencoba mengecek atau menukar
+// token absensi milik user lain tanpa permission attendance.scan
+const SecurityEventTokenOwnerMismatch = "attendance_token_owner_mismatch"
+
+// CheckAttendanceToken mengecek status token absensi. checkedBy 0 berarti
+// dicek oleh service API key (scanner), bukan user.
+func CheckAttendanceToken(data types.CheckAttendanceToken, checkedBy int, ip string) (types.CheckAttendanceTokenResponse, error) {
 	var expired_at time.Time
 	var is_used bool
 
+	// catat setiap pengecekan token untuk deteksi anomali (token dicek berulang kali)
+	if _, err := database.DB.Exec(`
+		INSERT INTO attendance_token_checks (user_id, token, checked_by, ip)
+		VALUES ($1, $2, NULLIF($3, 0), $4)
+	`, data.UserID, data.Token, checkedBy, ip); err != nil {
+		slog.Error("Failed to record token check", "user_id", data.UserID, "error", err)
+	}
+
 	err := database.DB.QueryRow(`
 		SELECT expired_at, is_used
 		FROM attendance_tokens
@@ -89,31 +122,23 @@ func CheckAttendanceToken(data types.CheckAttendanceToken) (types.CheckAttendanc
 	`, data.UserID, data.Token).Scan(&expired_at, &is_used)
 
 	if err == sql.ErrNoRows {
-		log.Printf("User not found with ID: %d", data.UserID)
 		return types.CheckAttendanceTokenResponse{
-			Valid:      false,
-			Is_Used:    nil,
-			Expired_At: nil,
-			Message:    "Token not found",
+			Valid:   false,
+			Code:    TokenCodeNotFound,
+			Message: "Token not found",
 		}, nil
 	}
 
 	if err != nil {
-		log.Printf("Error fetching attendance token for user ID %d: %v", data.UserID, err)
-		return types.CheckAttendanceTokenResponse{
-			Valid:      false,
-			Is_Used:    nil,
-			Expired_At: nil,
-			Message:    "Token not found 2",
-		}, err
+		return types.CheckAttendanceTokenResponse{}, fmt.Errorf("gagal mengambil attendance token user %d: %w", data.UserID, err)
 	}
 
 	if is_used {
 		return types.CheckAttendanceTokenResponse{
-			Valid:      false,
-			Is_Used:    &is_used,
-			Expired_At: nil,
-			Message:    "Token already used",
+			Valid:   false,
+			Is_Used: &is_used,
+			Code:    TokenCodeUsed,
+			Message: "Token already used",
 		}, nil
 	}
 
@@ -122,6 +147,7 @@ func CheckAttendanceToken(data types.CheckAttendanceToken) (types.CheckAttendanc
 			Valid:      false,
 			Is_Used:    &is_used,
 			Expired_At: &expired_at,
+			Code:       TokenCodeExpired,
 			Message:    "Token expired",
 		}, nil
 	}
@@ -131,11 +157,14 @@ func CheckAttendanceToken(data types.CheckAttendanceToken) (types.CheckAttendanc
 		Valid:      true,
 		Is_Used:    &is_used,
 		Expired_At: &expired_at,
+		Code:       TokenCodeValid,
 		Message:    "Token is valid",
 	}, nil
 }
 
-func SubmitAttendance(submitReq types.UserReceivedAttendanceToken) (types.SubmitAttendanceResponse, error) {
+// SubmitAttendance menukarkan token absensi (check-in). redeemedBy 0 berarti
+// ditukar oleh service API key (scanner), bukan user.
+func SubmitAttendance(submitReq types.UserReceivedAttendanceToken, redeemedBy int, ip string) (types.SubmitAttendanceResponse, error) {
 	// cek terlebih dahulu apakah token user expired dan apakah sudah terpakai
 	var expired_at time.Time
 	var is_used bool
@@ -146,14 +175,23 @@ func SubmitAttendance(submitReq types.UserReceivedAttendanceToken) (types.Submit
 		WHERE user_id = $1 AND token = $2
 	`, submitReq.UserID, submitReq.Token).Scan(&expired_at, &is_used)
 
+	if err == sql.ErrNoRows {
+		return types.SubmitAttendanceResponse{
+			Success: false,
+			Code:    TokenCodeNotFound,
+			Message: "Token not found",
+			UserID:  submitReq.UserID,
+		}, nil
+	}
+
 	if err != nil {
-		log.Printf("Error fetching attendance token for user ID %d: %v", submitReq.UserID, err)
-		return types.SubmitAttendanceResponse{}, err
+		return types.SubmitAttendanceResponse{}, fmt.Errorf("gagal mengambil attendance token user %d: %w", submitReq.UserID, err)
 	}
 
 	if is_used {
 		return types.SubmitAttendanceResponse{
 			Success: false,
+			Code:    TokenCodeUsed,
 			Message: "Token already used",
 			UserID:  submitReq.UserID,
 		}, nil
@@ -162,65 +200,93 @@ func SubmitAttendance(submitReq types.UserReceivedAttendanceToken) (types.Submit
 	if time.Now().After(expired_at) {
 		return types.SubmitAttendanceResponse{
 			Success: false,
+			Code:    TokenCodeExpired,
 			Message: "Token expired",
 			UserID:  submitReq.UserID,
 		}, nil
 	}
 
-	// jika valid, maka mulai update is_used menjadi true
-
+	// jika valid, tandai token terpakai. Kondisi is_used = false mencegah token
+	// yang sama ditukar dua kali oleh request yang berjalan bersamaan.
 	tx, err := database.DB.Begin()
 	if err != nil {
-		log.Printf("Failed to begin transaction for submitting attendance: %v", err)
-		return types.SubmitAttendanceResponse{}, err
+		return types.SubmitAttendanceResponse{}, fmt.Errorf("gagal memulai transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-			log.Printf("Transaction rolled back for submitting attendance: %v", err)
-		}
-	}()
-
-	_, err = tx.Exec(`
+	var tokenID int
+	var createdAt, redeemedAt time.Time
+	err = tx.QueryRow(`
 		UPDATE attendance_tokens
-		SET is_used = true
-		WHERE user_id = $1 AND token = $2
-	`, submitReq.UserID, submitReq.Token)
+		SET is_used = true, redeemed_at = NOW(), redeemed_by = NULLIF($3, 0), redeemed_ip = $4
+		WHERE user_id = $1 AND token = $2 AND is_used = false
+		RETURNING id, created_at, redeemed_at
+	`, submitReq.UserID, submitReq.Token, redeemedBy, ip).Scan(&tokenID, &createdAt, &redeemedAt)
 
+	if err == sql.ErrNoRows {
+		return types.SubmitAttendanceResponse{
+			Success: false,
+			Code:    TokenCodeUsed,
+			Message: "Token already used",
+			UserID:  submitReq.UserID,
+		}, nil
+	}
+	if err != nil {
+		return types.SubmitAttendanceResponse{}, fmt.Errorf("gagal update attendance token user %d: %w", submitReq.UserID, err)
+	}
+
+	err = recordAuditTx(tx, AuditEntry{
+		ActorID:    redeemedBy,
+		Action:     AuditActionAttendanceSubmit,
+		EntityType: AuditEntityAttendanceToken,
+		EntityID:   tokenID,
+		Before:     map[string]interface{}{"is_used": false},
+		// Waktu token dibuat dan ditukar ikut di-hash sehingga perubahan jam
+		// absensi di attendance_tokens terdeteksi oleh VerifyAuditChain
+		After: map[string]interface{}{
+			"is_used":     true,
+			"user_id":     submitReq.UserID,
+			"created_at":  createdAt.Format(auditTimeLayout),
+			"redeemed_at": redeemedAt.Format(auditTimeLayout),
+		},
+		IP: ip,
+	})
 	if err != nil {
-		log.Printf("Failed to update attendance token as used for user ID %d: %v", submitReq.UserID, err)
 		return types.SubmitAttendanceResponse{}, err
 	}
 
-	err = tx.Commit()
-	if err != nil {
-		log.Printf("Failed to commit transaction for submitting attendance for user ID %d: %v", submitReq.UserID, err)
-		return types.SubmitAttendanceResponse{}, err
+	if err := tx.Commit(); err != nil {
+		return types.SubmitAttendanceResponse{}, fmt.Errorf("gagal commit transaction: %w", err)
 	}
 
-	log.Printf("Attendance submitted successfully for user ID %d with token %s", submitReq.UserID, submitReq.Token)
+	slog.Info("Attendance submitted successfully", "user_id", submitReq.UserID)
 	return types.SubmitAttendanceResponse{
 		Success: true,
+		Code:    TokenCodeSubmitted,
 		Message: fmt.Sprintf("User with ID %d attendance submitted successfully", submitReq.UserID),
 		UserID:  submitReq.UserID,
 	}, nil
 }
 
 func GetTodayAttendance() (types.TodayAttendanceListResponse, error) {
-	var attendances []types.TodayAttendance
+	return GetDailyAttendance(time.Now())
+}
 
-	// Get tolerance time from work_hours
-	var toleranceTime string
-	err := database.DB.QueryRow(`
-		SELECT tolerance_time
-		FROM work_hours
-		ORDER BY id DESC
-		LIMIT 1
-	`).Scan(&toleranceTime)
+// GetDailyAttendance mengambil rekap absensi untuk tanggal tertentu
+func GetDailyAttendance(date time.Time) (types.TodayAttendanceListResponse, error) {
+	return getDailyAttendance(date, 0)
+}
 
+// getDailyAttendance mengambil rekap absensi harian. managerID 0 berarti semua
+// karyawan; selain itu hanya karyawan di departemen yang dipimpin manager tersebut.
+func getDailyAttendance(date time.Time, managerID int) (types.TodayAttendanceListResponse, error) {
+	var attendances []types.TodayAttendance
+	day := date.Format("2006-01-02")
+
+	// Load work hours dan late policy yang berlaku
+	evaluator, err := newLateEvaluator()
 	if err != nil {
-		log.Printf("Error fetching work hours: %v", err)
+		slog.Error("Error loading late policy", "error", err)
 		return types.TodayAttendanceListResponse{}, err
 	}
 
@@ -230,19 +296,20 @@ func GetTodayAttendance() (types.TodayAttendanceListResponse, error) {
 			u.name,
 			u.email,
 			d.name as department_name,
-			u.position,
+			COALESCE(u.position, ''),
 			at.created_at,
 			at.token,
 			at.is_used
 		FROM attendance_tokens at
 		JOIN users u ON at.user_id = u.id
 		JOIN departments d ON u.department_id = d.id
-		WHERE DATE(at.created_at) = CURRENT_DATE AND at.is_used = true
+		WHERE DATE(at.created_at) = $1::date AND at.is_used = true
+		  AND ($2 = 0 OR (d.manager_id = $2 AND u.id <> $2))
 		ORDER BY at.created_at ASC
-	`)
+	`, day, managerID)
 
 	if err != nil {
-		log.Printf("Error fetching today's attendance: %v", err)
+		slog.Error("Error fetching today's attendance", "error", err)
 		return types.TodayAttendanceListResponse{}, err
 	}
 	defer rows.Close()
@@ -262,13 +329,17 @@ func GetTodayAttendance() (types.TodayAttendanceListResponse, error) {
 			&attendance.IsUsed,
 		)
 		if err != nil {
-			log.Printf("Error scanning attendance row: %v", err)
+			slog.Error("Error scanning attendance row", "error", err)
 			continue
 		}
 
 		// Determine status (on-time or late)
-		checkInTime := attendance.CheckInTime.Format("15:04:05")
-		if checkInTime > toleranceTime {
+		result, err := evaluator.evaluate(attendance.CheckInTime.Format("15:04:05"))
+		if err != nil {
+			slog.Error("Error evaluating check-in", "user_id", attendance.UserID, "error", err)
+			return types.TodayAttendanceListResponse{}, err
+		}
+		if result.IsLate {
 			attendance.Status = "late"
 			totalLate++
 		} else {
@@ -279,7 +350,7 @@ func GetTodayAttendance() (types.TodayAttendanceListResponse, error) {
 	}
 
 	if err = rows.Err(); err != nil {
-		log.Printf("Error iterating attendance rows: %v", err)
+		slog.Error("Error iterating attendance rows", "error", err)
 		return types.TodayAttendanceListResponse{}, err
 	}
 
@@ -291,20 +362,21 @@ func GetTodayAttendance() (types.TodayAttendanceListResponse, error) {
 			u.name,
 			u.email,
 			d.name as department_name,
-			u.position
+			COALESCE(u.position, '')
 		FROM users u
 		JOIN departments d ON u.department_id = d.id
 		WHERE u.status = 'active'
+		  AND ($2 = 0 OR (d.manager_id = $2 AND u.id <> $2))
 		  AND u.id NOT IN (
 			  SELECT DISTINCT user_id 
 			  FROM attendance_tokens 
-			  WHERE DATE(created_at) = CURRENT_DATE AND is_used = true
+			  WHERE DATE(created_at) = $1::date AND is_used = true
 		  )
 		ORDER BY u.name ASC
-	`)
+	`, day, managerID)
 
 	if err != nil {
-		log.Printf("Error fetching absent users: %v", err)
+		slog.Error("Error fetching absent users", "error", err)
 		// Continue even if absent users query fails
 	} else {
 		defer absentRows.Close()
@@ -319,22 +391,19 @@ func GetTodayAttendance() (types.TodayAttendanceListResponse, error) {
 				&absentUser.Position,
 			)
 			if err != nil {
-				log.Printf("Error scanning absent user row: %v", err)
+				slog.Error("Error scanning absent user row", "error", err)
 				continue
 			}
 			absentUsers = append(absentUsers, absentUser)
 		}
 
 		if err = absentRows.Err(); err != nil {
-			log.Printf("Error iterating absent user rows: %v", err)
+			slog.Error("Error iterating absent user rows", "error", err)
 		}
 	}
 
-	// Format today's date
-	today := time.Now().Format("2006-01-02")
-
 	response := types.TodayAttendanceListResponse{
-		Date:        today,
+		Date:        day,
 		TotalAttend: len(attendances),
 		TotalLate:   totalLate,
 		TotalAbsent: len(absentUsers),
@@ -345,20 +414,19 @@ func GetTodayAttendance() (types.TodayAttendanceListResponse, error) {
 	return response, nil
 }
 
-func GetMonthlyAttendance() (types.MonthlyAttendanceListResponse, error) {
-	var attendances []types.TodayAttendance
+func GetMonthlyAttendance(month int, year int) (types.MonthlyAttendanceListResponse, error) {
+	return getMonthlyAttendance(month, year, 0)
+}
 
-	// Get tolerance time from work_hours
-	var toleranceTime string
-	err := database.DB.QueryRow(`
-		SELECT tolerance_time
-		FROM work_hours
-		ORDER BY id DESC
-		LIMIT 1
-	`).Scan(&toleranceTime)
+// getMonthlyAttendance mengambil rekap absensi bulanan. managerID 0 berarti semua
+// karyawan; selain itu hanya karyawan di departemen yang dipimpin manager tersebut.
+func getMonthlyAttendance(month int, year int, managerID int) (types.MonthlyAttendanceListResponse, error) {
+	var attendances []types.TodayAttendance
 
+	// Load work hours dan late policy yang berlaku
+	evaluator, err := newLateEvaluator()
 	if err != nil {
-		log.Printf("Error fetching work hours: %v", err)
+		slog.Error("Error loading late policy", "error", err)
 		return types.MonthlyAttendanceListResponse{}, err
 	}
 
@@ -368,21 +436,22 @@ func GetMonthlyAttendance() (types.MonthlyAttendanceListResponse, error) {
 			u.name,
 			u.email,
 			d.name as department_name,
-			u.position,
+			COALESCE(u.position, ''),
 			at.created_at,
 			at.token,
 			at.is_used
 		FROM attendance_tokens at
 		JOIN users u ON at.user_id = u.id
 		JOIN departments d ON u.department_id = d.id
-		WHERE EXTRACT(MONTH FROM at.created_at) = EXTRACT(MONTH FROM CURRENT_DATE)
-		  AND EXTRACT(YEAR FROM at.created_at) = EXTRACT(YEAR FROM CURRENT_DATE)
+		WHERE EXTRACT(MONTH FROM at.created_at) = $1
+		  AND EXTRACT(YEAR FROM at.created_at) = $2
 		  AND at.is_used = true
+		  AND ($3 = 0 OR (d.manager_id = $3 AND u.id <> $3))
 		ORDER BY at.created_at ASC
-	`)
+	`, month, year, managerID)
 
 	if err != nil {
-		log.Printf("Error fetching monthly attendance: %v", err)
+		slog.Error("Error fetching monthly attendance", "error", err)
 		return types.MonthlyAttendanceListResponse{}, err
 	}
 	defer rows.Close()
@@ -402,13 +471,17 @@ func GetMonthlyAttendance() (types.MonthlyAttendanceListResponse, error) {
 			&attendance.IsUsed,
 		)
 		if err != nil {
-			log.Printf("Error scanning attendance row: %v", err)
+			slog.Error("Error scanning attendance row", "error", err)
 			continue
 		}
 
 		// Determine status (on-time or late)
-		checkInTime := attendance.CheckInTime.Format("15:04:05")
-		if checkInTime > toleranceTime {
+		result, err := evaluator.evaluate(attendance.CheckInTime.Format("15:04:05"))
+		if err != nil {
+			slog.Error("Error evaluating check-in", "user_id", attendance.UserID, "error", err)
+			return types.MonthlyAttendanceListResponse{}, err
+		}
+		if result.IsLate {
 			attendance.Status = "late"
 			totalLate++
 		} else {
@@ -419,70 +492,132 @@ func GetMonthlyAttendance() (types.MonthlyAttendanceListResponse, error) {
 	}
 
 	if err = rows.Err(); err != nil {
-		log.Printf("Error iterating attendance rows: %v", err)
+		slog.Error("Error iterating attendance rows", "error", err)
 		return types.MonthlyAttendanceListResponse{}, err
 	}
 
-	// Get absent users (active users who haven't attended at all this month)
-	var absentUsers []types.AbsentUser
-	absentRows, err := database.DB.Query(`
+	// Build matriks per user: satu kolom untuk setiap hari kerja yang sudah berjalan
+	now := time.Now()
+	workingDays := monthWorkingDays(year, month, now)
+
+	lastClosedDay, err := lastClosedWorkDay(now)
+	if err != nil {
+		slog.Error("Error fetching work hours", "error", err)
+		return types.MonthlyAttendanceListResponse{}, err
+	}
+
+	holidays, err := getHolidays(year, month)
+	if err != nil {
+		slog.Error("Error fetching holidays", "error", err)
+		return types.MonthlyAttendanceListResponse{}, err
+	}
+
+	leaves, err := getApprovedLeaveDays(0, year, month)
+	if err != nil {
+		slog.Error("Error fetching leave requests", "error", err)
+		return types.MonthlyAttendanceListResponse{}, err
+	}
+
+	checkIns, err := getFirstCheckIns(0, year, month)
+	if err != nil {
+		slog.Error("Error fetching monthly check-ins", "error", err)
+		return types.MonthlyAttendanceListResponse{}, err
+	}
+
+	userRows, err := database.DB.Query(`
 		SELECT 
 			u.id,
 			u.name,
 			u.email,
 			d.name as department_name,
-			u.position
+			COALESCE(u.position, ''),
+			COALESCE(TO_CHAR(u.created_at, 'YYYY-MM-DD'), '') as hired_on
 		FROM users u
 		JOIN departments d ON u.department_id = d.id
 		WHERE u.status = 'active'
-		  AND u.id NOT IN (
-			  SELECT DISTINCT user_id 
-			  FROM attendance_tokens 
-			  WHERE EXTRACT(MONTH FROM created_at) = EXTRACT(MONTH FROM CURRENT_DATE)
-				AND EXTRACT(YEAR FROM created_at) = EXTRACT(YEAR FROM CURRENT_DATE)
-				AND is_used = true
-		  )
+		  AND ($1 = 0 OR (d.manager_id = $1 AND u.id <> $1))
 		ORDER BY u.name ASC
-	`)
+	`, managerID)
 
 	if err != nil {
-		log.Printf("Error fetching absent users: %v", err)
-		// Continue even if absent users query fails
-	} else {
-		defer absentRows.Close()
+		slog.Error("Error fetching active users", "error", err)
+		return types.MonthlyAttendanceListResponse{}, err
+	}
+	defer userRows.Close()
 
-		for absentRows.Next() {
-			var absentUser types.AbsentUser
-			err := absentRows.Scan(
-				&absentUser.UserID,
-				&absentUser.UserName,
-				&absentUser.UserEmail,
-				&absentUser.DepartmentName,
-				&absentUser.Position,
-			)
+	matrix := []types.MonthlyAttendanceMatrixRow{}
+	var absentUsers []types.AbsentUser
+	totalAbsent := 0
+
+	for userRows.Next() {
+		var row types.MonthlyAttendanceMatrixRow
+		var hiredOn string
+		err := userRows.Scan(
+			&row.UserID,
+			&row.UserName,
+			&row.UserEmail,
+			&row.DepartmentName,
+			&row.Position,
+			&hiredOn,
+		)
+		if err != nil {
+			slog.Error("Error scanning user row", "error", err)
+			continue
+		}
+
+		row.Days = make(map[string]string, len(workingDays))
+		for _, day := range workingDays {
+			status, err := resolveDayStatus(day, holidays, leaves[row.UserID], checkIns[row.UserID], evaluator)
 			if err != nil {
-				log.Printf("Error scanning absent user row: %v", err)
+				slog.Error("Error resolving day status", "user_id", row.UserID, "error", err)
+				return types.MonthlyAttendanceListResponse{}, err
+			}
+			if status == DayStatusAbsent && !absenceCountable(day, hiredOn, lastClosedDay) {
 				continue
 			}
-			absentUsers = append(absentUsers, absentUser)
+			row.Days[day] = status
+
+			switch status {
+			case DayStatusPresent:
+				row.TotalPresent++
+			case DayStatusLate:
+				row.TotalPresent++
+				row.TotalLate++
+			case DayStatusLeave:
+				row.TotalLeave++
+			case DayStatusAbsent:
+				row.TotalAbsent++
+			}
 		}
 
-		if err = absentRows.Err(); err != nil {
-			log.Printf("Error iterating absent user rows: %v", err)
+		if row.TotalAbsent > 0 {
+			absentUsers = append(absentUsers, types.AbsentUser{
+				UserID:         row.UserID,
+				UserName:       row.UserName,
+				UserEmail:      row.UserEmail,
+				DepartmentName: row.DepartmentName,
+				Position:       row.Position,
+				AbsentDays:     row.TotalAbsent,
+			})
+			totalAbsent += row.TotalAbsent
 		}
+
+		matrix = append(matrix, row)
 	}
 
-	// Format current month and year
-	now := time.Now()
-	month := now.Format("01")  // MM format
-	year := now.Format("2006") // YYYY format
+	if err = userRows.Err(); err != nil {
+		slog.Error("Error iterating user rows", "error", err)
+		return types.MonthlyAttendanceListResponse{}, err
+	}
 
 	response := types.MonthlyAttendanceListResponse{
-		Month:       month,
-		Year:        year,
+		Month:       fmt.Sprintf("%02d", month),
+		Year:        fmt.Sprintf("%d", year),
 		TotalAttend: len(attendances),
 		TotalLate:   totalLate,
-		TotalAbsent: len(absentUsers),
+		TotalAbsent: totalAbsent,
+		WorkingDays: workingDays,
+		Matrix:      matrix,
 		Attendances: attendances,
 		AbsentUsers: absentUsers,
 	}
@@ -491,17 +626,10 @@ func GetMonthlyAttendance() (types.MonthlyAttendanceListResponse, error) {
 }
 
 func GetEmployeeMonthlyAttendance(userID int, month int, year int) (types.EmployeeMonthlyAttendanceResponse, error) {
-	// Get tolerance time
-	var toleranceTime string
-	err := database.DB.QueryRow(`
-		SELECT tolerance_time
-		FROM work_hours
-		ORDER BY id DESC
-		LIMIT 1
-	`).Scan(&toleranceTime)
-
+	// Load work hours dan late policy yang berlaku
+	evaluator, err := newLateEvaluator()
 	if err != nil {
-		log.Printf("Error fetching work hours: %v", err)
+		slog.Error("Error loading late policy", "error", err)
 		return types.EmployeeMonthlyAttendanceResponse{}, err
 	}
 
@@ -520,7 +648,7 @@ func GetEmployeeMonthlyAttendance(userID int, month int, year int) (types.Employ
 	`, userID, month, year)
 
 	if err != nil {
-		log.Printf("Error fetching employee attendance: %v", err)
+		slog.Error("Error fetching employee attendance", "error", err)
 		return types.EmployeeMonthlyAttendanceResponse{}, err
 	}
 	defer rows.Close()
@@ -535,39 +663,86 @@ func GetEmployeeMonthlyAttendance(userID int, month int, year int) (types.Employ
 
 		err := rows.Scan(&date, &checkInTime, &isUsed)
 		if err != nil {
-			log.Printf("Error scanning attendance row: %v", err)
+			slog.Error("Error scanning attendance row", "error", err)
 			continue
 		}
 
+		result, err := evaluator.evaluate(checkInTime)
+		if err != nil {
+			slog.Error("Error evaluating check-in", "date", date, "user_id", userID, "error", err)
+			return types.EmployeeMonthlyAttendanceResponse{}, err
+		}
+
 		status := "on-time"
-		if checkInTime > toleranceTime {
+		if result.IsLate {
 			status = "late"
-			// Calculate late minutes
-			lateMinutes := calculateLateMinutes(checkInTime, toleranceTime)
-			totalLateMinutes += lateMinutes
+			totalLateMinutes += result.Minutes
 		}
 
 		attendance := types.EmployeeAttendance{
-			Date:        date,
-			CheckInTime: checkInTime,
-			Status:      status,
+			Date:         date,
+			CheckInTime:  checkInTime,
+			Status:       status,
+			LateMinutes:  result.Minutes,
+			LateCategory: result.Category,
 		}
 		attendances = append(attendances, attendance)
 	}
 
 	if err = rows.Err(); err != nil {
-		log.Printf("Error iterating attendance rows: %v", err)
+		slog.Error("Error iterating attendance rows", "error", err)
 		return types.EmployeeMonthlyAttendanceResponse{}, err
 	}
 
-	// Calculate total working days in the month (weekdays)
-	totalWorkingDays := calculateWorkingDaysInMonth(year, month)
+	// Hitung absen per hari kerja (hari libur dan cuti yang disetujui tidak dihitung absen)
+	holidays, err := getHolidays(year, month)
+	if err != nil {
+		slog.Error("Error fetching holidays", "error", err)
+		return types.EmployeeMonthlyAttendanceResponse{}, err
+	}
+
+	leaves, err := getApprovedLeaveDays(userID, year, month)
+	if err != nil {
+		slog.Error("Error fetching leave requests", "error", err)
+		return types.EmployeeMonthlyAttendanceResponse{}, err
+	}
+
+	checkIns, err := getFirstCheckIns(userID, year, month)
+	if err != nil {
+		slog.Error("Error fetching monthly check-ins", "error", err)
+		return types.EmployeeMonthlyAttendanceResponse{}, err
+	}
+
+	var hiredOn string
+	err = database.DB.QueryRow(`
+		SELECT COALESCE(TO_CHAR(created_at, 'YYYY-MM-DD'), '') FROM users WHERE id = $1
+	`, userID).Scan(&hiredOn)
+	if err != nil && err != sql.ErrNoRows {
+		slog.Error("Error fetching user", "user_id", userID, "error", err)
+		return types.EmployeeMonthlyAttendanceResponse{}, err
+	}
+
+	now := time.Now()
+	lastClosedDay, err := lastClosedWorkDay(now)
+	if err != nil {
+		slog.Error("Error fetching work hours", "error", err)
+		return types.EmployeeMonthlyAttendanceResponse{}, err
+	}
 
 	// Total present is the number of attendance records
 	totalPresent := len(attendances)
 
-	// Total absent is working days minus present days
-	totalAbsent := totalWorkingDays - totalPresent
+	totalAbsent := 0
+	for _, day := range monthWorkingDays(year, month, now) {
+		status, err := resolveDayStatus(day, holidays, leaves[userID], checkIns[userID], evaluator)
+		if err != nil {
+			slog.Error("Error resolving day status", "user_id", userID, "error", err)
+			return types.EmployeeMonthlyAttendanceResponse{}, err
+		}
+		if status == DayStatusAbsent && absenceCountable(day, hiredOn, lastClosedDay) {
+			totalAbsent++
+		}
+	}
 
 	// Convert total late minutes to HH:MM format
 	totalLateHours := formatMinutesToHHMM(totalLateMinutes)
@@ -584,41 +759,185 @@ func GetEmployeeMonthlyAttendance(userID int, month int, year int) (types.Employ
 	return response, nil
 }
 
-func calculateLateMinutes(checkInTime, toleranceTime string) int {
-	// Parse times
-	checkIn, err1 := time.Parse("15:04:05", checkInTime)
-	tolerance, err2 := time.Parse("15:04:05", toleranceTime)
-
-	if err1 != nil || err2 != nil {
-		return 0
-	}
-
-	if checkIn.After(tolerance) {
-		diff := checkIn.Sub(tolerance)
-		return int(diff.Minutes())
-	}
-
-	return 0
-}
-
-func calculateWorkingDaysInMonth(year, month int) int {
+// monthWorkingDays mengembalikan hari kerja (Senin-Jumat) dalam format YYYY-MM-DD.
+// Untuk bulan berjalan hanya hari sampai hari ini yang dikembalikan, sehingga
+// hari yang belum terjadi tidak dihitung sebagai absen.
+func monthWorkingDays(year, month int, now time.Time) []string {
 	// Create time for first day of month
 	firstDay := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
 
 	// Get last day of month
 	lastDay := firstDay.AddDate(0, 1, -1)
 
-	workingDays := 0
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if today.Before(lastDay) {
+		lastDay = today
+	}
+
+	workingDays := []string{}
 	for d := firstDay; d.Before(lastDay) || d.Equal(lastDay); d = d.AddDate(0, 0, 1) {
 		// Skip weekends (Saturday = 6, Sunday = 0)
 		if d.Weekday() != time.Saturday && d.Weekday() != time.Sunday {
-			workingDays++
+			workingDays = append(workingDays, d.Format("2006-01-02"))
 		}
 	}
 
 	return workingDays
 }
 
+// lastClosedWorkDay mengembalikan hari terakhir (YYYY-MM-DD) yang jam kerjanya
+// sudah selesai: hari ini jika sudah lewat work_end_time, selain itu kemarin.
+// Sebelum jam kerja berakhir, user yang belum check-in belum bisa dianggap absen.
+func lastClosedWorkDay(now time.Time) (string, error) {
+	workHours, err := GetWorkHours()
+	if err != nil {
+		return "", err
+	}
+
+	workEnd, err := parseClock(workHours.WorkEndTime)
+	if err != nil {
+		return "", fmt.Errorf("work_end_time tidak valid: %w", err)
+	}
+
+	closesAt := time.Date(now.Year(), now.Month(), now.Day(), workEnd.Hour(), workEnd.Minute(), workEnd.Second(), 0, now.Location())
+	if now.Before(closesAt) {
+		return now.AddDate(0, 0, -1).Format("2006-01-02"), nil
+	}
+	return now.Format("2006-01-02"), nil
+}
+
+// absenceCountable mengecek apakah absen pada suatu hari boleh dihitung: tidak
+// sebelum user terdaftar (hiredOn, kosong jika tidak diketahui) dan tidak
+// setelah hari terakhir yang jam kerjanya sudah selesai. Semua tanggal YYYY-MM-DD.
+func absenceCountable(day, hiredOn, lastClosedDay string) bool {
+	return day >= hiredOn && day <= lastClosedDay
+}
+
+// resolveDayStatus menentukan status satu hari kerja untuk seorang user.
+// Kehadiran diutamakan, lalu hari libur, cuti, dan terakhir absen.
+func resolveDayStatus(day string, holidays map[string]string, leaveDays map[string]bool, checkIns map[string]string, evaluator *lateEvaluator) (string, error) {
+	if checkInTime, ok := checkIns[day]; ok {
+		result, err := evaluator.evaluate(checkInTime)
+		if err != nil {
+			return "", err
+		}
+		if result.IsLate {
+			return DayStatusLate, nil
+		}
+		return DayStatusPresent, nil
+	}
+
+	if _, ok := holidays[day]; ok {
+		return DayStatusHoliday, nil
+	}
+
+	if leaveDays[day] {
+		return DayStatusLeave, nil
+	}
+
+	return DayStatusAbsent, nil
+}
+
+// getHolidays mengambil hari libur pada bulan tertentu (tanggal -> nama)
+func getHolidays(year, month int) (map[string]string, error) {
+	rows, err := database.DB.Query(`
+		SELECT TO_CHAR(date, 'YYYY-MM-DD'), name
+		FROM holidays
+		WHERE EXTRACT(MONTH FROM date) = $1
+		  AND EXTRACT(YEAR FROM date) = $2
+	`, month, year)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	holidays := make(map[string]string)
+	for rows.Next() {
+		var date, name string
+		if err := rows.Scan(&date, &name); err != nil {
+			return nil, err
+		}
+		holidays[date] = name
+	}
+
+	return holidays, rows.Err()
+}
+
+// getApprovedLeaveDays mengambil hari cuti yang sudah disetujui pada bulan tertentu,
+// dikelompokkan per user. userID 0 berarti semua user.
+func getApprovedLeaveDays(userID, year, month int) (map[int]map[string]bool, error) {
+	firstDay := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := firstDay.AddDate(0, 1, -1)
+
+	rows, err := database.DB.Query(`
+		SELECT user_id, TO_CHAR(day, 'YYYY-MM-DD')
+		FROM leave_requests lr,
+		     generate_series(GREATEST(lr.start_date, $1::date), LEAST(lr.end_date, $2::date), INTERVAL '1 day') AS day
+		WHERE lr.status = 'approved'
+		  AND lr.start_date <= $2::date
+		  AND lr.end_date >= $1::date
+		  AND ($3 = 0 OR lr.user_id = $3)
+	`, firstDay.Format("2006-01-02"), lastDay.Format("2006-01-02"), userID)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	leaves := make(map[int]map[string]bool)
+	for rows.Next() {
+		var leaveUserID int
+		var day string
+		if err := rows.Scan(&leaveUserID, &day); err != nil {
+			return nil, err
+		}
+		if leaves[leaveUserID] == nil {
+			leaves[leaveUserID] = make(map[string]bool)
+		}
+		leaves[leaveUserID][day] = true
+	}
+
+	return leaves, rows.Err()
+}
+
+// getFirstCheckIns mengambil jam check-in pertama setiap hari pada bulan tertentu,
+// dikelompokkan per user (user -> tanggal -> HH:MM:SS). userID 0 berarti semua user.
+func getFirstCheckIns(userID, year, month int) (map[int]map[string]string, error) {
+	rows, err := database.DB.Query(`
+		SELECT 
+			user_id,
+			TO_CHAR(DATE(created_at), 'YYYY-MM-DD'),
+			TO_CHAR(MIN(created_at), 'HH24:MI:SS')
+		FROM attendance_tokens
+		WHERE is_used = true
+		  AND EXTRACT(MONTH FROM created_at) = $1
+		  AND EXTRACT(YEAR FROM created_at) = $2
+		  AND ($3 = 0 OR user_id = $3)
+		GROUP BY user_id, DATE(created_at)
+	`, month, year, userID)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checkIns := make(map[int]map[string]string)
+	for rows.Next() {
+		var checkInUserID int
+		var day, checkInTime string
+		if err := rows.Scan(&checkInUserID, &day, &checkInTime); err != nil {
+			return nil, err
+		}
+		if checkIns[checkInUserID] == nil {
+			checkIns[checkInUserID] = make(map[string]string)
+		}
+		checkIns[checkInUserID][day] = checkInTime
+	}
+
+	return checkIns, rows.Err()
+}
+
 func formatMinutesToHHMM(minutes int) string {
 	hours := minutes / 60
 	mins := minutes % 60
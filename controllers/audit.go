@@ -0,0 +1,454 @@
+package controllers
+
+import (
+	"backend/database"
+	"backend/types"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Aksi pada audit log
+const (
+	AuditActionUserUpdate             = "user.update"
+	AuditActionUserRolesUpdate        = "user.roles.update"
+	AuditActionLogin                  = "auth.login"
+	AuditActionLoginFailed            = "auth.login_failed"
+	AuditActionAttendanceSubmit       = "attendance.submit"
+	AuditActionAttendanceCorrection   = "attendance.correction_applied"
+	AuditActionWorkHoursUpdate        = "work_hours.update"
+	AuditActionRolePermissionsUpdate  = "role.permissions.update"
+	AuditActionRoleRequireTwoFAUpdate = "role.require_2fa.update"
+	AuditActionAPIKeyCreate           = "api_key.create"
+	AuditActionAPIKeyRevoke           = "api_key.revoke"
+)
+
+// Jenis entitas pada audit log
+const (
+	AuditEntityUser            = "user"
+	AuditEntityRole            = "role"
+	AuditEntityAttendanceToken = "attendance_token"
+	AuditEntityWorkHours       = "work_hours"
+	AuditEntityAPIKey          = "api_key"
+)
+
+const (
+	defaultAuditLogLimit = 100
+	maxAuditLogLimit     = 500
+)
+
+// AuditEntry adalah data satu aksi yang dicatat ke audit log.
+// ActorID/EntityID 0 berarti tidak ada. Before dan After di-encode ke JSON.
+type AuditEntry struct {
+	ActorID    int
+	Action     string
+	EntityType string
+	EntityID   int
+	Before     interface{}
+	After      interface{}
+	IP         string
+}
+
+// auditChainLockID adalah key pg_advisory_xact_lock untuk menulis audit log.
+// Penulisan diserialkan agar setiap baris merujuk hash baris sebelumnya.
+const auditChainLockID = 4903001
+
+// auditGenesisHash adalah prev_hash untuk baris audit pertama
+var auditGenesisHash = strings.Repeat("0", 64)
+
+// auditTimeLayout adalah format timestamp yang ikut di-hash (created_at baris
+// audit maupun waktu di data before/after), presisi mikrodetik mengikuti kolom
+// TIMESTAMP PostgreSQL
+const auditTimeLayout = "2006-01-02T15:04:05.000000"
+
+// RecordAudit mencatat aksi ke audit log. Kegagalan mencatat hanya di-log agar
+// tidak menggagalkan proses utama (dipakai untuk aksi di luar transaction,
+// misalnya login).
+func RecordAudit(entry AuditEntry) {
+	err := func() error {
+		tx, err := database.DB.Begin()
+		if err != nil {
+			return fmt.Errorf("gagal memulai transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		if err := insertAudit(tx, entry); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}()
+	if err != nil {
+		slog.Error("Failed to record audit log", "action", entry.Action, "error", err)
+	}
+}
+
+// recordAuditTx mencatat aksi ke audit log di dalam transaction yang sama dengan
+// perubahan datanya, sehingga perubahan tidak bisa tersimpan tanpa jejak audit
+func recordAuditTx(tx *sql.Tx, entry AuditEntry) error {
+	if err := insertAudit(tx, entry); err != nil {
+		return fmt.Errorf("gagal mencatat audit log: %w", err)
+	}
+	return nil
+}
+
+// insertAudit menambahkan baris audit ke hash chain: hash setiap baris dihitung
+// dari isi baris beserta hash baris sebelumnya, sehingga perubahan atau
+// penghapusan baris lama akan memutus rantai (lihat VerifyAuditChain)
+func insertAudit(tx *sql.Tx, entry AuditEntry) error {
+	before, err := auditJSON(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := auditJSON(entry.After)
+	if err != nil {
+		return err
+	}
+
+	// Lock dilepas otomatis saat transaction selesai
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, auditChainLockID); err != nil {
+		return fmt.Errorf("gagal mengunci audit chain: %w", err)
+	}
+
+	prevHash := auditGenesisHash
+	err = tx.QueryRow(`SELECT hash FROM audit_logs ORDER BY id DESC LIMIT 1`).Scan(&prevHash)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("gagal mengambil hash audit terakhir: %w", err)
+	}
+
+	// Presisi mikrodetik mengikuti kolom TIMESTAMP PostgreSQL
+	createdAt := time.Now().Truncate(time.Microsecond)
+
+	hash := auditHash(prevHash, auditRecord{
+		ActorID:    entry.ActorID,
+		Action:     entry.Action,
+		EntityType: entry.EntityType,
+		EntityID:   entry.EntityID,
+		Before:     before,
+		After:      after,
+		IP:         entry.IP,
+		CreatedAt:  createdAt,
+	})
+
+	_, err = tx.Exec(`
+		INSERT INTO audit_logs (actor_id, action, entity_type, entity_id, before_data, after_data, ip, created_at, prev_hash, hash)
+		VALUES (NULLIF($1, 0), $2, $3, NULLIF($4, 0), $5, $6, NULLIF($7, ''), $8, $9, $10)
+	`, entry.ActorID, entry.Action, entry.EntityType, entry.EntityID, nullableText(before), nullableText(after), entry.IP, createdAt, prevHash, hash)
+	return err
+}
+
+// auditRecord adalah isi baris audit yang ikut di-hash. Nilai NULL di database
+// direpresentasikan sebagai nilai kosong (0 atau "").
+type auditRecord struct {
+	ActorID    int
+	Action     string
+	EntityType string
+	EntityID   int
+	Before     string
+	After      string
+	IP         string
+	CreatedAt  time.Time
+}
+
+// auditHash menghitung SHA-256 (hex) dari hash sebelumnya dan isi baris.
+// Setiap field dipisah baris baru dan diberi panjang agar batas field tidak ambigu.
+func auditHash(prevHash string, rec auditRecord) string {
+	fields := []string{
+		prevHash,
+		strconv.Itoa(rec.ActorID),
+		rec.Action,
+		rec.EntityType,
+		strconv.Itoa(rec.EntityID),
+		rec.Before,
+		rec.After,
+		rec.IP,
+		rec.CreatedAt.Format(auditTimeLayout),
+	}
+
+	h := sha256.New()
+	for _, field := range fields {
+		fmt.Fprintf(h, "%d:%s\n", len(field), field)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// auditJSON meng-encode nilai before/after; nil menjadi string kosong (NULL).
+// Kolom bertipe JSON (bukan JSONB) sehingga teks yang disimpan sama persis
+// dengan teks yang di-hash.
+func auditJSON(value interface{}) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("gagal encode data audit: %w", err)
+	}
+	return string(data), nil
+}
+
+func nullableText(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// VerifyAuditChain menelusuri seluruh audit log dari baris pertama dan
+// menghitung ulang hash setiap baris. Berhenti di mata rantai pertama yang rusak.
+func VerifyAuditChain() (types.AuditChainVerification, error) {
+	rows, err := database.DB.Query(`
+		SELECT
+			id, COALESCE(actor_id, 0), action, entity_type, COALESCE(entity_id, 0),
+			COALESCE(before_data::text, ''), COALESCE(after_data::text, ''), COALESCE(ip, ''),
+			created_at, prev_hash, hash
+		FROM audit_logs
+		ORDER BY id ASC
+	`)
+	if err != nil {
+		return types.AuditChainVerification{}, fmt.Errorf("gagal query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	result := types.AuditChainVerification{Valid: true}
+	expectedPrev := auditGenesisHash
+
+	for rows.Next() {
+		var id int64
+		var rec auditRecord
+		var prevHash, hash string
+		err := rows.Scan(
+			&id, &rec.ActorID, &rec.Action, &rec.EntityType, &rec.EntityID,
+			&rec.Before, &rec.After, &rec.IP, &rec.CreatedAt, &prevHash, &hash,
+		)
+		if err != nil {
+			return types.AuditChainVerification{}, err
+		}
+
+		if prevHash != expectedPrev {
+			result.Valid = false
+			result.BrokenAtID = &id
+			result.Reason = "prev_hash tidak sama dengan hash baris sebelumnya (baris dihapus, disisipkan atau diubah urutannya)"
+			return result, nil
+		}
+
+		if computed := auditHash(prevHash, rec); computed != hash {
+			result.Valid = false
+			result.BrokenAtID = &id
+			result.Reason = "isi baris tidak sesuai dengan hash-nya (baris diubah)"
+			return result, nil
+		}
+
+		result.CheckedEntries++
+		result.LastID = id
+		result.LastHash = hash
+		expectedPrev = hash
+	}
+	if err := rows.Err(); err != nil {
+		return types.AuditChainVerification{}, err
+	}
+
+	if err := verifyAttendanceTokens(&result); err != nil {
+		return types.AuditChainVerification{}, err
+	}
+	return result, nil
+}
+
+// verifyAttendanceTokens mencocokkan token absensi yang terpakai dengan baris
+// audit-nya (absensi atau koreksi). Hash chain hanya melindungi audit_logs,
+// sehingga tanpa pengecekan ini token yang ditandai terpakai langsung di
+// database atau jam absensinya diubah tidak akan terdeteksi. Token yang
+// ditukar sebelum audit log ada (redeemed_at kosong dan dibuat sebelum baris
+// audit pertama) dilewati.
+func verifyAttendanceTokens(result *types.AuditChainVerification) error {
+	rows, err := database.DB.Query(`
+		SELECT at.id, at.user_id, COALESCE(at.is_used, false), at.created_at, at.redeemed_at,
+			COALESCE(a.action, ''), COALESCE(a.after_data::text, '')
+		FROM attendance_tokens at
+		LEFT JOIN audit_logs a
+			ON a.entity_type = $1 AND a.entity_id = at.id AND a.action IN ($2, $3)
+		WHERE a.id IS NOT NULL
+		   OR (at.is_used AND (at.redeemed_at IS NOT NULL OR at.created_at >= (SELECT MIN(created_at) FROM audit_logs)))
+		ORDER BY at.id, a.id
+	`, AuditEntityAttendanceToken, AuditActionAttendanceSubmit, AuditActionAttendanceCorrection)
+	if err != nil {
+		return fmt.Errorf("gagal query token absensi: %w", err)
+	}
+	defer rows.Close()
+
+	seen := make(map[int]bool)
+	for rows.Next() {
+		var tokenID, userID int
+		var isUsed bool
+		var createdAt, redeemedAt sql.NullTime
+		var action, after string
+		if err := rows.Scan(&tokenID, &userID, &isUsed, &createdAt, &redeemedAt, &action, &after); err != nil {
+			return err
+		}
+
+		reason := attendanceTokenMismatch(userID, isUsed, createdAt, redeemedAt, action, after)
+		if seen[tokenID] {
+			reason = "tercatat ditukar lebih dari sekali di audit log"
+		}
+		seen[tokenID] = true
+
+		if reason == "" {
+			result.CheckedAttendanceTokens++
+			continue
+		}
+
+		if result.Valid {
+			result.Valid = false
+			result.Reason = fmt.Sprintf("token absensi ID %d %s", tokenID, reason)
+		}
+		result.AttendanceTokenMismatches = append(result.AttendanceTokenMismatches, tokenID)
+	}
+
+	return rows.Err()
+}
+
+// attendanceTokenMismatch mengembalikan alasan token tidak sesuai dengan data
+// audit-nya (kosong jika sesuai). Baris audit lama yang belum menyimpan
+// created_at/redeemed_at hanya dicocokkan user-nya.
+func attendanceTokenMismatch(userID int, isUsed bool, createdAt, redeemedAt sql.NullTime, action string, after string) string {
+	if action == "" {
+		return "terpakai tanpa jejak di audit log"
+	}
+	if !isUsed {
+		return "ditandai belum terpakai padahal tercatat ditukar di audit log"
+	}
+
+	var recorded struct {
+		UserID     int    `json:"user_id"`
+		CreatedAt  string `json:"created_at"`
+		RedeemedAt string `json:"redeemed_at"`
+		CheckIn    string `json:"check_in"`
+	}
+	if err := json.Unmarshal([]byte(after), &recorded); err != nil {
+		return "memiliki data audit yang tidak terbaca"
+	}
+
+	formatTime := func(t sql.NullTime, layout string) string {
+		if !t.Valid {
+			return ""
+		}
+		return t.Time.Format(layout)
+	}
+
+	switch {
+	case recorded.UserID != userID:
+		return "memiliki user yang berbeda dengan audit log"
+	case recorded.CreatedAt != "" && recorded.CreatedAt != formatTime(createdAt, auditTimeLayout),
+		recorded.CheckIn != "" && recorded.CheckIn != formatTime(createdAt, "2006-01-02 15:04:05"):
+		return "memiliki waktu check-in yang berbeda dengan audit log"
+	case recorded.RedeemedAt != "" && recorded.RedeemedAt != formatTime(redeemedAt, auditTimeLayout):
+		return "memiliki waktu penukaran yang berbeda dengan audit log"
+	}
+	return ""
+}
+
+// GetAuditLogs mengambil audit log terbaru sesuai filter
+func GetAuditLogs(filter types.AuditLogFilter) (types.AuditLogListResponse, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLogLimit
+	}
+	if filter.Limit > maxAuditLogLimit {
+		filter.Limit = maxAuditLogLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	query := `
+		SELECT
+			a.id, a.actor_id, u.name, a.action, a.entity_type, a.entity_id,
+			a.before_data, a.after_data, a.ip, a.created_at, a.prev_hash, a.hash
+		FROM audit_logs a
+		LEFT JOIN users u ON u.id = a.actor_id
+	`
+	conditions := []string{}
+	args := []interface{}{}
+
+	if filter.ActorID != 0 {
+		args = append(args, filter.ActorID)
+		conditions = append(conditions, fmt.Sprintf("a.actor_id = $%d", len(args)))
+	}
+	if filter.Action != "" {
+		args = append(args, filter.Action)
+		conditions = append(conditions, fmt.Sprintf("a.action = $%d", len(args)))
+	}
+	if filter.EntityType != "" {
+		args = append(args, filter.EntityType)
+		conditions = append(conditions, fmt.Sprintf("a.entity_type = $%d", len(args)))
+	}
+	if filter.EntityID != 0 {
+		args = append(args, filter.EntityID)
+		conditions = append(conditions, fmt.Sprintf("a.entity_id = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("a.created_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("a.created_at < $%d", len(args)))
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY a.created_at DESC, a.id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return types.AuditLogListResponse{}, fmt.Errorf("gagal query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	logs := []types.AuditLog{}
+	for rows.Next() {
+		var entry types.AuditLog
+		var before, after []byte
+		err := rows.Scan(
+			&entry.ID,
+			&entry.ActorID,
+			&entry.ActorName,
+			&entry.Action,
+			&entry.EntityType,
+			&entry.EntityID,
+			&before,
+			&after,
+			&entry.IP,
+			&entry.CreatedAt,
+			&entry.PrevHash,
+			&entry.Hash,
+		)
+		if err != nil {
+			return types.AuditLogListResponse{}, err
+		}
+		entry.Before = nullableJSON(before)
+		entry.After = nullableJSON(after)
+		logs = append(logs, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return types.AuditLogListResponse{}, err
+	}
+
+	return types.AuditLogListResponse{
+		AuditLogs: logs,
+		Limit:     filter.Limit,
+		Offset:    filter.Offset,
+	}, nil
+}
+
+func nullableJSON(data []byte) json.RawMessage {
+	if data == nil {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(data)
+}
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"backend/database"
+	"backend/types"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestAttendanceTokenMismatch(t *testing.T) {
+	created := sql.NullTime{Time: time.Date(2026, 3, 2, 8, 0, 1, 123456000, time.UTC), Valid: true}
+	redeemed := sql.NullTime{Time: time.Date(2026, 3, 2, 8, 0, 20, 654321000, time.UTC), Valid: true}
+	submitted := `{"created_at":"2026-03-02T08:00:01.123456","is_used":true,"redeemed_at":"2026-03-02T08:00:20.654321","user_id":5}`
+
+	tests := []struct {
+		name     string
+		userID   int
+		isUsed   bool
+		created  sql.NullTime
+		redeemed sql.NullTime
+		action   string
+		after    string
+		mismatch bool
+	}{
+		{"absensi sesuai", 5, true, created, redeemed, AuditActionAttendanceSubmit, submitted, false},
+		{"koreksi sesuai", 5, true, sql.NullTime{Time: time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC), Valid: true}, redeemed,
+			AuditActionAttendanceCorrection, `{"check_in":"2026-03-02 08:00:00","correction_id":3,"redeemed_at":"2026-03-02T08:00:20.654321","user_id":5}`, false},
+		{"audit lama tanpa waktu", 5, true, created, redeemed, AuditActionAttendanceSubmit, `{"is_used":true,"user_id":5}`, false},
+		{"tanpa audit", 5, true, created, redeemed, "", "", true},
+		{"di-reset menjadi belum terpakai", 5, false, created, redeemed, AuditActionAttendanceSubmit, submitted, true},
+		{"user diganti", 6, true, created, redeemed, AuditActionAttendanceSubmit, submitted, true},
+		{"jam check-in dimundurkan", 5, true, sql.NullTime{Time: created.Time.Add(-time.Hour), Valid: true}, redeemed, AuditActionAttendanceSubmit, submitted, true},
+		{"jam penukaran diubah", 5, true, created, sql.NullTime{Time: redeemed.Time.Add(-time.Hour), Valid: true}, AuditActionAttendanceSubmit, submitted, true},
+		{"redeemed_at dihapus", 5, true, created, sql.NullTime{}, AuditActionAttendanceSubmit, submitted, true},
+		{"jam koreksi diubah", 5, true, created, redeemed,
+			AuditActionAttendanceCorrection, `{"check_in":"2026-03-02 08:00:00","correction_id":3,"user_id":5}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := attendanceTokenMismatch(tt.userID, tt.isUsed, tt.created, tt.redeemed, tt.action, tt.after)
+			if (reason != "") != tt.mismatch {
+				t.Fatalf("attendanceTokenMismatch = %q, want mismatch %v", reason, tt.mismatch)
+			}
+		})
+	}
+}
+
+// createTestToken membuat token absensi yang masih berlaku untuk user dan
+// menghapusnya setelah test (sebelum user-nya dihapus)
+func createTestToken(t *testing.T, userID int) (int, string) {
+	t.Helper()
+
+	token := fmt.Sprintf("test-token-%d", time.Now().UnixNano())
+	var tokenID int
+	err := database.DB.QueryRow(`
+		INSERT INTO attendance_tokens (user_id, token, expired_at, is_used, created_at)
+		VALUES ($1, $2, NOW() + INTERVAL '1 minute', false, NOW())
+		RETURNING id
+	`, userID, token).Scan(&tokenID)
+	if err != nil {
+		t.Fatalf("gagal membuat token test: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if _, err := database.DB.Exec(`DELETE FROM attendance_tokens WHERE id = $1`, tokenID); err != nil {
+			t.Errorf("gagal menghapus token test: %v", err)
+		}
+	})
+	return tokenID, token
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestVerifyAuditChainDetectsTamperedAttendance(t *testing.T) {
+	requireTestDB(t)
+
+	userID, _ := createTestUser(t, "audit-attendance")
+	submittedID, submittedToken := createTestToken(t, userID)
+	forgedID, _ := createTestToken(t, userID)
+
+	resp, err := SubmitAttendance(types.UserReceivedAttendanceToken{UserID: userID, Token: submittedToken}, 0, "127.0.0.1")
+	if err != nil || !resp.Success {
+		t.Fatalf("SubmitAttendance = %+v, %v", resp, err)
+	}
+
+	result, err := VerifyAuditChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if containsInt(result.AttendanceTokenMismatches, submittedID) {
+		t.Fatalf("token yang ditukar normal dilaporkan tidak sesuai: %s", result.Reason)
+	}
+
+	// Token ditandai terpakai langsung di database (tanpa audit) dan jam
+	// absensi token yang sah dimundurkan
+	_, err = database.DB.Exec(`UPDATE attendance_tokens SET is_used = true, redeemed_at = NOW() WHERE id = $1`, forgedID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = database.DB.Exec(`UPDATE attendance_tokens SET created_at = created_at - INTERVAL '1 hour' WHERE id = $1`, submittedID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err = VerifyAuditChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Valid {
+		t.Fatal("verifikasi tetap valid setelah token absensi diubah")
+	}
+	for _, id := range []int{submittedID, forgedID} {
+		if !containsInt(result.AttendanceTokenMismatches, id) {
+			t.Errorf("token %d tidak dilaporkan (mismatches = %v)", id, result.AttendanceTokenMismatches)
+		}
+	}
+}
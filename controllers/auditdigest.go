@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"backend/database"
+	"backend/types"
+	"crypto/ed25519"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var (
+	ErrAuditSigningKeyMissing = errors.New("AUDIT_SIGNING_KEY belum diatur")
+	ErrAuditSigningKeyInvalid = errors.New("AUDIT_SIGNING_KEY harus berisi 32 byte (base64)")
+	ErrAuditDigestFutureDate  = errors.New("digest tidak bisa dibuat untuk tanggal yang belum terjadi")
+)
+
+// auditSigningKey membaca private key Ed25519 dari AUDIT_SIGNING_KEY
+// (seed 32 byte dalam base64, contoh: openssl rand -base64 32)
+func auditSigningKey() (ed25519.PrivateKey, error) {
+	value := os.Getenv("AUDIT_SIGNING_KEY")
+	if value == "" {
+		return nil, ErrAuditSigningKeyMissing
+	}
+
+	seed, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, ErrAuditSigningKeyInvalid
+	}
+
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// GenerateAuditDigest membuat digest audit chain untuk satu hari dan
+// menandatanganinya dengan Ed25519
+func GenerateAuditDigest(date time.Time) (types.SignedAuditDigest, error) {
+	key, err := auditSigningKey()
+	if err != nil {
+		return types.SignedAuditDigest{}, err
+	}
+
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 0, 1)
+	if start.After(time.Now()) {
+		return types.SignedAuditDigest{}, ErrAuditDigestFutureDate
+	}
+
+	digest := types.AuditDigest{
+		Date:        start.Format("2006-01-02"),
+		GeneratedAt: time.Now(),
+	}
+
+	err = database.DB.QueryRow(`
+		SELECT COUNT(*), MIN(id), MAX(id)
+		FROM audit_logs
+		WHERE created_at >= $1 AND created_at < $2
+	`, start, end).Scan(&digest.Entries, &digest.FirstID, &digest.LastID)
+	if err != nil {
+		return types.SignedAuditDigest{}, fmt.Errorf("gagal menghitung audit log: %w", err)
+	}
+
+	if digest.Entries > 0 {
+		err = database.DB.QueryRow(`
+			SELECT
+				(SELECT prev_hash FROM audit_logs WHERE id = $1),
+				(SELECT hash FROM audit_logs WHERE id = $2)
+		`, *digest.FirstID, *digest.LastID).Scan(&digest.PrevHash, &digest.LastHash)
+	} else {
+		// Tidak ada aktivitas: rantai tetap berada di hash terakhir sebelum hari ini
+		digest.PrevHash = auditGenesisHash
+		err = database.DB.QueryRow(`
+			SELECT hash FROM audit_logs WHERE created_at < $1 ORDER BY id DESC LIMIT 1
+		`, start).Scan(&digest.PrevHash)
+		if err == sql.ErrNoRows {
+			err = nil
+		}
+		digest.LastHash = digest.PrevHash
+	}
+	if err != nil {
+		return types.SignedAuditDigest{}, fmt.Errorf("gagal mengambil hash audit: %w", err)
+	}
+
+	payload, err := json.Marshal(digest)
+	if err != nil {
+		return types.SignedAuditDigest{}, fmt.Errorf("gagal encode digest: %w", err)
+	}
+
+	return types.SignedAuditDigest{
+		Digest:    payload,
+		Algorithm: "Ed25519",
+		PublicKey: base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload)),
+	}, nil
+}
+
+const (
+	// auditDigestDelay adalah jeda setelah tengah malam sebelum digest hari
+	// sebelumnya ditulis, agar entry audit terakhir hari itu sudah tersimpan
+	auditDigestDelay = 5 * time.Minute
+	// auditDigestRetryInterval adalah jeda sebelum export yang gagal dicoba lagi
+	auditDigestRetryInterval = time.Hour
+)
+
+// auditDigestExport menyimpan hari terakhir yang digest-nya sudah dipastikan ada
+// di folder tujuan, sehingga folder hanya diperiksa ulang saat berganti hari
+var auditDigestExport = struct {
+	sync.Mutex
+	dir     string
+	through string
+	retryAt time.Time
+}{}
+
+// ExportDailyAuditDigest dijalankan scheduler setiap menit. Mulai pukul 00:05
+// digest hari sebelumnya ditulis ke AUDIT_DIGEST_DIR agar bisa disalin ke
+// penyimpanan di luar sistem (WORM storage, email ke legal, dll). Digest hari-hari
+// sebelumnya yang belum ada (misalnya karena server mati saat 00:05) ikut ditulis,
+// mulai dari hari audit log pertama; file yang sudah ada tidak ditimpa. Export
+// yang gagal dicoba lagi satu jam kemudian.
+func ExportDailyAuditDigest(minute time.Time) error {
+	dir := os.Getenv("AUDIT_DIGEST_DIR")
+	if dir == "" {
+		return nil
+	}
+
+	yesterday := minute.Add(-auditDigestDelay).AddDate(0, 0, -1)
+	last := time.Date(yesterday.Year(), yesterday.Month(), yesterday.Day(), 0, 0, 0, 0, time.Local)
+
+	auditDigestExport.Lock()
+	defer auditDigestExport.Unlock()
+	if auditDigestExport.dir == dir && auditDigestExport.through == last.Format("2006-01-02") {
+		return nil
+	}
+	if minute.Before(auditDigestExport.retryAt) {
+		return nil
+	}
+
+	if err := exportMissingAuditDigests(dir, last); err != nil {
+		auditDigestExport.retryAt = minute.Add(auditDigestRetryInterval)
+		return err
+	}
+
+	auditDigestExport.dir = dir
+	auditDigestExport.through = last.Format("2006-01-02")
+	auditDigestExport.retryAt = time.Time{}
+	return nil
+}
+
+// exportMissingAuditDigests menulis digest yang belum ada di dir untuk setiap hari
+// dari hari audit log pertama sampai last
+func exportMissingAuditDigests(dir string, last time.Time) error {
+	var first sql.NullTime
+	if err := database.DB.QueryRow(`SELECT MIN(created_at) FROM audit_logs`).Scan(&first); err != nil {
+		return fmt.Errorf("gagal mengambil audit log pertama: %w", err)
+	}
+	day := last
+	if first.Valid {
+		if firstDay := time.Date(first.Time.Year(), first.Time.Month(), first.Time.Day(), 0, 0, 0, 0, time.Local); firstDay.Before(last) {
+			day = firstDay
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("gagal membuat folder digest: %w", err)
+	}
+
+	for ; !day.After(last); day = day.AddDate(0, 0, 1) {
+		path := filepath.Join(dir, fmt.Sprintf("audit-digest-%s.json", day.Format("2006-01-02")))
+		_, err := os.Stat(path)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("gagal memeriksa digest: %w", err)
+		}
+
+		if err := writeAuditDigest(path, day); err != nil {
+			return err
+		}
+		slog.Info("Audit digest written", "date", day.Format("2006-01-02"), "path", path)
+	}
+
+	return nil
+}
+
+func writeAuditDigest(path string, day time.Time) error {
+	signed, err := GenerateAuditDigest(day)
+	if err != nil {
+		return err
+	}
+
+	// Tanpa indentasi agar field digest tetap sama persis dengan byte yang ditandatangani
+	data, err := json.Marshal(signed)
+	if err != nil {
+		return fmt.Errorf("gagal encode digest: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("gagal menulis digest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"backend/database"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func resetAuditDigestExport(t *testing.T) {
+	t.Helper()
+
+	reset := func() {
+		auditDigestExport.Lock()
+		auditDigestExport.dir, auditDigestExport.through, auditDigestExport.retryAt = "", "", time.Time{}
+		auditDigestExport.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestExportDailyAuditDigestCatchesUp(t *testing.T) {
+	requireTestDB(t)
+	resetAuditDigestExport(t)
+
+	dir := t.TempDir()
+	t.Setenv("AUDIT_DIGEST_DIR", dir)
+	t.Setenv("AUDIT_SIGNING_KEY", base64.StdEncoding.EncodeToString(make([]byte, 32)))
+
+	now := time.Now()
+	digestPath := func(day time.Time) string {
+		return filepath.Join(dir, fmt.Sprintf("audit-digest-%s.json", day.Format("2006-01-02")))
+	}
+	yesterday := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, -1)
+
+	// Digest yang sudah ada tidak boleh ditimpa
+	if err := os.WriteFile(digestPath(yesterday), []byte("lama"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sebelum 00:05 digest kemarin belum ditulis, jadi jalankan di jam yang aman
+	minute := time.Date(now.Year(), now.Month(), now.Day(), 12, 0, 0, 0, time.Local)
+	if err := ExportDailyAuditDigest(minute); err != nil {
+		t.Fatalf("ExportDailyAuditDigest: %v", err)
+	}
+
+	if data, _ := os.ReadFile(digestPath(yesterday)); string(data) != "lama" {
+		t.Errorf("digest yang sudah ada ditimpa: %q", data)
+	}
+
+	var first sql.NullTime
+	if err := database.DB.QueryRow(`SELECT MIN(created_at) FROM audit_logs`).Scan(&first); err != nil {
+		t.Fatal(err)
+	}
+	if first.Valid {
+		for day := time.Date(first.Time.Year(), first.Time.Month(), first.Time.Day(), 0, 0, 0, 0, time.Local); day.Before(yesterday); day = day.AddDate(0, 0, 1) {
+			if _, err := os.Stat(digestPath(day)); err != nil {
+				t.Errorf("digest %s tidak ditulis: %v", day.Format("2006-01-02"), err)
+			}
+		}
+	}
+
+	// Setelah semua hari lengkap, folder tidak diperiksa lagi sampai berganti hari
+	if err := os.Remove(digestPath(yesterday)); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExportDailyAuditDigest(minute.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(digestPath(yesterday)); err == nil {
+		t.Error("folder digest diperiksa ulang pada hari yang sama")
+	}
+}
@@ -4,65 +4,56 @@ import (
 	"backend/database"
 	"backend/types"
 	"database/sql"
-	"log"
+	"log/slog"
 )
 
 func CheckAuthentication(userID int) (types.AuthCheckResponse, error) {
-	var hrdID int
-	var adminID int
 	var tempUser types.CheckUserTemp
+	var mustChangePassword, twoFactorEnabled bool
 
 	err := database.DB.QueryRow(`
-		SELECT id 
-		FROM departments 
-		WHERE name = 'HR'
-	`).Scan(&hrdID)
-
-	err = database.DB.QueryRow(`
-		SELECT id 
-		FROM departments 
-		WHERE name = 'Administrator'
-	`).Scan(&adminID)
-
-	if err != nil {
-		log.Printf("Error fetching HRD department: %v", err)
-		return types.AuthCheckResponse{Authenticated: false}, err
-	}
-
-	err = database.DB.QueryRow(`
-		SELECT id, name, email, department_id
+		SELECT id, name, email, department_id, must_change_password, totp_enabled_at IS NOT NULL
 		FROM users
 		WHERE id = $1
-	`, userID).Scan(&tempUser.ID, &tempUser.Name, &tempUser.Email, &tempUser.DepartmentID)
+	`, userID).Scan(&tempUser.ID, &tempUser.Name, &tempUser.Email, &tempUser.DepartmentID, &mustChangePassword, &twoFactorEnabled)
 
 	if err == sql.ErrNoRows {
-		log.Printf("User not found with ID: %d", userID)
+		slog.Info("User not found", "user_id", userID)
 		return types.AuthCheckResponse{Authenticated: false}, nil
 	}
 
 	if err != nil {
-		log.Printf("Error fetching user with ID %d: %v", userID, err)
+		slog.Error("Error fetching user", "user_id", userID, "error", err)
 		return types.AuthCheckResponse{Authenticated: false}, err
 	}
 
-	//check if user is from hrd department
-	var role string
+	// Role dan permission diambil dari tabel user_roles / role_permissions
+	roles, err := GetUserRoles(userID)
+	if err != nil {
+		slog.Error("Error fetching roles", "user_id", userID, "error", err)
+		return types.AuthCheckResponse{Authenticated: false}, err
+	}
 
-	if tempUser.DepartmentID != 0 && tempUser.DepartmentID == adminID {
-		role = "Admin"
-	} else if tempUser.DepartmentID != 0 && tempUser.DepartmentID == hrdID {
-		role = "HR"
-	} else {
-		role = "Employee"
+	permissions, err := GetUserPermissions(userID)
+	if err != nil {
+		slog.Error("Error fetching permissions", "user_id", userID, "error", err)
+		return types.AuthCheckResponse{Authenticated: false}, err
 	}
 
-	log.Printf("User authenticated successfully: ID=%d, Name=%s, Role=%s", tempUser.ID, tempUser.Name, role)
+	role := primaryRole(roles)
+
+	slog.Debug("User authenticated", "user_id", tempUser.ID, "role", role)
 
 	userAuthInfo := types.UserAuthInfo{
-		ID:    tempUser.ID,
-		Name:  tempUser.Name,
-		Email: tempUser.Email,
-		Role:  role,
+		ID:          tempUser.ID,
+		Name:        tempUser.Name,
+		Email:       tempUser.Email,
+		Role:        role,
+		Roles:       roles,
+		Permissions: permissions,
+
+		MustChangePassword: mustChangePassword,
+		TwoFactorEnabled:   twoFactorEnabled,
 	}
 
 	// check if user is attend today
@@ -73,7 +64,7 @@ func CheckAuthentication(userID int) (types.AuthCheckResponse, error) {
 `, userID)
 
 	if err != nil {
-		log.Printf("Error fetching attendance for user %d: %v", userID, err)
+		slog.Error("Error fetching attendance", "user_id", userID, "error", err)
 		return types.AuthCheckResponse{Authenticated: false}, err
 	}
 	defer rows.Close()
@@ -85,7 +76,7 @@ func CheckAuthentication(userID int) (types.AuthCheckResponse, error) {
 		var isUsed bool
 
 		if err := rows.Scan(&userIDFromDB, &isUsed); err != nil {
-			log.Printf("Error scanning attendance row: %v", err)
+			slog.Error("Error scanning attendance row", "error", err)
 			continue
 		}
 
@@ -96,7 +87,7 @@ func CheckAuthentication(userID int) (types.AuthCheckResponse, error) {
 	}
 
 	if err = rows.Err(); err != nil {
-		log.Printf("Error iterating attendance rows: %v", err)
+		slog.Error("Error iterating attendance rows", "error", err)
 	}
 
 	return types.AuthCheckResponse{
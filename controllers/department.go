@@ -3,13 +3,20 @@ package controllers
 import (
 	"backend/database"
 	"backend/types"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
 )
 
 func GetDepartments() ([]types.Department, error) {
 	rows, err := database.DB.Query(`
-		SELECT id, name 
-		FROM departments 
-		ORDER BY name ASC
+		SELECT d.id, d.name, d.manager_id, m.name
+		FROM departments d
+		LEFT JOIN users m ON m.id = d.manager_id
+		ORDER BY d.name ASC
 	`)
 
 	if err != nil {
@@ -21,7 +28,7 @@ func GetDepartments() ([]types.Department, error) {
 	departments := []types.Department{}
 	for rows.Next() {
 		var dept types.Department
-		err := rows.Scan(&dept.ID, &dept.Name)
+		err := rows.Scan(&dept.ID, &dept.Name, &dept.ManagerID, &dept.ManagerName)
 		if err != nil {
 			return nil, err
 		}
@@ -30,3 +37,122 @@ func GetDepartments() ([]types.Department, error) {
 
 	return departments, nil
 }
+
+var (
+	ErrDepartmentNotFound = errors.New("departemen tidak ditemukan")
+	ErrDepartmentExists   = errors.New("nama departemen sudah digunakan")
+	ErrDepartmentInUse    = errors.New("departemen masih memiliki karyawan")
+	ErrDepartmentName     = errors.New("nama departemen tidak boleh kosong")
+	ErrManagerNotFound    = errors.New("manager tidak ditemukan")
+)
+
+func CreateDepartment(req types.DepartmentRequest) (types.Department, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return types.Department{}, ErrDepartmentName
+	}
+
+	var departmentID int
+	err := database.DB.QueryRow(`
+		INSERT INTO departments (name, manager_id)
+		VALUES ($1, $2)
+		RETURNING id
+	`, name, req.ManagerID).Scan(&departmentID)
+
+	if isUniqueViolation(err) {
+		return types.Department{}, ErrDepartmentExists
+	}
+	if isForeignKeyViolation(err) {
+		return types.Department{}, ErrManagerNotFound
+	}
+	if err != nil {
+		return types.Department{}, fmt.Errorf("gagal insert departemen: %w", err)
+	}
+
+	return getDepartment(departmentID)
+}
+
+// UpdateDepartment mengganti nama dan manager departemen
+func UpdateDepartment(departmentID int, req types.DepartmentRequest) (types.Department, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return types.Department{}, ErrDepartmentName
+	}
+
+	result, err := database.DB.Exec(`
+		UPDATE departments
+		SET name = $1, manager_id = $2
+		WHERE id = $3
+	`, name, req.ManagerID, departmentID)
+
+	if isUniqueViolation(err) {
+		return types.Department{}, ErrDepartmentExists
+	}
+	if isForeignKeyViolation(err) {
+		return types.Department{}, ErrManagerNotFound
+	}
+	if err != nil {
+		return types.Department{}, fmt.Errorf("gagal update departemen: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return types.Department{}, fmt.Errorf("gagal cek rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return types.Department{}, ErrDepartmentNotFound
+	}
+
+	return getDepartment(departmentID)
+}
+
+func DeleteDepartment(departmentID int) error {
+	result, err := database.DB.Exec(`DELETE FROM departments WHERE id = $1`, departmentID)
+	if isForeignKeyViolation(err) {
+		return ErrDepartmentInUse
+	}
+	if err != nil {
+		return fmt.Errorf("gagal menghapus departemen: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("gagal cek rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrDepartmentNotFound
+	}
+
+	return nil
+}
+
+func getDepartment(departmentID int) (types.Department, error) {
+	var dept types.Department
+	err := database.DB.QueryRow(`
+		SELECT d.id, d.name, d.manager_id, m.name
+		FROM departments d
+		LEFT JOIN users m ON m.id = d.manager_id
+		WHERE d.id = $1
+	`, departmentID).Scan(&dept.ID, &dept.Name, &dept.ManagerID, &dept.ManagerName)
+
+	if err == sql.ErrNoRows {
+		return types.Department{}, ErrDepartmentNotFound
+	}
+	if err != nil {
+		return types.Department{}, fmt.Errorf("gagal mengambil departemen: %w", err)
+	}
+
+	return dept, nil
+}
+
+// isUniqueViolation mengecek error PostgreSQL unique_violation (23505)
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// isForeignKeyViolation mengecek error PostgreSQL foreign_key_violation (23503)
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"backend/types"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// MonthlyAttendanceCSV mengekspor matriks absensi bulanan ke CSV:
+// satu baris per user, satu kolom per hari kerja, lalu total per user
+func MonthlyAttendanceCSV(month int, year int) ([]byte, error) {
+	report, err := GetMonthlyAttendance(month, year)
+	if err != nil {
+		return nil, err
+	}
+
+	return monthlyAttendanceCSV(report)
+}
+
+func monthlyAttendanceCSV(report types.MonthlyAttendanceListResponse) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	header := []string{"user_id", "name", "email", "department", "position"}
+	header = append(header, report.WorkingDays...)
+	header = append(header, "total_present", "total_late", "total_leave", "total_absent")
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+
+	for _, row := range report.Matrix {
+		record := []string{
+			strconv.Itoa(row.UserID),
+			row.UserName,
+			row.UserEmail,
+			row.DepartmentName,
+			row.Position,
+		}
+		for _, day := range report.WorkingDays {
+			record = append(record, row.Days[day])
+		}
+		record = append(record,
+			strconv.Itoa(row.TotalPresent),
+			strconv.Itoa(row.TotalLate),
+			strconv.Itoa(row.TotalLeave),
+			strconv.Itoa(row.TotalAbsent),
+		)
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("gagal menulis CSV: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// DailyAttendanceCSV mengekspor rekap absensi satu hari ke CSV
+// (user yang hadir beserta jam check-in, lalu user yang absen)
+func DailyAttendanceCSV(date time.Time) ([]byte, error) {
+	report, err := GetDailyAttendance(date)
+	if err != nil {
+		return nil, err
+	}
+
+	return dailyAttendanceCSV(report)
+}
+
+func dailyAttendanceCSV(report types.TodayAttendanceListResponse) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write([]string{"user_id", "name", "email", "department", "position", "check_in_time", "status"}); err != nil {
+		return nil, err
+	}
+
+	for _, attendance := range report.Attendances {
+		if err := writer.Write([]string{
+			strconv.Itoa(attendance.UserID),
+			attendance.UserName,
+			attendance.UserEmail,
+			attendance.DepartmentName,
+			attendance.Position,
+			attendance.CheckInTime.Format("15:04:05"),
+			attendance.Status,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, absent := range report.AbsentUsers {
+		if err := writer.Write([]string{
+			strconv.Itoa(absent.UserID),
+			absent.UserName,
+			absent.UserEmail,
+			absent.DepartmentName,
+			absent.Position,
+			"",
+			DayStatusAbsent,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("gagal menulis CSV: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
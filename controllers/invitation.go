@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"backend/database"
+	"backend/mailer"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const invitationTTL = 72 * time.Hour
+
+var (
+	ErrInvalidInvitation = errors.New("undangan tidak valid, sudah dipakai atau kedaluwarsa")
+	ErrUserNotPending    = errors.New("user tidak dalam status pending")
+	ErrNoActiveInvite    = errors.New("tidak ada undangan aktif untuk user ini")
+)
+
+// createInvitation membuat undangan baru untuk user dan membatalkan undangan
+// sebelumnya yang belum dipakai. Mengembalikan token asli (yang disimpan hanya hash-nya).
+func createInvitation(tx *sql.Tx, userID int, invitedBy int) (string, error) {
+	token, err := generateSecureToken()
+	if err != nil {
+		return "", fmt.Errorf("gagal membuat token undangan: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE invitations SET revoked_at = NOW()
+		WHERE user_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+	`, userID)
+	if err != nil {
+		return "", fmt.Errorf("gagal membatalkan undangan lama: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO invitations (user_id, token_hash, invited_by, expires_at)
+		VALUES ($1, $2, NULLIF($3, 0), NOW() + make_interval(secs => $4))
+	`, userID, hashToken(token), invitedBy, invitationTTL.Seconds())
+	if err != nil {
+		return "", fmt.Errorf("gagal menyimpan undangan: %w", err)
+	}
+
+	return token, nil
+}
+
+func sendInvitationEmail(name string, email string, token string) error {
+	subject := "Undangan akun absensi"
+	body := fmt.Sprintf(
+		"Halo %s,\n\nAnda diundang untuk menggunakan aplikasi absensi.\n"+
+			"Buka tautan berikut untuk membuat password dan mengaktifkan akun Anda (berlaku %d jam):\n\n%s\n",
+		name, int(invitationTTL.Hours()), AppURL("/accept-invitation?token="+token),
+	)
+	return mailer.Send([]string{email}, subject, body)
+}
+
+// ResendInvitation membuat undangan baru untuk user yang masih pending dan
+// mengirimkannya via email. Undangan lama otomatis tidak berlaku.
+func ResendInvitation(userID int, invitedBy int) error {
+	var name, email, status string
+	err := database.DB.QueryRow(`
+		SELECT name, email, status FROM users WHERE id = $1
+	`, userID).Scan(&name, &email, &status)
+
+	if err == sql.ErrNoRows {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("gagal mengambil user: %w", err)
+	}
+	if status != UserStatusPending {
+		return ErrUserNotPending
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("gagal memulai transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	token, err := createInvitation(tx, userID, invitedBy)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("gagal commit transaction: %w", err)
+	}
+
+	if err := sendInvitationEmail(name, email, token); err != nil {
+		return fmt.Errorf("gagal mengirim email undangan: %w", err)
+	}
+
+	slog.Info("Invitation resent", "user_id", userID, "actor_id", invitedBy)
+	return nil
+}
+
+// RevokeInvitation membatalkan undangan aktif milik user. User tetap berstatus
+// pending dan tidak bisa login sampai undangan baru dikirim dan diterima.
+func RevokeInvitation(userID int) error {
+	result, err := database.DB.Exec(`
+		UPDATE invitations SET revoked_at = NOW()
+		WHERE user_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("gagal membatalkan undangan: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("gagal cek rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNoActiveInvite
+	}
+
+	slog.Info("Invitation revoked", "user_id", userID)
+	return nil
+}
+
+// AcceptInvitation dipanggil oleh user yang diundang: menyimpan password pilihan user,
+// menandai email terverifikasi (tautan diterima lewat email tersebut) dan
+// mengaktifkan akun.
+func AcceptInvitation(token string, password string) error {
+	if token == "" {
+		return ErrInvalidInvitation
+	}
+	if password == "" {
+		return ErrPasswordIsRequired
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("gagal memulai transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var invitationID, userID int
+	var email string
+	err = tx.QueryRow(`
+		SELECT i.id, i.user_id, u.email
+		FROM invitations i
+		JOIN users u ON u.id = i.user_id
+		WHERE i.token_hash = $1
+		  AND i.accepted_at IS NULL
+		  AND i.revoked_at IS NULL
+		  AND i.expires_at > NOW()
+		  AND u.status = 'pending'
+		FOR UPDATE OF i
+	`, hashToken(token)).Scan(&invitationID, &userID, &email)
+
+	if err == sql.ErrNoRows {
+		return ErrInvalidInvitation
+	}
+	if err != nil {
+		return fmt.Errorf("gagal mengambil undangan: %w", err)
+	}
+
+	if err := ValidatePasswordStrength(password, email); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE invitations SET accepted_at = NOW() WHERE id = $1`, invitationID); err != nil {
+		return fmt.Errorf("gagal update undangan: %w", err)
+	}
+
+	if err := setPassword(tx, userID, password); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users SET status = 'active', email_verified_at = NOW() WHERE id = $1
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("gagal mengaktifkan user: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("gagal commit transaction: %w", err)
+	}
+
+	slog.Info("Invitation accepted", "user_id", userID)
+	return nil
+}
@@ -0,0 +1,242 @@
+package controllers
+
+import (
+	"backend/database"
+	"backend/types"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+)
+
+var ErrInvalidLatePolicy = errors.New("late policy tidak valid")
+
+// lateEvaluator menggabungkan jam kerja dan late policy yang sedang berlaku
+// untuk menentukan status dan menit keterlambatan sebuah check-in
+type lateEvaluator struct {
+	policy    types.LatePolicy
+	workStart time.Time
+	tolerance time.Time
+}
+
+// lateResult adalah hasil evaluasi keterlambatan satu check-in
+type lateResult struct {
+	IsLate   bool
+	Minutes  int
+	Category string
+}
+
+func GetLatePolicy() (types.LatePolicy, error) {
+	var policy types.LatePolicy
+
+	err := database.DB.QueryRow(`
+		SELECT id, measure_from, grace_minutes, rounding_mode, rounding_unit, created_at
+		FROM late_policies
+		ORDER BY id DESC
+		LIMIT 1
+	`).Scan(
+		&policy.ID,
+		&policy.MeasureFrom,
+		&policy.GraceMinutes,
+		&policy.RoundingMode,
+		&policy.RoundingUnit,
+		&policy.CreatedAt,
+	)
+
+	if err != nil {
+		slog.Error("Error fetching late policy", "error", err)
+		return types.LatePolicy{}, err
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT label, min_minutes, max_minutes
+		FROM late_policy_tiers
+		WHERE policy_id = $1
+		ORDER BY min_minutes ASC
+	`, policy.ID)
+
+	if err != nil {
+		slog.Error("Error fetching late policy tiers", "error", err)
+		return types.LatePolicy{}, err
+	}
+	defer rows.Close()
+
+	policy.Tiers = []types.LateTier{}
+	for rows.Next() {
+		var tier types.LateTier
+		if err := rows.Scan(&tier.Label, &tier.MinMinutes, &tier.MaxMinutes); err != nil {
+			return types.LatePolicy{}, err
+		}
+		policy.Tiers = append(policy.Tiers, tier)
+	}
+
+	return policy, rows.Err()
+}
+
+// UpdateLatePolicy menyimpan late policy baru. Policy lama tetap disimpan sebagai
+// riwayat; yang berlaku selalu baris terbaru (seperti work_hours).
+func UpdateLatePolicy(req types.UpdateLatePolicyRequest) (types.LatePolicy, error) {
+	if err := validateLatePolicy(req); err != nil {
+		return types.LatePolicy{}, err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return types.LatePolicy{}, fmt.Errorf("gagal memulai transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var policyID int
+	err = tx.QueryRow(`
+		INSERT INTO late_policies (measure_from, grace_minutes, rounding_mode, rounding_unit)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, req.MeasureFrom, req.GraceMinutes, req.RoundingMode, req.RoundingUnit).Scan(&policyID)
+
+	if err != nil {
+		return types.LatePolicy{}, fmt.Errorf("gagal insert late policy: %w", err)
+	}
+
+	for _, tier := range req.Tiers {
+		_, err = tx.Exec(`
+			INSERT INTO late_policy_tiers (policy_id, label, min_minutes, max_minutes)
+			VALUES ($1, $2, $3, $4)
+		`, policyID, tier.Label, tier.MinMinutes, tier.MaxMinutes)
+
+		if err != nil {
+			return types.LatePolicy{}, fmt.Errorf("gagal insert late policy tier: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return types.LatePolicy{}, fmt.Errorf("gagal commit transaction: %w", err)
+	}
+
+	slog.Info("Late policy updated", "policy_id", policyID)
+	return GetLatePolicy()
+}
+
+func validateLatePolicy(req types.UpdateLatePolicyRequest) error {
+	if req.MeasureFrom != "start" && req.MeasureFrom != "tolerance" {
+		return fmt.Errorf("%w: measure_from harus 'start' atau 'tolerance'", ErrInvalidLatePolicy)
+	}
+	if req.GraceMinutes < 0 {
+		return fmt.Errorf("%w: grace_minutes tidak boleh negatif", ErrInvalidLatePolicy)
+	}
+	if req.RoundingMode != "floor" && req.RoundingMode != "ceil" && req.RoundingMode != "nearest" {
+		return fmt.Errorf("%w: rounding_mode harus 'floor', 'ceil' atau 'nearest'", ErrInvalidLatePolicy)
+	}
+	if req.RoundingUnit < 1 {
+		return fmt.Errorf("%w: rounding_unit minimal 1 menit", ErrInvalidLatePolicy)
+	}
+
+	for _, tier := range req.Tiers {
+		if tier.Label == "" {
+			return fmt.Errorf("%w: label tier tidak boleh kosong", ErrInvalidLatePolicy)
+		}
+		if tier.MinMinutes < 0 {
+			return fmt.Errorf("%w: min_minutes tier %q tidak boleh negatif", ErrInvalidLatePolicy, tier.Label)
+		}
+		if tier.MaxMinutes != nil && *tier.MaxMinutes <= tier.MinMinutes {
+			return fmt.Errorf("%w: max_minutes tier %q harus lebih besar dari min_minutes", ErrInvalidLatePolicy, tier.Label)
+		}
+	}
+
+	return nil
+}
+
+// newLateEvaluator memuat jam kerja dan late policy yang berlaku
+func newLateEvaluator() (*lateEvaluator, error) {
+	workHours, err := GetWorkHours()
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := GetLatePolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	workStart, err := parseClock(workHours.WorkStartTime)
+	if err != nil {
+		return nil, fmt.Errorf("work_start_time tidak valid: %w", err)
+	}
+
+	tolerance, err := parseClock(workHours.ToleranceTime)
+	if err != nil {
+		return nil, fmt.Errorf("tolerance_time tidak valid: %w", err)
+	}
+
+	return &lateEvaluator{
+		policy:    policy,
+		workStart: workStart,
+		tolerance: tolerance,
+	}, nil
+}
+
+// evaluate menghitung status keterlambatan sebuah jam check-in (HH:MM:SS).
+// Check-in dianggap terlambat jika melewati tolerance_time + grace_minutes;
+// menitnya dihitung dari work_start_time atau tolerance_time sesuai measure_from.
+// Keterlambatan yang setelah dibulatkan menjadi 0 menit dianggap tepat waktu.
+func (e *lateEvaluator) evaluate(checkInTime string) (lateResult, error) {
+	checkIn, err := parseClock(checkInTime)
+	if err != nil {
+		return lateResult{}, fmt.Errorf("jam check-in tidak valid: %w", err)
+	}
+
+	gate := e.tolerance.Add(time.Duration(e.policy.GraceMinutes) * time.Minute)
+	if !checkIn.After(gate) {
+		return lateResult{IsLate: false, Minutes: 0, Category: "on-time"}, nil
+	}
+
+	reference := e.tolerance
+	if e.policy.MeasureFrom == "start" {
+		reference = e.workStart
+	}
+
+	minutes := roundLateMinutes(checkIn.Sub(reference), e.policy.RoundingMode, e.policy.RoundingUnit)
+	if minutes <= 0 {
+		return lateResult{IsLate: false, Minutes: 0, Category: "on-time"}, nil
+	}
+
+	return lateResult{
+		IsLate:   true,
+		Minutes:  minutes,
+		Category: lateCategory(e.policy.Tiers, minutes),
+	}, nil
+}
+
+// roundLateMinutes membulatkan durasi keterlambatan ke kelipatan unit menit
+func roundLateMinutes(diff time.Duration, mode string, unit int) int {
+	if unit < 1 {
+		unit = 1
+	}
+
+	units := diff.Minutes() / float64(unit)
+	switch mode {
+	case "ceil":
+		units = math.Ceil(units)
+	case "nearest":
+		units = math.Floor(units + 0.5)
+	default:
+		units = math.Floor(units)
+	}
+
+	return int(units) * unit
+}
+
+// lateCategory mencari label tier untuk jumlah menit keterlambatan
+func lateCategory(tiers []types.LateTier, minutes int) string {
+	for _, tier := range tiers {
+		if minutes >= tier.MinMinutes && (tier.MaxMinutes == nil || minutes < *tier.MaxMinutes) {
+			return tier.Label
+		}
+	}
+	return "late"
+}
+
+// parseClock mem-parsing jam dalam format HH:MM:SS (detik pecahan diizinkan)
+func parseClock(value string) (time.Time, error) {
+	return time.Parse("15:04:05", value)
+}
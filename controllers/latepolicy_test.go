@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"backend/types"
+	"testing"
+	"time"
+)
+
+func TestRoundLateMinutes(t *testing.T) {
+	tests := []struct {
+		diff time.Duration
+		mode string
+		unit int
+		want int
+	}{
+		{30 * time.Second, "floor", 1, 0},
+		{90 * time.Second, "floor", 1, 1},
+		{14*time.Minute + 59*time.Second, "floor", 5, 10},
+		{30 * time.Second, "ceil", 1, 1},
+		{10 * time.Minute, "ceil", 5, 10},
+		{10*time.Minute + time.Second, "ceil", 5, 15},
+		{2*time.Minute + 29*time.Second, "nearest", 5, 0},
+		{2*time.Minute + 30*time.Second, "nearest", 5, 5},
+		{7 * time.Minute, "nearest", 5, 5},
+		{8 * time.Minute, "nearest", 5, 10},
+		{7 * time.Minute, "", 0, 7}, // mode tidak dikenal = floor, unit minimal 1
+	}
+
+	for _, tt := range tests {
+		if got := roundLateMinutes(tt.diff, tt.mode, tt.unit); got != tt.want {
+			t.Errorf("roundLateMinutes(%s, %q, %d) = %d, want %d", tt.diff, tt.mode, tt.unit, got, tt.want)
+		}
+	}
+}
+
+func intPtr(v int) *int { return &v }
+
+var testLateTiers = []types.LateTier{
+	{Label: "late < 15", MinMinutes: 0, MaxMinutes: intPtr(15)},
+	{Label: "late 15-60", MinMinutes: 15, MaxMinutes: intPtr(60)},
+	{Label: "late > 60", MinMinutes: 60},
+}
+
+func TestLateCategory(t *testing.T) {
+	tests := []struct {
+		tiers   []types.LateTier
+		minutes int
+		want    string
+	}{
+		{testLateTiers, 1, "late < 15"},
+		{testLateTiers, 14, "late < 15"},
+		{testLateTiers, 15, "late 15-60"},
+		{testLateTiers, 59, "late 15-60"},
+		{testLateTiers, 60, "late > 60"},
+		{testLateTiers, 600, "late > 60"},
+		{testLateTiers[:2], 90, "late"}, // di luar semua tier
+		{nil, 5, "late"},
+	}
+
+	for _, tt := range tests {
+		if got := lateCategory(tt.tiers, tt.minutes); got != tt.want {
+			t.Errorf("lateCategory(%d) = %q, want %q", tt.minutes, got, tt.want)
+		}
+	}
+}
+
+func TestLateEvaluatorEvaluate(t *testing.T) {
+	clock := func(value string) time.Time {
+		c, err := parseClock(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name        string
+		measureFrom string
+		grace       int
+		mode        string
+		unit        int
+		checkIn     string
+		want        lateResult
+	}{
+		{"sebelum toleransi", "tolerance", 0, "floor", 1, "08:10:00", lateResult{false, 0, "on-time"}},
+		{"tepat di batas toleransi", "tolerance", 0, "floor", 1, "08:15:00", lateResult{false, 0, "on-time"}},
+		{"terlambat beberapa detik dibulatkan ke bawah", "tolerance", 0, "floor", 1, "08:15:59", lateResult{false, 0, "on-time"}},
+		{"terlambat beberapa detik dibulatkan ke atas", "tolerance", 0, "ceil", 1, "08:15:01", lateResult{true, 1, "late < 15"}},
+		{"terlambat satu menit", "tolerance", 0, "floor", 1, "08:16:00", lateResult{true, 1, "late < 15"}},
+		{"di bawah unit pembulatan", "tolerance", 0, "floor", 5, "08:19:59", lateResult{false, 0, "on-time"}},
+		{"masih dalam grace", "tolerance", 5, "floor", 1, "08:20:00", lateResult{false, 0, "on-time"}},
+		{"lewat grace dihitung dari toleransi", "tolerance", 5, "floor", 1, "08:21:00", lateResult{true, 6, "late < 15"}},
+		{"dihitung dari jam masuk", "start", 0, "floor", 1, "08:16:00", lateResult{true, 16, "late 15-60"}},
+		{"sangat terlambat", "tolerance", 0, "floor", 1, "09:30:00", lateResult{true, 75, "late > 60"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluator := &lateEvaluator{
+				policy: types.LatePolicy{
+					MeasureFrom:  tt.measureFrom,
+					GraceMinutes: tt.grace,
+					RoundingMode: tt.mode,
+					RoundingUnit: tt.unit,
+					Tiers:        testLateTiers,
+				},
+				workStart: clock("08:00:00"),
+				tolerance: clock("08:15:00"),
+			}
+
+			got, err := evaluator.evaluate(tt.checkIn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("evaluate(%s) = %+v, want %+v", tt.checkIn, got, tt.want)
+			}
+		})
+	}
+
+	evaluator := &lateEvaluator{policy: types.LatePolicy{Tiers: testLateTiers}}
+	if _, err := evaluator.evaluate("bukan-jam"); err == nil {
+		t.Error("evaluate dengan jam tidak valid tidak mengembalikan error")
+	}
+}
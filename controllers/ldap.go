@@ -0,0 +1,505 @@
+package controllers
+
+import (
+	"backend/database"
+	"backend/ldap"
+	"backend/types"
+	"crypto/tls"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Nilai kolom users.auth_source
+const (
+	AuthSourceLocal = "local"
+	AuthSourceLDAP  = "ldap" // password diverifikasi dengan bind ke directory
+)
+
+// Bit ACCOUNTDISABLE pada atribut userAccountControl Active Directory
+const adAccountDisabled = 0x2
+
+// Batas default porsi user LDAP aktif yang boleh dinonaktifkan dalam satu sinkronisasi
+const defaultLDAPMaxDeactivateRatio = 0.2
+
+var (
+	ErrLDAPNotConfigured      = errors.New("LDAP tidak dikonfigurasi")
+	ErrLDAPInvalidCredentials = errors.New("email atau password directory salah")
+
+	// Hasil pencarian kosong hampir selalu berarti LDAP_BASE_DN/LDAP_USER_FILTER
+	// salah; tanpa pengecekan ini semua user LDAP akan dinonaktifkan
+	ErrLDAPSyncEmptyDirectory       = errors.New("pencarian LDAP tidak mengembalikan user, periksa LDAP_BASE_DN dan LDAP_USER_FILTER")
+	ErrLDAPSyncTooManyDeactivations = errors.New("sinkronisasi akan menonaktifkan terlalu banyak user LDAP (lihat LDAP_SYNC_MAX_DEACTIVATE_RATIO)")
+)
+
+type ldapConfig struct {
+	URL                 string
+	BindDN              string
+	BindPassword        string
+	BaseDN              string
+	UserFilter          string
+	EmailAttr           string
+	NameAttr            string
+	PhoneAttr           string
+	TitleAttr           string
+	GroupAttr           string
+	GroupDepartments    map[string]string // DN grup -> nama departemen
+	DefaultDepartmentID int
+	InsecureSkipVerify  bool
+	MaxDeactivateRatio  float64 // porsi maksimal user LDAP aktif yang dinonaktifkan per sinkronisasi
+}
+
+// loadLDAPConfig membaca konfigurasi LDAP dari environment (lihat .env.example)
+func loadLDAPConfig() (ldapConfig, error) {
+	cfg := ldapConfig{
+		URL:          os.Getenv("LDAP_URL"),
+		BindDN:       os.Getenv("LDAP_BIND_DN"),
+		BindPassword: os.Getenv("LDAP_BIND_PASSWORD"),
+		BaseDN:       os.Getenv("LDAP_BASE_DN"),
+		UserFilter:   envOrDefault("LDAP_USER_FILTER", "(objectClass=person)"),
+		EmailAttr:    envOrDefault("LDAP_EMAIL_ATTR", "mail"),
+		NameAttr:     envOrDefault("LDAP_NAME_ATTR", "cn"),
+		PhoneAttr:    envOrDefault("LDAP_PHONE_ATTR", "telephoneNumber"),
+		TitleAttr:    envOrDefault("LDAP_TITLE_ATTR", "title"),
+		GroupAttr:    envOrDefault("LDAP_GROUP_ATTR", "memberOf"),
+
+		InsecureSkipVerify: os.Getenv("LDAP_INSECURE_SKIP_VERIFY") == "true",
+		MaxDeactivateRatio: defaultLDAPMaxDeactivateRatio,
+	}
+	if cfg.URL == "" || cfg.BaseDN == "" {
+		return cfg, ErrLDAPNotConfigured
+	}
+
+	cfg.GroupDepartments = make(map[string]string)
+	if value := os.Getenv("LDAP_GROUP_DEPARTMENTS"); value != "" {
+		var mapping map[string]string
+		if err := json.Unmarshal([]byte(value), &mapping); err != nil {
+			return cfg, fmt.Errorf("LDAP_GROUP_DEPARTMENTS harus berupa JSON {\"<DN grup>\": \"<nama departemen>\"}: %w", err)
+		}
+		for groupDN, department := range mapping {
+			cfg.GroupDepartments[ldap.NormalizeDN(groupDN)] = department
+		}
+	}
+
+	if value := os.Getenv("LDAP_DEFAULT_DEPARTMENT_ID"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return cfg, fmt.Errorf("LDAP_DEFAULT_DEPARTMENT_ID tidak valid: %w", err)
+		}
+		cfg.DefaultDepartmentID = id
+	}
+
+	if value := os.Getenv("LDAP_SYNC_MAX_DEACTIVATE_RATIO"); value != "" {
+		ratio, err := strconv.ParseFloat(value, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return cfg, fmt.Errorf("LDAP_SYNC_MAX_DEACTIVATE_RATIO harus angka 0 sampai 1")
+		}
+		cfg.MaxDeactivateRatio = ratio
+	}
+
+	return cfg, nil
+}
+
+// LDAPEnabled menandakan apakah login dan sinkronisasi LDAP aktif
+func LDAPEnabled() bool {
+	return os.Getenv("LDAP_URL") != "" && os.Getenv("LDAP_BASE_DN") != ""
+}
+
+// connect membuka koneksi dan melakukan bind dengan service account
+func (cfg ldapConfig) connect() (*ldap.Conn, error) {
+	conn, err := ldap.Dial(cfg.URL, &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify})
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.BindDN != "" {
+		if err := conn.Bind(cfg.BindDN, cfg.BindPassword); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("gagal bind service account LDAP: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// AuthenticateLDAP memverifikasi password user dengan mencari DN-nya berdasarkan
+// email lalu melakukan bind sebagai user tersebut
+func AuthenticateLDAP(email string, password string) error {
+	cfg, err := loadLDAPConfig()
+	if err != nil {
+		return err
+	}
+	if password == "" {
+		return ErrLDAPInvalidCredentials
+	}
+
+	conn, err := cfg.connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	entries, err := conn.Search(ldap.SearchRequest{
+		BaseDN:     cfg.BaseDN,
+		Scope:      ldap.ScopeWholeSubtree,
+		Filter:     fmt.Sprintf("(&%s(%s=%s))", cfg.UserFilter, cfg.EmailAttr, ldap.EscapeFilter(email)),
+		Attributes: []string{cfg.EmailAttr, "userAccountControl"},
+		SizeLimit:  2,
+	})
+	if err != nil {
+		return fmt.Errorf("gagal mencari user di LDAP: %w", err)
+	}
+	// Email harus menunjuk tepat satu entry
+	if len(entries) != 1 || adDisabled(entries[0]) {
+		return ErrLDAPInvalidCredentials
+	}
+
+	if err := conn.Bind(entries[0].DN, password); err != nil {
+		if errors.Is(err, ldap.ErrInvalidCredentials) {
+			return ErrLDAPInvalidCredentials
+		}
+		return fmt.Errorf("gagal bind user LDAP: %w", err)
+	}
+
+	return nil
+}
+
+type ldapLocalUser struct {
+	ID           int
+	Name         string
+	Phone        string
+	Position     string
+	DepartmentID int
+	Status       string
+	AuthSource   string
+}
+
+type ldapCreate struct {
+	Name         string
+	Email        string
+	Phone        string
+	Position     string
+	DepartmentID int
+}
+
+// SyncLDAPUsers menyamakan tabel users dengan directory: membuat user baru,
+// memperbarui nama/telepon/jabatan/departemen (dari grup), mengaktifkan kembali
+// dan menonaktifkan user LDAP sesuai status di directory. Akun lokal dengan
+// email yang sama tidak diubah (dilaporkan sebagai skipped).
+// Dengan dryRun, tidak ada perubahan yang disimpan; report berisi rencana perubahan.
+// Sinkronisasi dibatalkan jika directory kosong atau (bukan dryRun) akan
+// menonaktifkan lebih dari MaxDeactivateRatio user LDAP aktif.
+func SyncLDAPUsers(dryRun bool) (types.LDAPSyncReport, error) {
+	report := types.LDAPSyncReport{
+		DryRun:      dryRun,
+		StartedAt:   time.Now(),
+		Created:     []types.LDAPSyncChange{},
+		Updated:     []types.LDAPSyncChange{},
+		Deactivated: []types.LDAPSyncChange{},
+		Skipped:     []types.LDAPSyncChange{},
+	}
+
+	cfg, err := loadLDAPConfig()
+	if err != nil {
+		return report, err
+	}
+
+	groupDepartments, err := resolveGroupDepartments(cfg.GroupDepartments)
+	if err != nil {
+		return report, err
+	}
+
+	conn, err := cfg.connect()
+	if err != nil {
+		return report, err
+	}
+	entries, err := conn.Search(ldap.SearchRequest{
+		BaseDN: cfg.BaseDN,
+		Scope:  ldap.ScopeWholeSubtree,
+		Filter: cfg.UserFilter,
+		Attributes: []string{
+			cfg.EmailAttr, cfg.NameAttr, cfg.PhoneAttr, cfg.TitleAttr, cfg.GroupAttr, "userAccountControl",
+		},
+	})
+	conn.Close()
+	if err != nil {
+		return report, fmt.Errorf("gagal mengambil user dari LDAP: %w", err)
+	}
+	if len(entries) == 0 {
+		return report, ErrLDAPSyncEmptyDirectory
+	}
+
+	localUsers, err := getLocalUsersByEmail()
+	if err != nil {
+		return report, err
+	}
+
+	var creates []ldapCreate
+	updates := make(map[int]map[string]types.FieldChange)
+	var deactivations []int
+	seen := make(map[string]bool)
+
+	for _, entry := range entries {
+		email := NormalizeEmail(entry.Get(cfg.EmailAttr))
+		if email == "" {
+			report.Skipped = append(report.Skipped, types.LDAPSyncChange{DN: entry.DN, Reason: "entry tidak memiliki email"})
+			continue
+		}
+		if seen[email] {
+			report.Skipped = append(report.Skipped, types.LDAPSyncChange{Email: email, DN: entry.DN, Reason: "email dipakai lebih dari satu entry"})
+			continue
+		}
+		seen[email] = true
+
+		name := strings.TrimSpace(entry.Get(cfg.NameAttr))
+		if name == "" {
+			name = email
+		}
+		phone := strings.TrimSpace(entry.Get(cfg.PhoneAttr))
+		position := strings.TrimSpace(entry.Get(cfg.TitleAttr))
+
+		departmentID := cfg.DefaultDepartmentID
+		for _, group := range entry.GetAll(cfg.GroupAttr) {
+			if id, ok := groupDepartments[ldap.NormalizeDN(group)]; ok {
+				departmentID = id
+				break
+			}
+		}
+
+		local, exists := localUsers[email]
+
+		// Akun lokal (misalnya Admin/HR hasil seed) tidak diambil alih: password
+		// lokalnya akan berhenti berlaku dan akun bisa ikut dinonaktifkan
+		if exists && local.AuthSource != AuthSourceLDAP {
+			report.Skipped = append(report.Skipped, types.LDAPSyncChange{
+				UserID: local.ID, Email: email, Name: local.Name, DN: entry.DN, Reason: "email sudah dipakai akun lokal (bukan akun LDAP)",
+			})
+			continue
+		}
+
+		if adDisabled(entry) {
+			if exists && local.AuthSource == AuthSourceLDAP && local.Status == UserStatusActive {
+				deactivations = append(deactivations, local.ID)
+				report.Deactivated = append(report.Deactivated, types.LDAPSyncChange{
+					UserID: local.ID, Email: email, Name: local.Name, DN: entry.DN, Reason: "akun dinonaktifkan di directory",
+				})
+			}
+			continue
+		}
+
+		if departmentID == 0 {
+			report.Skipped = append(report.Skipped, types.LDAPSyncChange{
+				Email: email, Name: name, DN: entry.DN, Reason: "tidak ada grup yang dipetakan ke departemen",
+			})
+			continue
+		}
+
+		if !exists {
+			creates = append(creates, ldapCreate{Name: name, Email: email, Phone: phone, Position: position, DepartmentID: departmentID})
+			report.Created = append(report.Created, types.LDAPSyncChange{Email: email, Name: name, DN: entry.DN})
+			continue
+		}
+
+		changes := make(map[string]types.FieldChange)
+		if local.Name != name {
+			changes["name"] = types.FieldChange{From: local.Name, To: name}
+		}
+		if phone != "" && local.Phone != phone {
+			changes["phone"] = types.FieldChange{From: local.Phone, To: phone}
+		}
+		if position != "" && local.Position != position {
+			changes["position"] = types.FieldChange{From: local.Position, To: position}
+		}
+		if local.DepartmentID != departmentID {
+			changes["department_id"] = types.FieldChange{From: local.DepartmentID, To: departmentID}
+		}
+		// Akun yang diaktifkan kembali (atau muncul lagi) di directory ikut aktif
+		if local.Status == UserStatusInactive {
+			changes["status"] = types.FieldChange{From: local.Status, To: UserStatusActive}
+		}
+
+		if len(changes) > 0 {
+			updates[local.ID] = changes
+			report.Updated = append(report.Updated, types.LDAPSyncChange{
+				UserID: local.ID, Email: email, Name: name, DN: entry.DN, Changes: changes,
+			})
+		}
+	}
+
+	// User LDAP yang sudah tidak ada di directory dinonaktifkan
+	for email, local := range localUsers {
+		if !seen[email] && local.AuthSource == AuthSourceLDAP && local.Status == UserStatusActive {
+			deactivations = append(deactivations, local.ID)
+			report.Deactivated = append(report.Deactivated, types.LDAPSyncChange{
+				UserID: local.ID, Email: email, Name: local.Name, Reason: "tidak ditemukan di directory",
+			})
+		}
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	activeLDAPUsers := 0
+	for _, local := range localUsers {
+		if local.AuthSource == AuthSourceLDAP && local.Status == UserStatusActive {
+			activeLDAPUsers++
+		}
+	}
+	if exceedsDeactivationLimit(len(deactivations), activeLDAPUsers, cfg.MaxDeactivateRatio) {
+		slog.Warn("LDAP sync aborted", "deactivations", len(deactivations), "active_ldap_users", activeLDAPUsers)
+		return report, ErrLDAPSyncTooManyDeactivations
+	}
+
+	if err := applyLDAPSync(creates, updates, deactivations); err != nil {
+		return report, err
+	}
+
+	slog.Info("LDAP sync finished",
+		"created", len(report.Created), "updated", len(report.Updated),
+		"deactivated", len(report.Deactivated), "skipped", len(report.Skipped))
+	return report, nil
+}
+
+func applyLDAPSync(creates []ldapCreate, updates map[int]map[string]types.FieldChange, deactivations []int) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("gagal memulai transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, user := range creates {
+		// Password lokal diisi nilai acak; user LDAP login dengan password directory
+		password, err := generateSecureToken()
+		if err != nil {
+			return fmt.Errorf("gagal membuat password acak: %w", err)
+		}
+		hashedPassword, err := hashPassword(password)
+		if err != nil {
+			return err
+		}
+
+		var userID int
+		err = tx.QueryRow(`
+			INSERT INTO users (name, email, phone, position, department_id, status, password_hash, auth_source, email_verified_at, created_at)
+			VALUES ($1, $2, $3, $4, $5, 'active', $6, $7, NOW(), NOW())
+			RETURNING id
+		`, user.Name, user.Email, user.Phone, user.Position, user.DepartmentID, hashedPassword, AuthSourceLDAP).Scan(&userID)
+		if err != nil {
+			return fmt.Errorf("gagal insert user %s: %w", user.Email, err)
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO user_roles (user_id, role_id)
+			SELECT $1, id FROM roles WHERE name = 'Employee'
+		`, userID)
+		if err != nil {
+			return fmt.Errorf("gagal assign role user: %w", err)
+		}
+	}
+
+	for userID, changes := range updates {
+		setClauses := []string{}
+		args := []interface{}{}
+		for field, change := range changes {
+			args = append(args, change.To)
+			setClauses = append(setClauses, fmt.Sprintf("%s = $%d", field, len(args)))
+		}
+		args = append(args, userID)
+
+		query := fmt.Sprintf("UPDATE users SET %s WHERE id = $%d", strings.Join(setClauses, ", "), len(args))
+		if _, err := tx.Exec(query, args...); err != nil {
+			return fmt.Errorf("gagal update user ID %d: %w", userID, err)
+		}
+	}
+
+	for _, userID := range deactivations {
+		if _, err := tx.Exec(`UPDATE users SET status = 'inactive' WHERE id = $1`, userID); err != nil {
+			return fmt.Errorf("gagal menonaktifkan user ID %d: %w", userID, err)
+		}
+		if err := revokeUserSessionsTx(tx, userID, ""); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("gagal commit transaction: %w", err)
+	}
+
+	for _, userID := range deactivations {
+		InvalidateUserStatus(userID)
+	}
+	for userID := range updates {
+		InvalidateUserStatus(userID)
+	}
+	return nil
+}
+
+// exceedsDeactivationLimit menandakan sinkronisasi akan menonaktifkan lebih dari
+// ratio user LDAP aktif (dibulatkan ke atas, minimal satu user boleh dinonaktifkan)
+func exceedsDeactivationLimit(deactivations int, activeUsers int, ratio float64) bool {
+	limit := int(math.Ceil(ratio * float64(activeUsers)))
+	if limit < 1 {
+		limit = 1
+	}
+	return deactivations > limit
+}
+
+// resolveGroupDepartments mengubah pemetaan DN grup -> nama departemen menjadi
+// DN grup -> id departemen
+func resolveGroupDepartments(mapping map[string]string) (map[string]int, error) {
+	resolved := make(map[string]int)
+	for groupDN, name := range mapping {
+		var id int
+		err := database.DB.QueryRow(`SELECT id FROM departments WHERE LOWER(name) = LOWER($1)`, name).Scan(&id)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("departemen %q pada LDAP_GROUP_DEPARTMENTS tidak ditemukan", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("gagal mengambil departemen: %w", err)
+		}
+		resolved[groupDN] = id
+	}
+	return resolved, nil
+}
+
+func getLocalUsersByEmail() (map[string]ldapLocalUser, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, name, LOWER(email), COALESCE(phone, ''), COALESCE(position, ''), department_id, status, auth_source
+		FROM users
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("gagal query user: %w", err)
+	}
+	defer rows.Close()
+
+	users := make(map[string]ldapLocalUser)
+	for rows.Next() {
+		var u ldapLocalUser
+		var email string
+		if err := rows.Scan(&u.ID, &u.Name, &email, &u.Phone, &u.Position, &u.DepartmentID, &u.Status, &u.AuthSource); err != nil {
+			return nil, fmt.Errorf("gagal scan user: %w", err)
+		}
+		users[email] = u
+	}
+
+	return users, rows.Err()
+}
+
+func adDisabled(entry ldap.Entry) bool {
+	flags, err := strconv.Atoi(entry.Get("userAccountControl"))
+	return err == nil && flags&adAccountDisabled != 0
+}
+
+func envOrDefault(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
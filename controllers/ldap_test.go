@@ -0,0 +1,357 @@
+package controllers
+
+import (
+	"backend/database"
+	"backend/ldap"
+	"backend/types"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+const (
+	testLDAPBaseDN    = "dc=company,dc=com"
+	testLDAPServiceDN = "cn=service,dc=company,dc=com"
+)
+
+// startTestLDAP menjalankan ldap.Server in-process berisi service account dan
+// entry yang diberikan (password semuanya "password123"), lalu mengarahkan
+// konfigurasi LDAP ke server tersebut selama test
+func startTestLDAP(t *testing.T, entries ...ldap.Entry) {
+	t.Helper()
+
+	server := ldap.NewServer()
+	server.AddEntry(ldap.Entry{DN: testLDAPServiceDN, Attributes: map[string][]string{"cn": {"service"}}}, "service123")
+	for _, entry := range entries {
+		server.AddEntry(entry, "password123")
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(listener)
+	t.Cleanup(func() { listener.Close() })
+
+	t.Setenv("LDAP_URL", "ldap://"+listener.Addr().String())
+	t.Setenv("LDAP_BIND_DN", testLDAPServiceDN)
+	t.Setenv("LDAP_BIND_PASSWORD", "service123")
+	t.Setenv("LDAP_BASE_DN", testLDAPBaseDN)
+	t.Setenv("LDAP_USER_FILTER", "")
+	t.Setenv("LDAP_GROUP_DEPARTMENTS", "")
+	t.Setenv("LDAP_DEFAULT_DEPARTMENT_ID", "")
+}
+
+func ldapPerson(uid string, attributes map[string][]string) ldap.Entry {
+	entry := ldap.Entry{
+		DN:         fmt.Sprintf("uid=%s,ou=People,%s", uid, testLDAPBaseDN),
+		Attributes: map[string][]string{"objectClass": {"person"}, "cn": {uid}},
+	}
+	for attr, values := range attributes {
+		entry.Attributes[attr] = values
+	}
+	return entry
+}
+
+func TestAuthenticateLDAP(t *testing.T) {
+	startTestLDAP(t,
+		ldapPerson("ahmad", map[string][]string{"mail": {"ahmad@company.com"}}),
+		ldapPerson("budi", map[string][]string{"mail": {"budi@company.com"}, "userAccountControl": {"514"}}),
+		ldapPerson("dewi", map[string][]string{"mail": {"dewi@company.com"}}),
+		ldapPerson("dewi2", map[string][]string{"mail": {"DEWI@company.com"}}),
+	)
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		wantErr  error
+	}{
+		{"password benar", "ahmad@company.com", "password123", nil},
+		{"password salah", "ahmad@company.com", "salah", ErrLDAPInvalidCredentials},
+		{"password kosong", "ahmad@company.com", "", ErrLDAPInvalidCredentials},
+		{"email tidak terdaftar", "tidak-ada@company.com", "password123", ErrLDAPInvalidCredentials},
+		{"akun dinonaktifkan di AD", "budi@company.com", "password123", ErrLDAPInvalidCredentials},
+		{"email dipakai dua entry", "dewi@company.com", "password123", ErrLDAPInvalidCredentials},
+		{"injection wildcard", "*", "password123", ErrLDAPInvalidCredentials},
+		{"injection filter", "ahmad@company.com)(mail=*", "password123", ErrLDAPInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := AuthenticateLDAP(tt.email, tt.password); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AuthenticateLDAP err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestADDisabled(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"", false},
+		{"512", false}, // NORMAL_ACCOUNT
+		{"514", true},  // NORMAL_ACCOUNT | ACCOUNTDISABLE
+		{"66050", true},
+		{"66048", false},
+		{"bukan-angka", false},
+	}
+
+	for _, tt := range tests {
+		entry := ldap.Entry{Attributes: map[string][]string{}}
+		if tt.value != "" {
+			entry.Attributes["useraccountcontrol"] = []string{tt.value}
+		}
+		if got := adDisabled(entry); got != tt.want {
+			t.Errorf("adDisabled(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+// ldapSyncFixture menyiapkan user lokal dan directory untuk test sinkronisasi:
+// satu user baru di directory, satu user LDAP yang datanya berubah, satu user
+// LDAP nonaktif yang aktif lagi di directory, satu akun lokal dengan email yang
+// sama dengan entry directory, satu user LDAP yang dinonaktifkan di AD dan satu
+// user LDAP yang sudah dihapus dari directory
+type ldapSyncFixture struct {
+	newEmail         string
+	updatedID        int
+	updatedEmail     string
+	reactivatedID    int
+	reactivatedEmail string
+	localID          int
+	localEmail       string
+	disabledID       int
+	disabledEmail    string
+	goneID           int
+	goneEmail        string
+}
+
+func setupLDAPSync(t *testing.T) ldapSyncFixture {
+	t.Helper()
+	requireTestDB(t)
+
+	var f ldapSyncFixture
+	f.newEmail = fmt.Sprintf("ldap-new-%d@test.local", time.Now().UnixNano())
+	t.Cleanup(func() { deleteTestUsers(t, f.newEmail) })
+
+	f.updatedID, f.updatedEmail = createTestUser(t, "ldap-updated")
+	f.reactivatedID, f.reactivatedEmail = createTestUser(t, "ldap-reactivated")
+	f.localID, f.localEmail = createTestUser(t, "ldap-local")
+	f.disabledID, f.disabledEmail = createTestUser(t, "ldap-disabled")
+	f.goneID, f.goneEmail = createTestUser(t, "ldap-gone")
+	_, err := database.DB.Exec(`
+		UPDATE users SET auth_source = 'ldap' WHERE id IN ($1, $2, $3, $4)
+	`, f.updatedID, f.reactivatedID, f.disabledID, f.goneID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.DB.Exec(`UPDATE users SET status = 'inactive' WHERE id = $1`, f.reactivatedID); err != nil {
+		t.Fatal(err)
+	}
+
+	var departmentID int
+	if err := database.DB.QueryRow(`SELECT department_id FROM users WHERE id = $1`, f.updatedID).Scan(&departmentID); err != nil {
+		t.Fatal(err)
+	}
+
+	startTestLDAP(t,
+		ldapPerson("new", map[string][]string{"mail": {f.newEmail}, "cn": {"User Baru"}}),
+		ldapPerson("updated", map[string][]string{
+			"mail":            {f.updatedEmail},
+			"cn":              {"Nama Dari Directory"},
+			"telephoneNumber": {"+62 811-0000-0000"},
+			"title":           {"Engineer"},
+		}),
+		ldapPerson("reactivated", map[string][]string{"mail": {f.reactivatedEmail}, "cn": {"ldap-reactivated"}}),
+		ldapPerson("local", map[string][]string{"mail": {f.localEmail}, "cn": {"Nama Dari Directory"}}),
+		ldapPerson("disabled", map[string][]string{"mail": {f.disabledEmail}, "cn": {"ldap-disabled"}, "userAccountControl": {"514"}}),
+	)
+	t.Setenv("LDAP_DEFAULT_DEPARTMENT_ID", fmt.Sprint(departmentID))
+	// Fixture sengaja menonaktifkan sebagian besar user LDAP-nya
+	t.Setenv("LDAP_SYNC_MAX_DEACTIVATE_RATIO", "1")
+
+	return f
+}
+
+func findSyncChange(changes []types.LDAPSyncChange, email string) (types.LDAPSyncChange, bool) {
+	for _, change := range changes {
+		if change.Email == email {
+			return change, true
+		}
+	}
+	return types.LDAPSyncChange{}, false
+}
+
+type ldapTestUser struct {
+	name, phone, position, status, authSource string
+}
+
+func loadLDAPTestUser(t *testing.T, email string) (ldapTestUser, bool) {
+	t.Helper()
+
+	var u ldapTestUser
+	err := database.DB.QueryRow(`
+		SELECT name, COALESCE(phone, '<null>'), COALESCE(position, '<null>'), status, auth_source
+		FROM users WHERE LOWER(email) = LOWER($1)
+	`, email).Scan(&u.name, &u.phone, &u.position, &u.status, &u.authSource)
+	if err != nil {
+		return u, false
+	}
+	return u, true
+}
+
+func TestSyncLDAPUsersDryRunWritesNothing(t *testing.T) {
+	f := setupLDAPSync(t)
+
+	before := map[string]ldapTestUser{}
+	for _, email := range []string{f.updatedEmail, f.reactivatedEmail, f.localEmail, f.disabledEmail, f.goneEmail} {
+		before[email], _ = loadLDAPTestUser(t, email)
+	}
+
+	report, err := SyncLDAPUsers(true)
+	if err != nil {
+		t.Fatalf("SyncLDAPUsers: %v", err)
+	}
+	if !report.DryRun {
+		t.Error("report.DryRun = false")
+	}
+
+	if _, ok := findSyncChange(report.Created, f.newEmail); !ok {
+		t.Errorf("report tidak berisi user baru %s", f.newEmail)
+	}
+	if change, ok := findSyncChange(report.Updated, f.updatedEmail); !ok || change.Changes["name"].To != "Nama Dari Directory" {
+		t.Errorf("report update = %+v, want perubahan nama", change)
+	}
+	if change, ok := findSyncChange(report.Updated, f.reactivatedEmail); !ok || change.Changes["status"].To != UserStatusActive {
+		t.Errorf("report update = %+v, want status aktif kembali", change)
+	}
+	if _, ok := findSyncChange(report.Skipped, f.localEmail); !ok {
+		t.Errorf("akun lokal %s tidak dilaporkan sebagai skipped", f.localEmail)
+	}
+	if _, ok := findSyncChange(report.Updated, f.localEmail); ok {
+		t.Errorf("akun lokal %s diambil alih oleh directory", f.localEmail)
+	}
+	for _, email := range []string{f.disabledEmail, f.goneEmail} {
+		if _, ok := findSyncChange(report.Deactivated, email); !ok {
+			t.Errorf("report tidak menonaktifkan %s", email)
+		}
+	}
+
+	if _, exists := loadLDAPTestUser(t, f.newEmail); exists {
+		t.Error("dry run membuat user baru")
+	}
+	for email, want := range before {
+		if got, _ := loadLDAPTestUser(t, email); got != want {
+			t.Errorf("dry run mengubah %s: %+v -> %+v", email, want, got)
+		}
+	}
+}
+
+// Sinkronisasi menonaktifkan semua user LDAP aktif yang tidak ada di directory
+// test, jadi database test tidak boleh berisi user LDAP selain milik test ini
+func TestSyncLDAPUsersApply(t *testing.T) {
+	f := setupLDAPSync(t)
+
+	if _, err := SyncLDAPUsers(false); err != nil {
+		t.Fatalf("SyncLDAPUsers: %v", err)
+	}
+
+	created, ok := loadLDAPTestUser(t, f.newEmail)
+	if !ok {
+		t.Fatalf("user baru %s tidak dibuat", f.newEmail)
+	}
+	// Atribut yang kosong di directory disimpan sebagai '' (bukan NULL)
+	if want := (ldapTestUser{"User Baru", "", "", UserStatusActive, AuthSourceLDAP}); created != want {
+		t.Errorf("user baru = %+v, want %+v", created, want)
+	}
+
+	updated, _ := loadLDAPTestUser(t, f.updatedEmail)
+	if want := (ldapTestUser{"Nama Dari Directory", "+62 811-0000-0000", "Engineer", UserStatusActive, AuthSourceLDAP}); updated != want {
+		t.Errorf("user diperbarui = %+v, want %+v", updated, want)
+	}
+
+	for _, email := range []string{f.disabledEmail, f.goneEmail} {
+		if u, _ := loadLDAPTestUser(t, email); u.status != UserStatusInactive {
+			t.Errorf("status %s = %s, want %s", email, u.status, UserStatusInactive)
+		}
+	}
+
+	if u, _ := loadLDAPTestUser(t, f.reactivatedEmail); u.status != UserStatusActive {
+		t.Errorf("status user yang aktif lagi = %s, want %s", u.status, UserStatusActive)
+	}
+	if u, _ := loadLDAPTestUser(t, f.localEmail); u.authSource != AuthSourceLocal || u.name != "ldap-local" {
+		t.Errorf("akun lokal berubah: %+v", u)
+	}
+
+	// Sinkronisasi kedua tidak menemukan perubahan lagi untuk user test
+	report, err := SyncLDAPUsers(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, email := range []string{f.newEmail, f.updatedEmail, f.reactivatedEmail, f.disabledEmail, f.goneEmail} {
+		_, created := findSyncChange(report.Created, email)
+		_, updated := findSyncChange(report.Updated, email)
+		_, deactivated := findSyncChange(report.Deactivated, email)
+		if created || updated || deactivated {
+			t.Errorf("sinkronisasi kedua masih mengubah %s", email)
+		}
+	}
+}
+
+func TestSyncLDAPUsersAbortsOnMassDeactivation(t *testing.T) {
+	f := setupLDAPSync(t)
+	t.Setenv("LDAP_SYNC_MAX_DEACTIVATE_RATIO", "0.2")
+
+	// Dry run tetap mengembalikan rencana perubahan
+	if _, err := SyncLDAPUsers(true); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+
+	if _, err := SyncLDAPUsers(false); !errors.Is(err, ErrLDAPSyncTooManyDeactivations) {
+		t.Fatalf("err = %v, want ErrLDAPSyncTooManyDeactivations", err)
+	}
+	if u, _ := loadLDAPTestUser(t, f.goneEmail); u.status != UserStatusActive {
+		t.Errorf("user dinonaktifkan walaupun sinkronisasi dibatalkan")
+	}
+	if _, exists := loadLDAPTestUser(t, f.newEmail); exists {
+		t.Error("user baru dibuat walaupun sinkronisasi dibatalkan")
+	}
+}
+
+func TestSyncLDAPUsersAbortsOnEmptyDirectory(t *testing.T) {
+	startTestLDAP(t)
+
+	for _, dryRun := range []bool{true, false} {
+		if _, err := SyncLDAPUsers(dryRun); !errors.Is(err, ErrLDAPSyncEmptyDirectory) {
+			t.Fatalf("dryRun=%v: err = %v, want ErrLDAPSyncEmptyDirectory", dryRun, err)
+		}
+	}
+}
+
+func TestExceedsDeactivationLimit(t *testing.T) {
+	tests := []struct {
+		deactivations, active int
+		ratio                 float64
+		want                  bool
+	}{
+		{0, 0, 0.2, false},
+		{1, 1, 0.2, false}, // minimal satu user selalu boleh dinonaktifkan
+		{2, 3, 0.2, true},
+		{20, 100, 0.2, false},
+		{21, 100, 0.2, true},
+		{3, 10, 0.25, false}, // batas dibulatkan ke atas
+		{100, 100, 1, false},
+		{2, 100, 0, true},
+	}
+
+	for _, tt := range tests {
+		if got := exceedsDeactivationLimit(tt.deactivations, tt.active, tt.ratio); got != tt.want {
+			t.Errorf("exceedsDeactivationLimit(%d, %d, %v) = %v, want %v", tt.deactivations, tt.active, tt.ratio, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"backend/database"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Aturan proteksi brute-force login. Semua penghitung disimpan di database
+// (login_attempts dan users.failed_login_count) sehingga berlaku sama di
+// semua instance server.
+const (
+	progressiveDelayAfter = 3                // mulai menunda setelah 3 kali gagal berturut-turut
+	maxProgressiveDelay   = 30 * time.Second // penundaan maksimum antar percobaan
+	accountLockThreshold  = 5                // kunci akun setelah 5 kali gagal berturut-turut
+	accountLockDuration   = 15 * time.Minute
+	ipFailureThreshold    = 20 // blokir IP setelah 20 kali gagal dalam ipFailureWindow
+	ipFailureWindow       = 15 * time.Minute
+	loginAttemptRetention = 30 * 24 * time.Hour
+)
+
+var (
+	ErrAccountLocked   = errors.New("akun dikunci sementara karena terlalu banyak percobaan login gagal")
+	ErrLoginThrottled  = errors.New("terlalu cepat, silakan tunggu sebelum mencoba login lagi")
+	ErrTooManyAttempts = errors.New("terlalu banyak percobaan login gagal dari alamat IP ini")
+)
+
+// Jenis event pada tabel security_events
+const (
+	SecurityEventAccountLocked   = "account_locked"
+	SecurityEventAccountUnlocked = "account_unlocked"
+	SecurityEventIPBlocked       = "ip_blocked"
+)
+
+// CheckLoginAllowed dipanggil sebelum memverifikasi password. Jika login belum
+// boleh dicoba, mengembalikan salah satu error di atas beserta lama waktu tunggu.
+func CheckLoginAllowed(email string, ip string) (time.Duration, error) {
+	email = NormalizeEmail(email)
+
+	// Batas per IP. Waktu dihitung di database (NOW()) agar konsisten antar instance.
+	var ipFailures int
+	var ipWaitSeconds sql.NullFloat64
+	err := database.DB.QueryRow(`
+		SELECT COUNT(*), EXTRACT(EPOCH FROM MIN(attempted_at) + make_interval(secs => $2) - NOW())
+		FROM login_attempts
+		WHERE ip = $1 AND success = false AND attempted_at > NOW() - make_interval(secs => $2)
+	`, ip, ipFailureWindow.Seconds()).Scan(&ipFailures, &ipWaitSeconds)
+
+	if err != nil {
+		return 0, fmt.Errorf("gagal cek percobaan login per IP: %w", err)
+	}
+	if ipFailures >= ipFailureThreshold {
+		return retryAfter(ipWaitSeconds.Float64), ErrTooManyAttempts
+	}
+
+	// Batas per akun
+	var lockWaitSeconds, lastFailureAgo sql.NullFloat64
+	var failedCount int
+	err = database.DB.QueryRow(`
+		SELECT
+			EXTRACT(EPOCH FROM u.locked_until - NOW()),
+			u.failed_login_count,
+			EXTRACT(EPOCH FROM NOW() - (
+				SELECT MAX(attempted_at) FROM login_attempts la WHERE la.user_id = u.id AND la.success = false
+			))
+		FROM users u
+		WHERE LOWER(u.email) = $1
+	`, email).Scan(&lockWaitSeconds, &failedCount, &lastFailureAgo)
+
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("gagal cek status lock akun: %w", err)
+	}
+
+	if lockWaitSeconds.Valid && lockWaitSeconds.Float64 > 0 {
+		return retryAfter(lockWaitSeconds.Float64), ErrAccountLocked
+	}
+
+	if delay := progressiveDelay(failedCount); delay > 0 && lastFailureAgo.Valid {
+		if wait := delay.Seconds() - lastFailureAgo.Float64; wait > 0 {
+			return retryAfter(wait), ErrLoginThrottled
+		}
+	}
+
+	return 0, nil
+}
+
+// RecordLoginFailure mencatat percobaan login gagal. userID 0 berarti email tidak
+// terdaftar. Jika ambang batas tercapai, akun dikunci sementara dan event
+// keamanan dicatat.
+func RecordLoginFailure(email string, userID int, ip string) error {
+	_, err := database.DB.Exec(`
+		INSERT INTO login_attempts (email, user_id, ip, success)
+		VALUES ($1, NULLIF($2, 0), $3, false)
+	`, NormalizeEmail(email), userID, ip)
+	if err != nil {
+		return fmt.Errorf("gagal mencatat percobaan login: %w", err)
+	}
+
+	// Catat event saat IP tepat mencapai ambang batas (sekali per blokir)
+	var ipFailures int
+	err = database.DB.QueryRow(`
+		SELECT COUNT(*) FROM login_attempts
+		WHERE ip = $1 AND success = false AND attempted_at > NOW() - make_interval(secs => $2)
+	`, ip, ipFailureWindow.Seconds()).Scan(&ipFailures)
+	if err != nil {
+		return fmt.Errorf("gagal menghitung percobaan login per IP: %w", err)
+	}
+	if ipFailures == ipFailureThreshold {
+		RecordSecurityEvent(SecurityEventIPBlocked, 0, 0, ip,
+			fmt.Sprintf("%d percobaan login gagal dalam %d menit", ipFailures, int(ipFailureWindow.Minutes())))
+	}
+
+	if userID == 0 {
+		return nil
+	}
+
+	var failedCount int
+	err = database.DB.QueryRow(`
+		UPDATE users SET failed_login_count = failed_login_count + 1
+		WHERE id = $1
+		RETURNING failed_login_count
+	`, userID).Scan(&failedCount)
+	if err != nil {
+		return fmt.Errorf("gagal update jumlah login gagal: %w", err)
+	}
+
+	if failedCount < accountLockThreshold {
+		return nil
+	}
+
+	// Penghitung direset saat dikunci, sehingga setelah masa kunci berakhir
+	// user kembali mendapat jatah percobaan (dengan penundaan bertahap)
+	_, err = database.DB.Exec(`
+		UPDATE users SET locked_until = NOW() + make_interval(secs => $1), failed_login_count = 0
+		WHERE id = $2
+	`, accountLockDuration.Seconds(), userID)
+	if err != nil {
+		return fmt.Errorf("gagal mengunci akun: %w", err)
+	}
+
+	RecordSecurityEvent(SecurityEventAccountLocked, userID, 0, ip,
+		fmt.Sprintf("%d percobaan login gagal berturut-turut, dikunci %d menit", failedCount, int(accountLockDuration.Minutes())))
+	slog.Warn("Account locked after failed login attempts", "user_id", userID, "failed_count", failedCount)
+	return nil
+}
+
+// RecordLoginSuccess mencatat login berhasil dan mereset penghitung gagal
+func RecordLoginSuccess(email string, userID int, ip string) error {
+	_, err := database.DB.Exec(`
+		INSERT INTO login_attempts (email, user_id, ip, success)
+		VALUES ($1, $2, $3, true)
+	`, NormalizeEmail(email), userID, ip)
+	if err != nil {
+		return fmt.Errorf("gagal mencatat percobaan login: %w", err)
+	}
+
+	_, err = database.DB.Exec(`
+		UPDATE users SET failed_login_count = 0, locked_until = NULL
+		WHERE id = $1
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("gagal reset jumlah login gagal: %w", err)
+	}
+
+	return nil
+}
+
+// UnlockUser membuka kunci akun secara manual (oleh HR)
+func UnlockUser(userID int, actorID int, ip string) error {
+	result, err := database.DB.Exec(`
+		UPDATE users SET locked_until = NULL, failed_login_count = 0
+		WHERE id = $1
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("gagal membuka kunci akun: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("gagal cek rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	RecordSecurityEvent(SecurityEventAccountUnlocked, userID, actorID, ip, "Kunci akun dibuka manual")
+	slog.Info("Account unlocked", "user_id", userID, "actor_id", actorID)
+	return nil
+}
+
+// PruneLoginAttempts menghapus riwayat percobaan login yang sudah lama
+func PruneLoginAttempts() error {
+	result, err := database.DB.Exec(`
+		DELETE FROM login_attempts WHERE attempted_at < NOW() - make_interval(secs => $1)
+	`, loginAttemptRetention.Seconds())
+	if err != nil {
+		return fmt.Errorf("gagal menghapus riwayat login: %w", err)
+	}
+
+	if deleted, err := result.RowsAffected(); err == nil && deleted > 0 {
+		slog.Info("Pruned old login attempts", "deleted", deleted)
+	}
+	return nil
+}
+
+// RecordSecurityEvent mencatat event keamanan ke tabel security_events.
+// userID/actorID 0 berarti tidak ada. Kegagalan mencatat hanya di-log agar
+// tidak menggagalkan proses utama.
+func RecordSecurityEvent(eventType string, userID int, actorID int, ip string, details string) {
+	_, err := database.DB.Exec(`
+		INSERT INTO security_events (event_type, user_id, actor_id, ip, details)
+		VALUES ($1, NULLIF($2, 0), NULLIF($3, 0), NULLIF($4, ''), $5)
+	`, eventType, userID, actorID, ip, details)
+	if err != nil {
+		slog.Error("Failed to record security event", "event_type", eventType, "user_id", userID, "error", err)
+	}
+}
+
+// progressiveDelay: 1 detik setelah percobaan gagal ke-3, lalu berlipat dua
+// (2, 4, 8, ...) sampai maxProgressiveDelay
+func progressiveDelay(failedCount int) time.Duration {
+	if failedCount < progressiveDelayAfter {
+		return 0
+	}
+
+	delay := time.Second << uint(failedCount-progressiveDelayAfter)
+	if delay > maxProgressiveDelay || delay <= 0 {
+		return maxProgressiveDelay
+	}
+	return delay
+}
+
+// retryAfter mengubah sisa waktu tunggu (detik) menjadi durasi, minimal 1 detik
+func retryAfter(seconds float64) time.Duration {
+	wait := time.Duration(seconds * float64(time.Second))
+	if wait < time.Second {
+		return time.Second
+	}
+	return wait.Round(time.Second)
+}
+
+// NormalizeEmail menyeragamkan email (huruf kecil, tanpa spasi) sebelum disimpan
+// atau dibandingkan. Query pencarian user memakai LOWER(email) = email hasil normalisasi.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"backend/database"
+	"backend/types"
+	"log/slog"
+	"time"
+)
+
+// GetMyAttendance mengambil riwayat absensi bulanan milik user sendiri,
+// lengkap dengan cuti, hari libur dan pengajuan koreksi pada bulan tersebut
+func GetMyAttendance(userID int, month int, year int) (types.MyAttendanceResponse, error) {
+	attendance, err := GetEmployeeMonthlyAttendance(userID, month, year)
+	if err != nil {
+		return types.MyAttendanceResponse{}, err
+	}
+
+	holidays, err := getHolidayList(month, year)
+	if err != nil {
+		slog.Error("Error fetching holidays", "error", err)
+		return types.MyAttendanceResponse{}, err
+	}
+
+	leaves, err := getUserLeaveRequests(userID, month, year)
+	if err != nil {
+		slog.Error("Error fetching leave requests", "user_id", userID, "error", err)
+		return types.MyAttendanceResponse{}, err
+	}
+
+	corrections, err := getUserAttendanceCorrections(userID, month, year)
+	if err != nil {
+		slog.Error("Error fetching attendance corrections", "user_id", userID, "error", err)
+		return types.MyAttendanceResponse{}, err
+	}
+
+	return types.MyAttendanceResponse{
+		EmployeeMonthlyAttendanceResponse: attendance,
+		Leaves:                            leaves,
+		Holidays:                          holidays,
+		Corrections:                       corrections,
+	}, nil
+}
+
+func getHolidayList(month int, year int) ([]types.Holiday, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, TO_CHAR(date, 'YYYY-MM-DD'), name
+		FROM holidays
+		WHERE EXTRACT(MONTH FROM date) = $1
+		  AND EXTRACT(YEAR FROM date) = $2
+		ORDER BY date ASC
+	`, month, year)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	holidays := []types.Holiday{}
+	for rows.Next() {
+		var holiday types.Holiday
+		if err := rows.Scan(&holiday.ID, &holiday.Date, &holiday.Name); err != nil {
+			return nil, err
+		}
+		holidays = append(holidays, holiday)
+	}
+
+	return holidays, rows.Err()
+}
+
+// getUserLeaveRequests mengambil semua pengajuan cuti user yang beririsan dengan bulan tersebut
+func getUserLeaveRequests(userID int, month int, year int) ([]types.LeaveRequest, error) {
+	firstDay := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := firstDay.AddDate(0, 1, -1)
+
+	rows, err := database.DB.Query(`
+		SELECT id, user_id, TO_CHAR(start_date, 'YYYY-MM-DD'), TO_CHAR(end_date, 'YYYY-MM-DD'),
+		       reason, status, review_note, reviewed_by, reviewed_at, created_at
+		FROM leave_requests
+		WHERE user_id = $1
+		  AND start_date <= $3::date
+		  AND end_date >= $2::date
+		ORDER BY start_date ASC
+	`, userID, firstDay.Format("2006-01-02"), lastDay.Format("2006-01-02"))
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	leaves := []types.LeaveRequest{}
+	for rows.Next() {
+		var leave types.LeaveRequest
+		err := rows.Scan(
+			&leave.ID,
+			&leave.UserID,
+			&leave.StartDate,
+			&leave.EndDate,
+			&leave.Reason,
+			&leave.Status,
+			&leave.ReviewNote,
+			&leave.ReviewedBy,
+			&leave.ReviewedAt,
+			&leave.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leave)
+	}
+
+	return leaves, rows.Err()
+}
+
+func getUserAttendanceCorrections(userID int, month int, year int) ([]types.AttendanceCorrection, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, user_id, TO_CHAR(date, 'YYYY-MM-DD'), TO_CHAR(requested_check_in, 'HH24:MI:SS'),
+		       reason, status, review_note, reviewed_by, reviewed_at, created_at
+		FROM attendance_corrections
+		WHERE user_id = $1
+		  AND EXTRACT(MONTH FROM date) = $2
+		  AND EXTRACT(YEAR FROM date) = $3
+		ORDER BY date ASC
+	`, userID, month, year)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	corrections := []types.AttendanceCorrection{}
+	for rows.Next() {
+		var correction types.AttendanceCorrection
+		err := rows.Scan(
+			&correction.ID,
+			&correction.UserID,
+			&correction.Date,
+			&correction.RequestedCheckIn,
+			&correction.Reason,
+			&correction.Status,
+			&correction.ReviewNote,
+			&correction.ReviewedBy,
+			&correction.ReviewedAt,
+			&correction.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		corrections = append(corrections, correction)
+	}
+
+	return corrections, rows.Err()
+}
@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"backend/database"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrOIDCEmailNotVerified = errors.New("email dari identity provider belum terverifikasi")
+	ErrOIDCUserNotFound     = errors.New("email belum terdaftar di aplikasi absensi, silakan hubungi HR")
+	ErrOIDCSubjectMismatch  = errors.New("akun sudah terhubung dengan identitas SSO lain")
+)
+
+// ResolveOIDCUser mencari user untuk identitas dari identity provider. User
+// dicocokkan berdasarkan oidc_subject, lalu berdasarkan email (dan subject-nya
+// disimpan untuk login berikutnya). Jika OIDC_JIT_PROVISIONING=true, user yang
+// belum terdaftar dibuat otomatis di departemen OIDC_DEFAULT_DEPARTMENT_ID.
+// Mengembalikan id dan status user.
+func ResolveOIDCUser(subject string, email string, name string, emailVerified bool) (int, string, error) {
+	var userID int
+	var status string
+	err := database.DB.QueryRow(`
+		SELECT id, status FROM users WHERE oidc_subject = $1
+	`, subject).Scan(&userID, &status)
+	if err == nil {
+		return userID, status, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, "", fmt.Errorf("gagal mencari user SSO: %w", err)
+	}
+
+	// Mencocokkan berdasarkan email hanya aman jika email sudah diverifikasi
+	// oleh identity provider
+	email = NormalizeEmail(email)
+	if email == "" || !emailVerified {
+		return 0, "", ErrOIDCEmailNotVerified
+	}
+
+	var linkedSubject sql.NullString
+	err = database.DB.QueryRow(`
+		SELECT id, status, oidc_subject FROM users WHERE LOWER(email) = $1
+	`, email).Scan(&userID, &status, &linkedSubject)
+
+	switch {
+	case err == sql.ErrNoRows:
+		if os.Getenv("OIDC_JIT_PROVISIONING") != "true" {
+			return 0, "", ErrOIDCUserNotFound
+		}
+		userID, err = provisionOIDCUser(subject, email, name)
+		if err != nil {
+			return 0, "", err
+		}
+		return userID, UserStatusActive, nil
+	case err != nil:
+		return 0, "", fmt.Errorf("gagal mencari user: %w", err)
+	case linkedSubject.Valid:
+		return 0, "", ErrOIDCSubjectMismatch
+	}
+
+	_, err = database.DB.Exec(`
+		UPDATE users SET oidc_subject = $1, email_verified_at = COALESCE(email_verified_at, NOW())
+		WHERE id = $2
+	`, subject, userID)
+	if err != nil {
+		return 0, "", fmt.Errorf("gagal menghubungkan akun SSO: %w", err)
+	}
+
+	slog.Info("User linked to OIDC subject", "user_id", userID)
+	return userID, status, nil
+}
+
+// provisionOIDCUser membuat user baru (just-in-time) dengan role Employee.
+// Password diisi nilai acak sehingga user hanya bisa login lewat SSO
+// (atau setelah reset password).
+func provisionOIDCUser(subject string, email string, name string) (int, error) {
+	departmentID, err := strconv.Atoi(os.Getenv("OIDC_DEFAULT_DEPARTMENT_ID"))
+	if err != nil || departmentID <= 0 {
+		return 0, fmt.Errorf("OIDC_DEFAULT_DEPARTMENT_ID wajib diisi untuk JIT provisioning")
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = email
+	}
+
+	password, err := generateSecureToken()
+	if err != nil {
+		return 0, fmt.Errorf("gagal membuat password acak: %w", err)
+	}
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("gagal memulai transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID int
+	err = tx.QueryRow(`
+		INSERT INTO users (name, email, phone, position, department_id, status, password_hash, oidc_subject, email_verified_at, created_at)
+		VALUES ($1, $2, '', '', $3, 'active', $4, $5, NOW(), NOW())
+		RETURNING id
+	`, name, email, departmentID, hashedPassword, subject).Scan(&userID)
+	if err != nil {
+		return 0, fmt.Errorf("gagal insert user SSO: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO user_roles (user_id, role_id)
+		SELECT $1, id FROM roles WHERE name = 'Employee'
+	`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("gagal assign role user: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("gagal commit transaction: %w", err)
+	}
+
+	slog.Info("User provisioned from OIDC login", "user_id", userID)
+	return userID, nil
+}
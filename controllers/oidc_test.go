@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"backend/database"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveOIDCUserLinksByVerifiedEmail(t *testing.T) {
+	requireTestDB(t)
+	t.Setenv("OIDC_JIT_PROVISIONING", "false")
+
+	userID, email := createTestUser(t, "oidc-link")
+	subject := fmt.Sprintf("sub-link-%d", time.Now().UnixNano())
+
+	if _, _, err := ResolveOIDCUser(subject, email, "", false); !errors.Is(err, ErrOIDCEmailNotVerified) {
+		t.Fatalf("email belum terverifikasi: err = %v, want ErrOIDCEmailNotVerified", err)
+	}
+
+	// Email dicocokkan tanpa membedakan huruf besar/kecil
+	gotID, status, err := ResolveOIDCUser(subject, "  "+strings.ToUpper(email)+" ", "", true)
+	if err != nil {
+		t.Fatalf("ResolveOIDCUser: %v", err)
+	}
+	if gotID != userID || status != UserStatusActive {
+		t.Fatalf("ResolveOIDCUser = (%d, %s), want (%d, %s)", gotID, status, userID, UserStatusActive)
+	}
+
+	var linked sql.NullString
+	if err := database.DB.QueryRow(`SELECT oidc_subject FROM users WHERE id = $1`, userID).Scan(&linked); err != nil {
+		t.Fatal(err)
+	}
+	if linked.String != subject {
+		t.Fatalf("oidc_subject = %q, want %q", linked.String, subject)
+	}
+
+	// Login berikutnya dicocokkan lewat subject walaupun email di IdP berubah
+	gotID, _, err = ResolveOIDCUser(subject, "email-baru@test.local", "", false)
+	if err != nil || gotID != userID {
+		t.Fatalf("login lewat subject = (%d, %v), want (%d, nil)", gotID, err, userID)
+	}
+}
+
+func TestResolveOIDCUserRejectsSubjectConflict(t *testing.T) {
+	requireTestDB(t)
+
+	userID, email := createTestUser(t, "oidc-conflict")
+	linkedSubject := fmt.Sprintf("sub-lama-%d", time.Now().UnixNano())
+	if _, err := database.DB.Exec(`UPDATE users SET oidc_subject = $1 WHERE id = $2`, linkedSubject, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err := ResolveOIDCUser(linkedSubject+"-lain", email, "", true)
+	if !errors.Is(err, ErrOIDCSubjectMismatch) {
+		t.Fatalf("err = %v, want ErrOIDCSubjectMismatch", err)
+	}
+
+	var stored string
+	if err := database.DB.QueryRow(`SELECT oidc_subject FROM users WHERE id = $1`, userID).Scan(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored != linkedSubject {
+		t.Fatalf("oidc_subject berubah menjadi %q", stored)
+	}
+}
+
+func TestResolveOIDCUserUnknownEmail(t *testing.T) {
+	requireTestDB(t)
+
+	email := fmt.Sprintf("oidc-unknown-%d@test.local", time.Now().UnixNano())
+	subject := "sub-" + email
+	t.Cleanup(func() { deleteTestUsers(t, email) })
+
+	t.Setenv("OIDC_JIT_PROVISIONING", "false")
+	if _, _, err := ResolveOIDCUser(subject, email, "", true); !errors.Is(err, ErrOIDCUserNotFound) {
+		t.Fatalf("tanpa JIT: err = %v, want ErrOIDCUserNotFound", err)
+	}
+
+	var departmentID int
+	if err := database.DB.QueryRow(`SELECT id FROM departments ORDER BY id LIMIT 1`).Scan(&departmentID); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("OIDC_JIT_PROVISIONING", "true")
+	t.Setenv("OIDC_DEFAULT_DEPARTMENT_ID", fmt.Sprint(departmentID))
+
+	userID, status, err := ResolveOIDCUser(subject, email, "Karyawan SSO", true)
+	if err != nil {
+		t.Fatalf("JIT provisioning: %v", err)
+	}
+	if status != UserStatusActive {
+		t.Fatalf("status = %s, want %s", status, UserStatusActive)
+	}
+
+	var name, phone, position, role string
+	err = database.DB.QueryRow(`
+		SELECT u.name, u.phone, u.position, r.name
+		FROM users u
+		JOIN user_roles ur ON ur.user_id = u.id
+		JOIN roles r ON r.id = ur.role_id
+		WHERE u.id = $1 AND u.oidc_subject = $2 AND u.department_id = $3
+	`, userID, subject, departmentID).Scan(&name, &phone, &position, &role)
+	if err != nil {
+		t.Fatalf("user hasil provisioning tidak ditemukan: %v", err)
+	}
+	if name != "Karyawan SSO" || phone != "" || position != "" || role != "Employee" {
+		t.Errorf("user hasil provisioning = (%q, %q, %q, %q)", name, phone, position, role)
+	}
+}
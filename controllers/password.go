@@ -0,0 +1,361 @@
+package controllers
+
+import (
+	"backend/database"
+	"backend/mailer"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	minPasswordLength = 8
+	// bcrypt hanya menerima maksimal 72 byte
+	maxPasswordBytes      = 72
+	passwordResetTokenTTL = 30 * time.Minute
+)
+
+var (
+	ErrWeakPassword          = errors.New("password tidak memenuhi syarat")
+	ErrWrongPassword         = errors.New("password saat ini salah")
+	ErrInvalidResetToken     = errors.New("token reset password tidak valid atau sudah kedaluwarsa")
+	ErrPasswordUnchanged     = errors.New("password baru harus berbeda dari password lama")
+	ErrPasswordIsRequired    = errors.New("password tidak boleh kosong")
+	ErrPasswordManagedByLDAP = errors.New("password akun ini dikelola oleh directory perusahaan (LDAP), ganti password melalui directory")
+)
+
+// commonPasswords adalah daftar password yang terlalu umum untuk dipakai
+var commonPasswords = map[string]bool{
+	"password": true, "password1": true, "password123": true, "12345678": true,
+	"123456789": true, "qwerty123": true, "admin123": true, "welcome123": true,
+	"iloveyou": true, "11111111": true, "abc12345": true, "passw0rd": true,
+}
+
+// ValidatePasswordStrength mengecek aturan password: minimal 8 karakter, maksimal
+// 72 byte, mengandung huruf dan angka, bukan password umum, dan tidak sama dengan email.
+func ValidatePasswordStrength(password string, email string) error {
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("%w: minimal %d karakter", ErrWeakPassword, minPasswordLength)
+	}
+	if len(password) > maxPasswordBytes {
+		return fmt.Errorf("%w: maksimal %d byte", ErrWeakPassword, maxPasswordBytes)
+	}
+
+	hasLetter, hasDigit := false, false
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return fmt.Errorf("%w: harus mengandung huruf dan angka", ErrWeakPassword)
+	}
+
+	lower := strings.ToLower(password)
+	if commonPasswords[lower] {
+		return fmt.Errorf("%w: password terlalu umum", ErrWeakPassword)
+	}
+
+	if email != "" {
+		localPart := strings.ToLower(strings.SplitN(email, "@", 2)[0])
+		if lower == strings.ToLower(email) || lower == localPart {
+			return fmt.Errorf("%w: password tidak boleh sama dengan email", ErrWeakPassword)
+		}
+	}
+
+	return nil
+}
+
+// hashPassword membuat bcrypt hash dari password
+func hashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("gagal hash password: %w", err)
+	}
+	return string(hashed), nil
+}
+
+// generateTemporaryPassword membuat password sementara acak yang lolos aturan kekuatan
+func generateTemporaryPassword() (string, error) {
+	const letters = "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+	const digits = "23456789"
+	const alphabet = letters + digits
+
+	for {
+		password := make([]byte, 12)
+		for i := range password {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+			if err != nil {
+				return "", err
+			}
+			password[i] = alphabet[n.Int64()]
+		}
+
+		if strings.ContainsAny(string(password), letters) && strings.ContainsAny(string(password), digits) {
+			return string(password), nil
+		}
+	}
+}
+
+// ChangePassword mengganti password user setelah memverifikasi password saat ini.
+// Semua session lain milik user dicabut; hanya session keepSessionToken (session
+// yang dipakai untuk mengganti password) yang tetap berlaku. Password saat ini yang
+// salah dihitung sebagai login gagal, sehingga ikut memicu penguncian akun.
+func ChangePassword(userID int, currentPassword string, newPassword string, keepSessionToken string, ip string) error {
+	if currentPassword == "" || newPassword == "" {
+		return ErrPasswordIsRequired
+	}
+
+	var email, hashedPassword, authSource string
+	err := database.DB.QueryRow(`
+		SELECT email, password_hash, auth_source FROM users WHERE id = $1
+	`, userID).Scan(&email, &hashedPassword, &authSource)
+
+	if err == sql.ErrNoRows {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("gagal mengambil user: %w", err)
+	}
+
+	if authSource != AuthSourceLocal {
+		return ErrPasswordManagedByLDAP
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(currentPassword)); err != nil {
+		if err := RecordLoginFailure(email, userID, ip); err != nil {
+			slog.Error("Failed to record password change failure", "user_id", userID, "error", err)
+		}
+		return ErrWrongPassword
+	}
+	if currentPassword == newPassword {
+		return ErrPasswordUnchanged
+	}
+	if err := ValidatePasswordStrength(newPassword, email); err != nil {
+		return err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("gagal memulai transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := setPassword(tx, userID, newPassword); err != nil {
+		return err
+	}
+
+	if err := revokeUserSessionsTx(tx, userID, keepSessionToken); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("gagal commit transaction: %w", err)
+	}
+	InvalidateUserStatus(userID)
+
+	slog.Info("Password changed", "user_id", userID)
+	return nil
+}
+
+// RequestPasswordReset membuat token reset password sekali pakai dan mengirimkannya
+// via email. Jika email tidak terdaftar, user tidak aktif atau password-nya dikelola
+// LDAP, tidak ada yang dilakukan dan tidak ada error, supaya endpoint tidak bisa
+// dipakai untuk menebak email terdaftar.
+func RequestPasswordReset(email string, ip string) error {
+	email = NormalizeEmail(email)
+
+	var userID int
+	var name string
+	err := database.DB.QueryRow(`
+		SELECT id, name FROM users WHERE LOWER(email) = $1 AND status = 'active' AND auth_source = 'local'
+	`, email).Scan(&userID, &name)
+
+	if err == sql.ErrNoRows {
+		slog.Info("Password reset requested for unknown, inactive or LDAP email", "ip", ip)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("gagal mengambil user: %w", err)
+	}
+
+	token, err := generateSecureToken()
+	if err != nil {
+		return fmt.Errorf("gagal membuat token reset: %w", err)
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("gagal memulai transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Token lama yang belum terpakai tidak berlaku lagi
+	_, err = tx.Exec(`
+		UPDATE password_reset_tokens SET used_at = NOW()
+		WHERE user_id = $1 AND used_at IS NULL
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("gagal menonaktifkan token lama: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, requested_ip)
+		VALUES ($1, $2, NOW() + make_interval(secs => $3), $4)
+	`, userID, hashToken(token), passwordResetTokenTTL.Seconds(), ip)
+	if err != nil {
+		return fmt.Errorf("gagal menyimpan token reset: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("gagal commit transaction: %w", err)
+	}
+
+	// Kirim di background agar respons tidak menunggu server email. Respons untuk
+	// email terdaftar tetap sedikit lebih lambat karena transaction di atas; yang
+	// mencegah enumerasi email adalah isi respons yang selalu sama, dibantu rate limit.
+	go func() {
+		subject := "Reset password akun absensi"
+		body := fmt.Sprintf(
+			"Halo %s,\n\nKami menerima permintaan reset password untuk akun Anda.\n"+
+				"Buka tautan berikut untuk membuat password baru (berlaku %d menit):\n\n%s\n\n"+
+				"Jika Anda tidak meminta reset password, abaikan email ini.\n",
+			name, int(passwordResetTokenTTL.Minutes()), AppURL("/reset-password?token="+token),
+		)
+		if err := mailer.Send([]string{email}, subject, body); err != nil {
+			slog.Error("Failed to send password reset email", "user_id", userID, "error", err)
+		}
+	}()
+
+	slog.Info("Password reset token created", "user_id", userID)
+	return nil
+}
+
+// ResetPassword mengganti password memakai token dari email reset password.
+// Token hanya bisa dipakai sekali dan hanya sebelum kedaluwarsa. Semua session
+// user dicabut karena reset biasanya dilakukan saat akun mungkin disalahgunakan.
+func ResetPassword(token string, newPassword string) error {
+	if token == "" {
+		return ErrInvalidResetToken
+	}
+	if newPassword == "" {
+		return ErrPasswordIsRequired
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("gagal memulai transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var tokenID, userID int
+	var email string
+	err = tx.QueryRow(`
+		SELECT t.id, t.user_id, u.email
+		FROM password_reset_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = $1
+		  AND t.used_at IS NULL
+		  AND t.expires_at > NOW()
+		  AND u.status = 'active'
+		  AND u.auth_source = 'local'
+		FOR UPDATE OF t
+	`, hashToken(token)).Scan(&tokenID, &userID, &email)
+
+	if err == sql.ErrNoRows {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return fmt.Errorf("gagal mengambil token reset: %w", err)
+	}
+
+	if err := ValidatePasswordStrength(newPassword, email); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE password_reset_tokens SET used_at = NOW() WHERE id = $1`, tokenID); err != nil {
+		return fmt.Errorf("gagal menandai token reset: %w", err)
+	}
+
+	if err := setPassword(tx, userID, newPassword); err != nil {
+		return err
+	}
+
+	if err := revokeUserSessionsTx(tx, userID, ""); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("gagal commit transaction: %w", err)
+	}
+	InvalidateUserStatus(userID)
+
+	slog.Info("Password reset completed", "user_id", userID)
+	return nil
+}
+
+// setPassword menyimpan hash password baru dan membatalkan token reset yang masih aktif
+func setPassword(tx *sql.Tx, userID int, newPassword string) error {
+	hashed, err := hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE users
+		SET password_hash = $1, must_change_password = false, password_changed_at = NOW()
+		WHERE id = $2
+	`, hashed, userID)
+	if err != nil {
+		return fmt.Errorf("gagal update password: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE password_reset_tokens SET used_at = NOW()
+		WHERE user_id = $1 AND used_at IS NULL
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("gagal menonaktifkan token reset: %w", err)
+	}
+
+	return nil
+}
+
+// generateSecureToken membuat token acak 32 byte (64 karakter hex) untuk tautan
+// sekali pakai (reset password, undangan)
+func generateSecureToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// hashToken: database hanya menyimpan SHA-256 dari token, bukan token aslinya
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// AppURL membentuk URL frontend dari APP_BASE_URL (default http://localhost:3000)
+func AppURL(path string) string {
+	baseURL := os.Getenv("APP_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:3000"
+	}
+	return strings.TrimRight(baseURL, "/") + path
+}
@@ -0,0 +1,397 @@
+package controllers
+
+import (
+	"backend/database"
+	"backend/types"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrRoleNotFound      = errors.New("role tidak ditemukan")
+	ErrUnknownRole       = errors.New("role tidak dikenal")
+	ErrUnknownPermission = errors.New("permission tidak dikenal")
+	ErrUserRolesRequired = errors.New("user minimal harus memiliki satu role")
+)
+
+// effectiveRolesCTE menghasilkan tabel effective_roles(role_id) berisi role milik user
+// ($1) beserta semua role yang diwarisinya lewat roles.inherits_role_id.
+// Contoh: Admin mewarisi HR, HR mewarisi Employee, sehingga Admin memiliki semua
+// permission HR dan Employee.
+const effectiveRolesCTE = `
+	WITH RECURSIVE effective_roles(role_id) AS (
+		SELECT role_id FROM user_roles WHERE user_id = $1
+		UNION
+		SELECT r.inherits_role_id
+		FROM roles r
+		JOIN effective_roles er ON er.role_id = r.id
+		WHERE r.inherits_role_id IS NOT NULL
+	)
+`
+
+// GetUserRoles mengambil nama role yang dimiliki user
+func GetUserRoles(userID int) ([]string, error) {
+	rows, err := database.DB.Query(`
+		SELECT r.name
+		FROM user_roles ur
+		JOIN roles r ON r.id = ur.role_id
+		WHERE ur.user_id = $1
+		ORDER BY r.name ASC
+	`, userID)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []string{}
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+// GetUserPermissions mengambil semua permission user dari seluruh role-nya,
+// termasuk permission dari role yang diwarisi
+func GetUserPermissions(userID int) ([]string, error) {
+	rows, err := database.DB.Query(effectiveRolesCTE+`
+		SELECT DISTINCT p.name
+		FROM effective_roles er
+		JOIN role_permissions rp ON rp.role_id = er.role_id
+		JOIN permissions p ON p.id = rp.permission_id
+		ORDER BY p.name ASC
+	`, userID)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := []string{}
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, permission)
+	}
+
+	return permissions, rows.Err()
+}
+
+// UserHasPermission mengecek apakah user memiliki permission tertentu lewat salah satu role-nya
+func UserHasPermission(userID int, permission string) (bool, error) {
+	var allowed bool
+	err := database.DB.QueryRow(effectiveRolesCTE+`
+		SELECT EXISTS (
+			SELECT 1
+			FROM effective_roles er
+			JOIN role_permissions rp ON rp.role_id = er.role_id
+			JOIN permissions p ON p.id = rp.permission_id
+			WHERE p.name = $2
+		)
+	`, userID, permission).Scan(&allowed)
+
+	if err != nil {
+		return false, fmt.Errorf("gagal cek permission %s: %w", permission, err)
+	}
+
+	return allowed, nil
+}
+
+// UserHasRole mengecek apakah user memiliki role tertentu, secara langsung atau
+// lewat pewarisan (user Admin dianggap memiliki role HR dan Employee)
+func UserHasRole(userID int, role string) (bool, error) {
+	var allowed bool
+	err := database.DB.QueryRow(effectiveRolesCTE+`
+		SELECT EXISTS (
+			SELECT 1
+			FROM effective_roles er
+			JOIN roles r ON r.id = er.role_id
+			WHERE r.name = $2
+		)
+	`, userID, role).Scan(&allowed)
+
+	if err != nil {
+		return false, fmt.Errorf("gagal cek role %s: %w", role, err)
+	}
+
+	return allowed, nil
+}
+
+// GetRoles mengambil semua role beserta permission langsungnya
+func GetRoles() ([]types.Role, error) {
+	rows, err := database.DB.Query(`
+		SELECT
+			r.id, r.name, r.description, r.inherits_role_id, parent.name, r.require_2fa,
+			COALESCE(array_agg(p.name ORDER BY p.name) FILTER (WHERE p.name IS NOT NULL), '{}')
+		FROM roles r
+		LEFT JOIN roles parent ON parent.id = r.inherits_role_id
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		LEFT JOIN permissions p ON p.id = rp.permission_id
+		GROUP BY r.id, parent.name
+		ORDER BY r.id ASC
+	`)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []types.Role{}
+	for rows.Next() {
+		var role types.Role
+		err := rows.Scan(
+			&role.ID,
+			&role.Name,
+			&role.Description,
+			&role.InheritsRoleID,
+			&role.InheritsRole,
+			&role.RequireTwoFA,
+			pq.Array(&role.Permissions),
+		)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+func GetPermissions() ([]types.Permission, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, name, description
+		FROM permissions
+		ORDER BY name ASC
+	`)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := []types.Permission{}
+	for rows.Next() {
+		var permission types.Permission
+		if err := rows.Scan(&permission.ID, &permission.Name, &permission.Description); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, permission)
+	}
+
+	return permissions, rows.Err()
+}
+
+// SetRolePermissions mengganti seluruh permission langsung milik sebuah role
+func SetRolePermissions(roleID int, permissions []string, actorID int, ip string) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("gagal memulai transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM roles WHERE id = $1)`, roleID).Scan(&exists); err != nil {
+		return fmt.Errorf("gagal cek role: %w", err)
+	}
+	if !exists {
+		return ErrRoleNotFound
+	}
+
+	var known int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM permissions WHERE name = ANY($1)`, pq.Array(permissions)).Scan(&known); err != nil {
+		return fmt.Errorf("gagal cek permission: %w", err)
+	}
+	if known != len(uniqueStrings(permissions)) {
+		return ErrUnknownPermission
+	}
+
+	var previous []string
+	err = tx.QueryRow(`
+		SELECT COALESCE(array_agg(p.name ORDER BY p.name), '{}')
+		FROM role_permissions rp
+		JOIN permissions p ON p.id = rp.permission_id
+		WHERE rp.role_id = $1
+	`, roleID).Scan(pq.Array(&previous))
+	if err != nil {
+		return fmt.Errorf("gagal mengambil permission role: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM role_permissions WHERE role_id = $1`, roleID); err != nil {
+		return fmt.Errorf("gagal menghapus permission role: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO role_permissions (role_id, permission_id)
+		SELECT $1, id FROM permissions WHERE name = ANY($2)
+	`, roleID, pq.Array(permissions))
+	if err != nil {
+		return fmt.Errorf("gagal insert permission role: %w", err)
+	}
+
+	err = recordAuditTx(tx, AuditEntry{
+		ActorID:    actorID,
+		Action:     AuditActionRolePermissionsUpdate,
+		EntityType: AuditEntityRole,
+		EntityID:   roleID,
+		Before:     map[string]interface{}{"permissions": previous},
+		After:      map[string]interface{}{"permissions": permissions},
+		IP:         ip,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("gagal commit transaction: %w", err)
+	}
+
+	slog.Info("Role permissions updated", "role_id", roleID, "permissions", permissions)
+	return nil
+}
+
+// SetUserRoles mengganti seluruh role milik user
+func SetUserRoles(userID int, roles []string, actorID int, ip string) error {
+	if len(roles) == 0 {
+		return ErrUserRolesRequired
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("gagal memulai transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, userID).Scan(&exists); err != nil {
+		return fmt.Errorf("gagal cek user: %w", err)
+	}
+	if !exists {
+		return ErrUserNotFound
+	}
+
+	var known int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM roles WHERE name = ANY($1)`, pq.Array(roles)).Scan(&known); err != nil {
+		return fmt.Errorf("gagal cek role: %w", err)
+	}
+	if known != len(uniqueStrings(roles)) {
+		return ErrUnknownRole
+	}
+
+	var previous []string
+	err = tx.QueryRow(`
+		SELECT COALESCE(array_agg(r.name ORDER BY r.name), '{}')
+		FROM user_roles ur
+		JOIN roles r ON r.id = ur.role_id
+		WHERE ur.user_id = $1
+	`, userID).Scan(pq.Array(&previous))
+	if err != nil {
+		return fmt.Errorf("gagal mengambil role user: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM user_roles WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("gagal menghapus role user: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO user_roles (user_id, role_id)
+		SELECT $1, id FROM roles WHERE name = ANY($2)
+	`, userID, pq.Array(roles))
+	if err != nil {
+		return fmt.Errorf("gagal insert role user: %w", err)
+	}
+
+	err = recordAuditTx(tx, AuditEntry{
+		ActorID:    actorID,
+		Action:     AuditActionUserRolesUpdate,
+		EntityType: AuditEntityUser,
+		EntityID:   userID,
+		Before:     map[string]interface{}{"roles": previous},
+		After:      map[string]interface{}{"roles": roles},
+		IP:         ip,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("gagal commit transaction: %w", err)
+	}
+
+	slog.Info("User roles updated", "user_id", userID, "roles", roles)
+	return nil
+}
+
+func uniqueStrings(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+// primaryRole memilih satu role untuk field "role" pada response auth check
+// (dipertahankan untuk kompatibilitas frontend)
+func primaryRole(roles []string) string {
+	for _, candidate := range []string{"Admin", "HR"} {
+		for _, role := range roles {
+			if role == candidate {
+				return candidate
+			}
+		}
+	}
+	return "Employee"
+}
+
+// SetRoleRequireTwoFA mengatur apakah user dengan role ini (termasuk role yang
+// mewarisinya) wajib memakai 2FA saat login
+func SetRoleRequireTwoFA(roleID int, require bool, actorID int, ip string) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("gagal memulai transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var previous bool
+	err = tx.QueryRow(`SELECT require_2fa FROM roles WHERE id = $1 FOR UPDATE`, roleID).Scan(&previous)
+	if err == sql.ErrNoRows {
+		return ErrRoleNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("gagal mengambil role: %w", err)
+	}
+
+	if _, err := tx.Exec(`UPDATE roles SET require_2fa = $1 WHERE id = $2`, require, roleID); err != nil {
+		return fmt.Errorf("gagal update role: %w", err)
+	}
+
+	err = recordAuditTx(tx, AuditEntry{
+		ActorID:    actorID,
+		Action:     AuditActionRoleRequireTwoFAUpdate,
+		EntityType: AuditEntityRole,
+		EntityID:   roleID,
+		Before:     map[string]interface{}{"require_2fa": previous},
+		After:      map[string]interface{}{"require_2fa": require},
+		IP:         ip,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("gagal commit transaction: %w", err)
+	}
+
+	slog.Info("Role 2FA requirement updated", "role_id", roleID, "require_2fa", require)
+	return nil
+}
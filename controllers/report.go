@@ -0,0 +1,332 @@
+package controllers
+
+import (
+	"backend/database"
+	"backend/mailer"
+	"backend/scheduler"
+	"backend/types"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	ReportTypeDailySummary  = "daily_summary"
+	ReportTypeMonthlyReport = "monthly_report"
+)
+
+var (
+	ErrInvalidReportSubscription  = errors.New("report subscription tidak valid")
+	ErrReportSubscriptionNotFound = errors.New("report subscription tidak ditemukan")
+	ErrReportNotWorkingDay        = errors.New("tanggal laporan bukan hari kerja")
+)
+
+func GetReportSubscriptions() ([]types.ReportSubscription, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, name, recipients, report_type, cron_expression, is_active, last_run_at, failed_run_at, created_at
+		FROM report_subscriptions
+		ORDER BY id ASC
+	`)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []types.ReportSubscription{}
+	for rows.Next() {
+		var sub types.ReportSubscription
+		err := rows.Scan(
+			&sub.ID,
+			&sub.Name,
+			pq.Array(&sub.Recipients),
+			&sub.ReportType,
+			&sub.CronExpression,
+			&sub.IsActive,
+			&sub.LastRunAt,
+			&sub.FailedRunAt,
+			&sub.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+func CreateReportSubscription(req types.CreateReportSubscriptionRequest, createdBy int) (types.ReportSubscription, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return types.ReportSubscription{}, fmt.Errorf("%w: name tidak boleh kosong", ErrInvalidReportSubscription)
+	}
+	if req.ReportType != ReportTypeDailySummary && req.ReportType != ReportTypeMonthlyReport {
+		return types.ReportSubscription{}, fmt.Errorf("%w: report_type harus '%s' atau '%s'",
+			ErrInvalidReportSubscription, ReportTypeDailySummary, ReportTypeMonthlyReport)
+	}
+	if len(req.Recipients) == 0 {
+		return types.ReportSubscription{}, fmt.Errorf("%w: recipients tidak boleh kosong", ErrInvalidReportSubscription)
+	}
+	for _, recipient := range req.Recipients {
+		if !strings.Contains(recipient, "@") {
+			return types.ReportSubscription{}, fmt.Errorf("%w: email %q tidak valid", ErrInvalidReportSubscription, recipient)
+		}
+	}
+	if _, err := scheduler.ParseCron(req.CronExpression); err != nil {
+		return types.ReportSubscription{}, fmt.Errorf("%w: %v", ErrInvalidReportSubscription, err)
+	}
+
+	var sub types.ReportSubscription
+	err := database.DB.QueryRow(`
+		INSERT INTO report_subscriptions (name, recipients, report_type, cron_expression, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, name, recipients, report_type, cron_expression, is_active, last_run_at, failed_run_at, created_at
+	`, req.Name, pq.Array(req.Recipients), req.ReportType, req.CronExpression, createdBy).Scan(
+		&sub.ID,
+		&sub.Name,
+		pq.Array(&sub.Recipients),
+		&sub.ReportType,
+		&sub.CronExpression,
+		&sub.IsActive,
+		&sub.LastRunAt,
+		&sub.FailedRunAt,
+		&sub.CreatedAt,
+	)
+
+	if err != nil {
+		return types.ReportSubscription{}, fmt.Errorf("gagal insert report subscription: %w", err)
+	}
+
+	return sub, nil
+}
+
+func DeleteReportSubscription(subscriptionID int) error {
+	result, err := database.DB.Exec(`DELETE FROM report_subscriptions WHERE id = $1`, subscriptionID)
+	if err != nil {
+		return fmt.Errorf("gagal menghapus report subscription: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("gagal cek rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrReportSubscriptionNotFound
+	}
+
+	return nil
+}
+
+// SendReportSubscriptionNow mengirim laporan sebuah subscription saat ini juga
+// (berguna untuk mengecek konfigurasi SMTP)
+func SendReportSubscriptionNow(subscriptionID int) error {
+	var reportType string
+	var recipients []string
+
+	err := database.DB.QueryRow(`
+		SELECT report_type, recipients
+		FROM report_subscriptions
+		WHERE id = $1
+	`, subscriptionID).Scan(&reportType, pq.Array(&recipients))
+
+	if err == sql.ErrNoRows {
+		return ErrReportSubscriptionNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return SendReport(reportType, recipients, time.Now())
+}
+
+// RunDueReportSubscriptions dipanggil scheduler setiap menit. Subscription yang
+// jadwal cron-nya cocok di-"klaim" dengan update last_run_at terlebih dahulu,
+// sehingga laporan tidak terkirim dua kali walau server berjalan di banyak instance.
+// Jika pengiriman gagal, klaim dilepas (last_run_at dikembalikan) dan jadwal yang
+// gagal disimpan di failed_run_at untuk dicoba ulang pada menit berikutnya.
+func RunDueReportSubscriptions(minute time.Time) error {
+	subscriptions, err := GetReportSubscriptions()
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subscriptions {
+		if !sub.IsActive {
+			continue
+		}
+
+		schedule, err := scheduler.ParseCron(sub.CronExpression)
+		if err != nil {
+			slog.Error("Report subscription has invalid cron expression", "subscription_id", sub.ID, "cron_expression", sub.CronExpression, "error", err)
+			continue
+		}
+
+		// Jadwal yang sebelumnya gagal dikirim lebih dulu agar urutan laporan terjaga
+		var runs []time.Time
+		if sub.FailedRunAt != nil {
+			runs = append(runs, *sub.FailedRunAt)
+		}
+		if schedule.Matches(minute) {
+			runs = append(runs, minute)
+		}
+		if len(runs) == 0 {
+			continue
+		}
+
+		result, err := database.DB.Exec(`
+			UPDATE report_subscriptions
+			SET last_run_at = $1
+			WHERE id = $2 AND (last_run_at IS NULL OR last_run_at < $1)
+		`, minute, sub.ID)
+		if err != nil {
+			slog.Error("Failed to claim report subscription", "subscription_id", sub.ID, "error", err)
+			continue
+		}
+		if claimed, _ := result.RowsAffected(); claimed == 0 {
+			continue // sudah dikirim oleh instance lain
+		}
+
+		runReportSubscription(sub, minute, runs)
+	}
+
+	return nil
+}
+
+// runReportSubscription mengirim laporan untuk setiap jadwal di runs. Pengiriman
+// berhenti pada kegagalan pertama; jadwal tersebut dicatat untuk dicoba ulang.
+func runReportSubscription(sub types.ReportSubscription, claimedAt time.Time, runs []time.Time) {
+	for _, runAt := range runs {
+		err := SendReport(sub.ReportType, sub.Recipients, runAt)
+		if errors.Is(err, ErrReportNotWorkingDay) {
+			slog.Info("Report subscription skipped", "subscription_id", sub.ID, "report_type", sub.ReportType, "run_at", runAt, "reason", err.Error())
+			continue
+		}
+		if err != nil {
+			slog.Error("Failed to send report subscription", "subscription_id", sub.ID, "run_at", runAt, "error", err)
+			_, err := database.DB.Exec(`
+				UPDATE report_subscriptions
+				SET last_run_at = $1, failed_run_at = $2
+				WHERE id = $3 AND last_run_at = $4
+			`, sub.LastRunAt, runAt, sub.ID, claimedAt)
+			if err != nil {
+				slog.Error("Failed to release report subscription claim", "subscription_id", sub.ID, "error", err)
+			}
+			return
+		}
+		slog.Info("Report subscription sent", "subscription_id", sub.ID, "report_type", sub.ReportType, "run_at", runAt, "recipients", len(sub.Recipients))
+	}
+
+	if sub.FailedRunAt != nil {
+		if _, err := database.DB.Exec(`UPDATE report_subscriptions SET failed_run_at = NULL WHERE id = $1`, sub.ID); err != nil {
+			slog.Error("Failed to clear failed report run", "subscription_id", sub.ID, "error", err)
+		}
+	}
+}
+
+// SendReport menyusun dan mengirim laporan relatif terhadap waktu now:
+// daily_summary untuk hari sebelumnya, monthly_report untuk bulan sebelumnya
+func SendReport(reportType string, recipients []string, now time.Time) error {
+	switch reportType {
+	case ReportTypeDailySummary:
+		return sendDailySummary(recipients, now.AddDate(0, 0, -1))
+	case ReportTypeMonthlyReport:
+		previousMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -1, 0)
+		return sendMonthlyReport(recipients, int(previousMonth.Month()), previousMonth.Year())
+	default:
+		return fmt.Errorf("report_type tidak dikenal: %s", reportType)
+	}
+}
+
+func sendDailySummary(recipients []string, date time.Time) error {
+	report, onLeave, err := getDailySummary(date)
+	if err != nil {
+		return err
+	}
+
+	data, err := dailyAttendanceCSV(report)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(
+		"Rekap absensi tanggal %s\n\nHadir: %d\nTerlambat: %d\nCuti: %d\nTidak hadir: %d\n\nDetail terlampir dalam file CSV.\n",
+		report.Date, report.TotalAttend, report.TotalLate, onLeave, report.TotalAbsent,
+	)
+
+	return mailer.Send(recipients, "Rekap Absensi Harian "+report.Date, body, mailer.Attachment{
+		Filename:    fmt.Sprintf("attendance-%s.csv", report.Date),
+		ContentType: "text/csv",
+		Data:        data,
+	})
+}
+
+// getDailySummary mengambil rekap harian untuk laporan terjadwal. Akhir pekan dan
+// hari libur menghasilkan ErrReportNotWorkingDay, dan user yang sedang cuti tidak
+// dihitung absen (jumlahnya dikembalikan terpisah).
+func getDailySummary(date time.Time) (types.TodayAttendanceListResponse, int, error) {
+	day := date.Format("2006-01-02")
+	if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+		return types.TodayAttendanceListResponse{}, 0, fmt.Errorf("%w: %s akhir pekan", ErrReportNotWorkingDay, day)
+	}
+
+	holidays, err := getHolidays(date.Year(), int(date.Month()))
+	if err != nil {
+		return types.TodayAttendanceListResponse{}, 0, fmt.Errorf("gagal mengambil hari libur: %w", err)
+	}
+	if name, ok := holidays[day]; ok {
+		return types.TodayAttendanceListResponse{}, 0, fmt.Errorf("%w: %s libur %s", ErrReportNotWorkingDay, day, name)
+	}
+
+	report, err := GetDailyAttendance(date)
+	if err != nil {
+		return types.TodayAttendanceListResponse{}, 0, err
+	}
+
+	leaves, err := getApprovedLeaveDays(0, date.Year(), int(date.Month()))
+	if err != nil {
+		return types.TodayAttendanceListResponse{}, 0, fmt.Errorf("gagal mengambil data cuti: %w", err)
+	}
+
+	absentUsers := []types.AbsentUser{}
+	onLeave := 0
+	for _, absent := range report.AbsentUsers {
+		if leaves[absent.UserID][day] {
+			onLeave++
+			continue
+		}
+		absentUsers = append(absentUsers, absent)
+	}
+	report.AbsentUsers = absentUsers
+	report.TotalAbsent = len(absentUsers)
+
+	return report, onLeave, nil
+}
+
+func sendMonthlyReport(recipients []string, month int, year int) error {
+	report, err := GetMonthlyAttendance(month, year)
+	if err != nil {
+		return err
+	}
+
+	data, err := monthlyAttendanceCSV(report)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(
+		"Laporan absensi bulan %s/%s\n\nHari kerja: %d\nTotal check-in: %d\nTotal terlambat: %d\nTotal hari absen: %d\nKaryawan dengan absen: %d\n\nMatriks absensi per karyawan terlampir dalam file CSV.\n",
+		report.Month, report.Year, len(report.WorkingDays), report.TotalAttend, report.TotalLate,
+		report.TotalAbsent, len(report.AbsentUsers),
+	)
+
+	return mailer.Send(recipients, fmt.Sprintf("Laporan Absensi Bulanan %s/%s", report.Month, report.Year), body, mailer.Attachment{
+		Filename:    fmt.Sprintf("attendance-%d-%02d.csv", year, month),
+		ContentType: "text/csv",
+		Data:        data,
+	})
+}
@@ -0,0 +1,18 @@
+package controllers
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDailySummarySkipsWeekend(t *testing.T) {
+	for _, date := range []time.Time{
+		time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC), // Sabtu
+		time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC), // Minggu
+	} {
+		if _, _, err := getDailySummary(date); !errors.Is(err, ErrReportNotWorkingDay) {
+			t.Errorf("getDailySummary(%s) err = %v, want ErrReportNotWorkingDay", date.Format("Mon 2006-01-02"), err)
+		}
+	}
+}
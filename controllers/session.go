@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"backend/database"
+	"backend/types"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Session yang sudah kedaluwarsa atau dicabut disimpan sebentar untuk keperluan
+// audit, lalu dihapus oleh PruneSessions
+const sessionRetention = 7 * 24 * time.Hour
+
+var ErrSessionNotFound = errors.New("session tidak ditemukan")
+
+// CreateSession menyimpan session baru dan mengembalikan token-nya. Token asli
+// hanya dikirim ke browser (di dalam cookie), database menyimpan hash-nya.
+// userID 0 berarti session belum terautentikasi (misalnya menunggu kode 2FA).
+func CreateSession(userID int, data []byte, userAgent string, ip string, maxAge time.Duration) (string, error) {
+	token, err := generateSecureToken()
+	if err != nil {
+		return "", fmt.Errorf("gagal membuat token session: %w", err)
+	}
+
+	_, err = database.DB.Exec(`
+		INSERT INTO user_sessions (token_hash, user_id, data, user_agent, ip, expires_at)
+		VALUES ($1, NULLIF($2, 0), $3, $4, NULLIF($5, ''), NOW() + make_interval(secs => $6))
+	`, hashToken(token), userID, data, userAgent, ip, maxAge.Seconds())
+	if err != nil {
+		return "", fmt.Errorf("gagal menyimpan session: %w", err)
+	}
+
+	return token, nil
+}
+
+// LoadSession mengambil data session yang masih aktif. found false berarti
+// session tidak ada, sudah kedaluwarsa atau sudah dicabut.
+// last_seen_at diperbarui paling sering sekali per menit.
+func LoadSession(token string) (data []byte, found bool, err error) {
+	err = database.DB.QueryRow(`
+		WITH s AS (
+			SELECT id, data FROM user_sessions
+			WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		), touch AS (
+			UPDATE user_sessions SET last_seen_at = NOW()
+			WHERE id = (SELECT id FROM s) AND last_seen_at < NOW() - INTERVAL '1 minute'
+		)
+		SELECT data FROM s
+	`, hashToken(token)).Scan(&data)
+
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("gagal mengambil session: %w", err)
+	}
+
+	return data, true, nil
+}
+
+// UpdateSession menyimpan perubahan data session dan memperpanjang masa berlakunya
+func UpdateSession(token string, userID int, data []byte, maxAge time.Duration) error {
+	_, err := database.DB.Exec(`
+		UPDATE user_sessions
+		SET user_id = NULLIF($2, 0), data = $3, last_seen_at = NOW(),
+			expires_at = NOW() + make_interval(secs => $4)
+		WHERE token_hash = $1 AND revoked_at IS NULL
+	`, hashToken(token), userID, data, maxAge.Seconds())
+	if err != nil {
+		return fmt.Errorf("gagal update session: %w", err)
+	}
+	return nil
+}
+
+// EndSession mencabut session berdasarkan token (logout)
+func EndSession(token string) error {
+	_, err := database.DB.Exec(`
+		UPDATE user_sessions SET revoked_at = NOW()
+		WHERE token_hash = $1 AND revoked_at IS NULL
+	`, hashToken(token))
+	if err != nil {
+		return fmt.Errorf("gagal mencabut session: %w", err)
+	}
+	return nil
+}
+
+// GetUserSessions mengembalikan session aktif milik user. currentToken dipakai
+// untuk menandai session yang sedang dipakai request ini.
+func GetUserSessions(userID int, currentToken string) ([]types.UserSession, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, COALESCE(user_agent, ''), COALESCE(ip, ''), created_at, last_seen_at, expires_at,
+			token_hash = $2
+		FROM user_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_seen_at DESC
+	`, userID, hashToken(currentToken))
+	if err != nil {
+		return nil, fmt.Errorf("gagal query session: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []types.UserSession{}
+	for rows.Next() {
+		var s types.UserSession
+		if err := rows.Scan(&s.ID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt, &s.Current); err != nil {
+			return nil, fmt.Errorf("gagal scan session: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+
+	return sessions, rows.Err()
+}
+
+// RevokeUserSession mencabut satu session milik user
+func RevokeUserSession(userID int, sessionID int) error {
+	result, err := database.DB.Exec(`
+		UPDATE user_sessions SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > NOW()
+	`, sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("gagal mencabut session: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("gagal cek rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+
+	slog.Info("Session revoked", "session_id", sessionID, "user_id", userID)
+	return nil
+}
+
+// RevokeUserSessions mencabut semua session aktif milik user, kecuali session
+// dengan token exceptToken (kosong berarti semua). Mengembalikan jumlah session
+// yang dicabut.
+func RevokeUserSessions(userID int, exceptToken string) (int64, error) {
+	result, err := database.DB.Exec(`
+		UPDATE user_sessions SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL AND ($2 = '' OR token_hash <> $3)
+	`, userID, exceptToken, hashToken(exceptToken))
+	if err != nil {
+		return 0, fmt.Errorf("gagal mencabut session: %w", err)
+	}
+
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("gagal cek rows affected: %w", err)
+	}
+
+	slog.Info("Sessions revoked", "revoked", revoked, "user_id", userID)
+	return revoked, nil
+}
+
+// revokeUserSessionsTx mencabut semua session user di dalam transaction yang
+// sedang berjalan (misalnya saat user dinonaktifkan atau password diganti),
+// kecuali session dengan token exceptToken (kosong berarti semua)
+func revokeUserSessionsTx(tx *sql.Tx, userID int, exceptToken string) error {
+	_, err := tx.Exec(`
+		UPDATE user_sessions SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL AND ($2 = '' OR token_hash <> $3)
+	`, userID, exceptToken, hashToken(exceptToken))
+	if err != nil {
+		return fmt.Errorf("gagal mencabut session user: %w", err)
+	}
+	return nil
+}
+
+// PruneSessions menghapus session yang sudah lama kedaluwarsa atau dicabut.
+// Session anonim (sebelum login) tidak berguna untuk audit sehingga langsung
+// dihapus begitu kedaluwarsa.
+func PruneSessions() error {
+	result, err := database.DB.Exec(`
+		DELETE FROM user_sessions
+		WHERE COALESCE(revoked_at, expires_at) < NOW() - make_interval(secs => $1)
+		   OR (user_id IS NULL AND COALESCE(revoked_at, expires_at) < NOW())
+	`, sessionRetention.Seconds())
+	if err != nil {
+		return fmt.Errorf("gagal menghapus session lama: %w", err)
+	}
+
+	if deleted, err := result.RowsAffected(); err == nil && deleted > 0 {
+		slog.Info("Pruned old sessions", "deleted", deleted)
+	}
+	return nil
+}
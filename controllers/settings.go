@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"backend/database"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+var ErrInvalidSetting = errors.New("key pengaturan tidak boleh kosong")
+
+// GetSystemSettings mengambil semua pengaturan sistem (key -> value)
+func GetSystemSettings() (map[string]string, error) {
+	rows, err := database.DB.Query(`
+		SELECT key, value
+		FROM system_settings
+		ORDER BY key ASC
+	`)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	settings := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		settings[key] = value
+	}
+
+	return settings, rows.Err()
+}
+
+// UpdateSystemSettings menyimpan (insert atau update) pengaturan sistem
+func UpdateSystemSettings(settings map[string]string, updatedBy int) (map[string]string, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("gagal memulai transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for key, value := range settings {
+		if key == "" {
+			return nil, ErrInvalidSetting
+		}
+
+		_, err := tx.Exec(`
+			INSERT INTO system_settings (key, value, updated_by, updated_at)
+			VALUES ($1, $2, $3, NOW())
+			ON CONFLICT (key) DO UPDATE
+			SET value = EXCLUDED.value, updated_by = EXCLUDED.updated_by, updated_at = NOW()
+		`, key, value, updatedBy)
+
+		if err != nil {
+			return nil, fmt.Errorf("gagal menyimpan pengaturan %s: %w", key, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("gagal commit transaction: %w", err)
+	}
+
+	slog.Info("System settings updated", "actor_id", updatedBy, "keys", len(settings))
+	return GetSystemSettings()
+}
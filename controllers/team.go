@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"backend/database"
+	"backend/types"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrNotTeamMember = errors.New("user bukan anggota tim Anda")
+
+// Tim seorang manager adalah karyawan di departemen yang manager_id-nya adalah
+// manager tersebut (departments.manager_id), tidak termasuk manager itu sendiri.
+
+// GetTeamTodayAttendance mengambil rekap absensi hari ini untuk tim manager
+func GetTeamTodayAttendance(managerID int) (types.TodayAttendanceListResponse, error) {
+	return getDailyAttendance(time.Now(), managerID)
+}
+
+// GetTeamMonthlyAttendance mengambil rekap absensi bulanan untuk tim manager
+func GetTeamMonthlyAttendance(managerID int, month int, year int) (types.MonthlyAttendanceListResponse, error) {
+	return getMonthlyAttendance(month, year, managerID)
+}
+
+// GetTeamEmployeeMonthlyAttendance mengambil absensi bulanan satu anggota tim.
+// Mengembalikan ErrNotTeamMember jika user bukan bawahan manager tersebut.
+func GetTeamEmployeeMonthlyAttendance(managerID int, userID int, month int, year int) (types.EmployeeMonthlyAttendanceResponse, error) {
+	isMember, err := IsTeamMember(managerID, userID)
+	if err != nil {
+		return types.EmployeeMonthlyAttendanceResponse{}, err
+	}
+	if !isMember {
+		return types.EmployeeMonthlyAttendanceResponse{}, ErrNotTeamMember
+	}
+
+	return GetEmployeeMonthlyAttendance(userID, month, year)
+}
+
+// IsTeamMember mengecek apakah user berada di departemen yang dipimpin manager
+func IsTeamMember(managerID int, userID int) (bool, error) {
+	var isMember bool
+	err := database.DB.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM users u
+			JOIN departments d ON d.id = u.department_id
+			WHERE u.id = $1 AND d.manager_id = $2 AND u.id <> $2
+		)
+	`, userID, managerID).Scan(&isMember)
+
+	if err != nil {
+		return false, fmt.Errorf("gagal cek anggota tim: %w", err)
+	}
+
+	return isMember, nil
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"backend/database"
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Test yang membutuhkan PostgreSQL memakai database dari TEST_DATABASE_URL yang
+// sudah disiapkan dengan `go run ./seed`. Tanpa variabel tersebut test di-skip.
+// Setiap test membuat datanya sendiri dan menghapusnya kembali lewat t.Cleanup.
+var testDB struct {
+	once sync.Once
+	err  error
+}
+
+func requireTestDB(t *testing.T) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL tidak diset, test database di-skip")
+	}
+
+	testDB.once.Do(func() {
+		db, err := sql.Open("postgres", dsn)
+		if err == nil {
+			err = db.Ping()
+		}
+		testDB.err = err
+		database.DB = db
+	})
+	if testDB.err != nil {
+		t.Fatalf("gagal terhubung ke database test: %v", testDB.err)
+	}
+}
+
+// createTestUser membuat user aktif dengan email unik dan menghapusnya setelah test
+func createTestUser(t *testing.T, prefix string) (int, string) {
+	t.Helper()
+
+	email := fmt.Sprintf("%s-%d@test.local", prefix, time.Now().UnixNano())
+	var userID int
+	err := database.DB.QueryRow(`
+		INSERT INTO users (name, email, phone, position, department_id, status, password_hash)
+		VALUES ($1, $2, '', '', (SELECT id FROM departments ORDER BY id LIMIT 1), 'active', 'x')
+		RETURNING id
+	`, prefix, email).Scan(&userID)
+	if err != nil {
+		t.Fatalf("gagal membuat user test: %v", err)
+	}
+
+	t.Cleanup(func() { deleteTestUsers(t, email) })
+	return userID, email
+}
+
+// deleteTestUsers menghapus user test berdasarkan email (termasuk yang dibuat
+// oleh kode yang dites, misalnya lewat provisioning)
+func deleteTestUsers(t *testing.T, emails ...string) {
+	t.Helper()
+
+	for _, email := range emails {
+		if _, err := database.DB.Exec(`DELETE FROM users WHERE LOWER(email) = LOWER($1)`, email); err != nil {
+			t.Errorf("gagal menghapus user test %s: %v", email, err)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package controllers
+
+import (
+	"backend/database"
+	"backend/totp"
+	"backend/types"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const recoveryCodeCount = 10
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("2FA sudah aktif")
+	ErrTwoFactorNotSetup       = errors.New("2FA belum disiapkan, panggil setup terlebih dahulu")
+	ErrTwoFactorNotEnabled     = errors.New("2FA belum aktif")
+	ErrTwoFactorRequired       = errors.New("2FA diwajibkan untuk role Anda dan tidak bisa dinonaktifkan")
+	ErrInvalidTwoFactorCode    = errors.New("kode 2FA tidak valid")
+)
+
+// TwoFactorStatus mengembalikan apakah user sudah mengaktifkan 2FA dan apakah
+// 2FA diwajibkan oleh salah satu role efektifnya (roles.require_2fa)
+func TwoFactorStatus(userID int) (enabled bool, required bool, err error) {
+	err = database.DB.QueryRow(effectiveRolesCTE+`
+		SELECT
+			(SELECT totp_enabled_at IS NOT NULL FROM users WHERE id = $1),
+			EXISTS (
+				SELECT 1
+				FROM effective_roles er
+				JOIN roles r ON r.id = er.role_id
+				WHERE r.require_2fa
+			)
+	`, userID).Scan(&enabled, &required)
+
+	if err != nil {
+		return false, false, fmt.Errorf("gagal cek status 2FA: %w", err)
+	}
+
+	return enabled, required, nil
+}
+
+// BeginTwoFactorSetup membuat secret TOTP baru (belum aktif) untuk user.
+// 2FA baru aktif setelah user memasukkan kode pertama lewat EnableTwoFactor.
+func BeginTwoFactorSetup(userID int) (types.TwoFactorSetupResponse, error) {
+	var email string
+	var enabled bool
+	err := database.DB.QueryRow(`
+		SELECT email, totp_enabled_at IS NOT NULL FROM users WHERE id = $1
+	`, userID).Scan(&email, &enabled)
+
+	if err == sql.ErrNoRows {
+		return types.TwoFactorSetupResponse{}, ErrUserNotFound
+	}
+	if err != nil {
+		return types.TwoFactorSetupResponse{}, fmt.Errorf("gagal mengambil user: %w", err)
+	}
+	if enabled {
+		return types.TwoFactorSetupResponse{}, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return types.TwoFactorSetupResponse{}, fmt.Errorf("gagal membuat secret TOTP: %w", err)
+	}
+
+	_, err = database.DB.Exec(`
+		UPDATE users SET totp_secret = $1, totp_last_counter = NULL WHERE id = $2
+	`, secret, userID)
+	if err != nil {
+		return types.TwoFactorSetupResponse{}, fmt.Errorf("gagal menyimpan secret TOTP: %w", err)
+	}
+
+	return types.TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(secret, totpIssuer(), email),
+	}, nil
+}
+
+// EnableTwoFactor mengaktifkan 2FA setelah kode dari aplikasi authenticator
+// terverifikasi, lalu mengembalikan recovery code (hanya ditampilkan sekali)
+func EnableTwoFactor(userID int, code string) ([]string, error) {
+	var secret sql.NullString
+	var enabled bool
+	err := database.DB.QueryRow(`
+		SELECT totp_secret, totp_enabled_at IS NOT NULL FROM users WHERE id = $1
+	`, userID).Scan(&secret, &enabled)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil user: %w", err)
+	}
+	if enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if !secret.Valid {
+		return nil, ErrTwoFactorNotSetup
+	}
+
+	counter, ok := totp.Validate(code, secret.String, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("gagal memulai transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE users SET totp_enabled_at = NOW(), totp_last_counter = $1 WHERE id = $2
+	`, counter, userID)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengaktifkan 2FA: %w", err)
+	}
+
+	codes, err := replaceRecoveryCodes(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("gagal commit transaction: %w", err)
+	}
+
+	slog.Info("2FA enabled", "user_id", userID)
+	return codes, nil
+}
+
+// VerifyTwoFactor memverifikasi kode TOTP atau recovery code milik user.
+// Kode TOTP yang sudah pernah dipakai ditolak; recovery code hanya bisa dipakai sekali.
+func VerifyTwoFactor(userID int, code string) error {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return ErrInvalidTwoFactorCode
+	}
+
+	var secret sql.NullString
+	var lastCounter sql.NullInt64
+	var enabled bool
+	err := database.DB.QueryRow(`
+		SELECT totp_secret, totp_last_counter, totp_enabled_at IS NOT NULL FROM users WHERE id = $1
+	`, userID).Scan(&secret, &lastCounter, &enabled)
+
+	if err == sql.ErrNoRows {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("gagal mengambil user: %w", err)
+	}
+	if !enabled || !secret.Valid {
+		return ErrTwoFactorNotEnabled
+	}
+
+	if counter, ok := totp.Validate(code, secret.String, time.Now()); ok {
+		// UPDATE bersyarat mencegah kode yang sama dipakai dua kali, termasuk
+		// oleh dua request yang bersamaan
+		result, err := database.DB.Exec(`
+			UPDATE users SET totp_last_counter = $1
+			WHERE id = $2 AND (totp_last_counter IS NULL OR totp_last_counter < $1)
+		`, counter, userID)
+		if err != nil {
+			return fmt.Errorf("gagal update counter TOTP: %w", err)
+		}
+		if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	result, err := database.DB.Exec(`
+		UPDATE recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`, userID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return fmt.Errorf("gagal cek recovery code: %w", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+
+	slog.Info("Recovery code used", "user_id", userID)
+	return nil
+}
+
+// DisableTwoFactor menonaktifkan 2FA setelah verifikasi password dan kode 2FA.
+// Tidak diizinkan jika role user mewajibkan 2FA.
+func DisableTwoFactor(userID int, password string, code string) error {
+	_, required, err := TwoFactorStatus(userID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrTwoFactorRequired
+	}
+
+	if err := verifyUserPassword(userID, password); err != nil {
+		return err
+	}
+	if err := VerifyTwoFactor(userID, code); err != nil {
+		return err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("gagal memulai transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_counter = NULL WHERE id = $1
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("gagal menonaktifkan 2FA: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("gagal menghapus recovery code: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("gagal commit transaction: %w", err)
+	}
+
+	slog.Info("2FA disabled", "user_id", userID)
+	return nil
+}
+
+// RegenerateRecoveryCodes membuat recovery code baru (yang lama tidak berlaku)
+func RegenerateRecoveryCodes(userID int, code string) ([]string, error) {
+	if err := VerifyTwoFactor(userID, code); err != nil {
+		return nil, err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("gagal memulai transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	codes, err := replaceRecoveryCodes(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("gagal commit transaction: %w", err)
+	}
+
+	slog.Info("Recovery codes regenerated", "user_id", userID)
+	return codes, nil
+}
+
+// replaceRecoveryCodes menghapus recovery code lama dan membuat yang baru.
+// Database hanya menyimpan hash-nya.
+func replaceRecoveryCodes(tx *sql.Tx, userID int) ([]string, error) {
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return nil, fmt.Errorf("gagal menghapus recovery code lama: %w", err)
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("gagal membuat recovery code: %w", err)
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)
+		`, userID, hashToken(normalizeRecoveryCode(code)))
+		if err != nil {
+			return nil, fmt.Errorf("gagal menyimpan recovery code: %w", err)
+		}
+
+		codes = append(codes, code)
+	}
+
+	return codes, nil
+}
+
+// generateRecoveryCode membuat kode berformat xxxxx-xxxxx
+func generateRecoveryCode() (string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+	code := make([]byte, 10)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = alphabet[n.Int64()]
+	}
+
+	return string(code[:5]) + "-" + string(code[5:]), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+// verifyUserPassword mencocokkan password dengan hash milik user
+func verifyUserPassword(userID int, password string) error {
+	var hashedPassword string
+	err := database.DB.QueryRow(`SELECT password_hash FROM users WHERE id = $1`, userID).Scan(&hashedPassword)
+	if err == sql.ErrNoRows {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("gagal mengambil user: %w", err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password)); err != nil {
+		return ErrWrongPassword
+	}
+	return nil
+}
+
+// totpIssuer adalah nama aplikasi yang tampil di aplikasi authenticator
+func totpIssuer() string {
+	if issuer := os.Getenv("TOTP_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "Attendance App"
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"backend/totp"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerifyTwoFactorRejectsReplayedCode(t *testing.T) {
+	requireTestDB(t)
+	userID, _ := createTestUser(t, "totp-replay")
+
+	setup, err := BeginTwoFactorSetup(userID)
+	if err != nil {
+		t.Fatalf("BeginTwoFactorSetup: %v", err)
+	}
+
+	now := time.Now()
+	code, err := totp.Code(setup.Secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EnableTwoFactor(userID, code); err != nil {
+		t.Fatalf("EnableTwoFactor: %v", err)
+	}
+
+	// Kode yang dipakai untuk aktivasi tidak boleh dipakai lagi untuk login
+	if err := VerifyTwoFactor(userID, code); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("kode aktivasi dipakai ulang: err = %v, want ErrInvalidTwoFactorCode", err)
+	}
+
+	// Kode periode berikutnya masih dalam skew dan diterima sekali
+	next, err := totp.Code(setup.Secret, now.Add(30*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyTwoFactor(userID, next); err != nil {
+		t.Fatalf("kode periode berikutnya ditolak: %v", err)
+	}
+	if err := VerifyTwoFactor(userID, next); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("kode dipakai ulang: err = %v, want ErrInvalidTwoFactorCode", err)
+	}
+
+	// Kode periode sebelumnya lebih lama dari counter terakhir, ditolak
+	previous, err := totp.Code(setup.Secret, now.Add(-30*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyTwoFactor(userID, previous); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("kode lama diterima: err = %v, want ErrInvalidTwoFactorCode", err)
+	}
+}
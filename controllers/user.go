@@ -3,15 +3,25 @@ package controllers
 import (
 	"backend/database"
 	"backend/types"
-	"backend/utils"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
+)
+
+var ErrUserNotFound = errors.New("user tidak ditemukan")
+
+// Nilai kolom users.status
+const (
+	UserStatusActive   = "active"
+	UserStatusInactive = "inactive"
+	UserStatusPending  = "pending" // sudah diundang, belum menerima undangan
 )
 
 func GetAllUsers() ([]types.User, error) {
 	rows, err := database.DB.Query(`
         SELECT 
-            u.id, u.name, u.email, u.phone, u.position, 
+            u.id, u.name, u.email, COALESCE(u.phone, ''), COALESCE(u.position, ''), 
             u.department_id, d.name as department_name,
             u.status, u.created_at 
         FROM users u
@@ -52,7 +62,7 @@ func GetUser(userID int) (*types.User, error) {
 	var user types.User
 	err := database.DB.QueryRow(`
         SELECT 
-            u.id, u.name, u.email, u.phone, u.position, 
+            u.id, u.name, u.email, COALESCE(u.phone, ''), COALESCE(u.position, ''), 
             u.department_id, d.name as department_name,
             u.status, u.created_at 
         FROM users u
@@ -77,52 +87,140 @@ func GetUser(userID int) (*types.User, error) {
 	return &user, nil
 }
 
-func CreateUser(req types.CreateUserRequest) (types.CreateUserRequest, error) {
-	fmt.Println("Creating user with data (controller):", req)
+// CreateUser membuat user baru. Ada tiga cara memberikan akses awal:
+//   - send_invite: user berstatus pending dan menerima tautan undangan via email
+//     untuk membuat password sendiri
+//   - password diisi: dipakai sebagai password awal
+//   - password kosong: dibuatkan password sementara yang dikembalikan sekali di response
+//
+// Untuk password awal dan sementara, user wajib mengganti password setelah login pertama.
+func CreateUser(req types.CreateUserRequest, createdBy int) (types.CreateUserResponse, error) {
+	req.Email = NormalizeEmail(req.Email)
+	slog.Debug("Creating user", "email", req.Email)
 
 	// cek apakah email sudah ada
 	var existingUserID int
 	err := database.DB.QueryRow(`
-				SELECT id FROM users WHERE email = $1
+				SELECT id FROM users WHERE LOWER(email) = $1
 		`, req.Email).Scan(&existingUserID)
 
 	if err != nil && err != sql.ErrNoRows {
 		// error unexpected (bukan "tidak ditemukan")
-		return req, fmt.Errorf("gagal memeriksa email: %w", err)
+		return types.CreateUserResponse{}, fmt.Errorf("gagal memeriksa email: %w", err)
 	}
 	if err == nil {
-		return req, fmt.Errorf("email sudah terdaftar")
+		return types.CreateUserResponse{}, fmt.Errorf("email sudah terdaftar")
 	}
 
-	// cek apakah nomor telepon sudah ada
-	err = database.DB.QueryRow(`
-				SELECT id FROM users WHERE phone = $1
-		`, req.Phone).Scan(&existingUserID)
+	// cek apakah nomor telepon sudah ada. Nomor kosong tidak dicek karena user
+	// dari SSO/LDAP disimpan tanpa nomor telepon.
+	if req.Phone != "" {
+		err = database.DB.QueryRow(`
+					SELECT id FROM users WHERE phone = $1
+			`, req.Phone).Scan(&existingUserID)
 
-	if err != nil && err != sql.ErrNoRows {
-		// error unexpected (bukan "tidak ditemukan")
-		return req, fmt.Errorf("gagal memeriksa nomor telepon: %w", err)
+		if err != nil && err != sql.ErrNoRows {
+			// error unexpected (bukan "tidak ditemukan")
+			return types.CreateUserResponse{}, fmt.Errorf("gagal memeriksa nomor telepon: %w", err)
+		}
+		if err == nil {
+			return types.CreateUserResponse{}, fmt.Errorf("nomor telepon sudah terdaftar")
+		}
 	}
-	if err == nil {
-		return req, fmt.Errorf("nomor telepon sudah terdaftar")
+
+	response := types.CreateUserResponse{
+		Name:  req.Name,
+		Email: req.Email,
+	}
+
+	var password string
+	switch {
+	case req.SendInvite:
+		// Password diisi sendiri oleh user saat menerima undangan; sampai saat itu
+		// hash diisi nilai acak yang tidak diketahui siapa pun
+		password, err = generateSecureToken()
+		if err != nil {
+			return types.CreateUserResponse{}, fmt.Errorf("gagal membuat password sementara: %w", err)
+		}
+		req.Status = UserStatusPending
+	case req.Password == "":
+		password, err = generateTemporaryPassword()
+		if err != nil {
+			return types.CreateUserResponse{}, fmt.Errorf("gagal membuat password sementara: %w", err)
+		}
+		response.TemporaryPassword = password
+		response.MustChangePassword = true
+	default:
+		if err := ValidatePasswordStrength(req.Password, req.Email); err != nil {
+			return types.CreateUserResponse{}, err
+		}
+		password = req.Password
+		response.MustChangePassword = true
+	}
+
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return types.CreateUserResponse{}, err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return types.CreateUserResponse{}, fmt.Errorf("gagal memulai transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	_, err = database.DB.Exec(`
-		INSERT INTO users (name, email, phone, position, department_id, status, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, NOW())
-	`, req.Name, req.Email, req.Phone, req.Position, req.DepartmentID, req.Status)
+	var userID int
+	err = tx.QueryRow(`
+		INSERT INTO users (name, email, phone, position, department_id, status, password_hash, must_change_password, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+		RETURNING id
+	`, req.Name, req.Email, req.Phone, req.Position, req.DepartmentID, req.Status, hashedPassword, response.MustChangePassword).Scan(&userID)
 
 	if err != nil {
-		return req, fmt.Errorf("gagal insert user: %w", err)
+		return types.CreateUserResponse{}, fmt.Errorf("gagal insert user: %w", err)
+	}
+
+	// user baru selalu mendapat role Employee
+	_, err = tx.Exec(`
+		INSERT INTO user_roles (user_id, role_id)
+		SELECT $1, id FROM roles WHERE name = 'Employee'
+	`, userID)
+
+	if err != nil {
+		return types.CreateUserResponse{}, fmt.Errorf("gagal assign role user: %w", err)
+	}
+
+	var inviteToken string
+	if req.SendInvite {
+		inviteToken, err = createInvitation(tx, userID, createdBy)
+		if err != nil {
+			return types.CreateUserResponse{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return types.CreateUserResponse{}, fmt.Errorf("gagal commit transaction: %w", err)
+	}
+
+	response.ID = userID
+	response.Status = req.Status
+
+	// User tetap dibuat walaupun email gagal terkirim; undangan bisa dikirim ulang
+	if req.SendInvite {
+		if err := sendInvitationEmail(req.Name, req.Email, inviteToken); err != nil {
+			slog.Error("Failed to send invitation email", "user_id", userID, "error", err)
+		} else {
+			response.InvitationSent = true
+		}
 	}
 
-	return req, nil
+	return response, nil
 }
 
 func SearchUsers(query string) ([]types.User, error) {
 	rows, err := database.DB.Query(`
         SELECT 
-            u.id, u.name, u.email, u.phone, u.position, 
+            u.id, u.name, u.email, COALESCE(u.phone, ''), COALESCE(u.position, ''), 
             u.department_id, d.name as department_name,
             u.status, u.created_at 
         FROM users u
@@ -162,28 +260,12 @@ func SearchUsers(query string) ([]types.User, error) {
 	return users, nil
 }
 
-func EditUser(userID int, req types.EditUserRequest) (map[string]interface{}, error) {
-	fmt.Println("Editing user with ID (controller):", userID)
-	fmt.Println("Edit User data (controller):", req)
-
-	// Buat map dari data yang ingin diedit
-	editData := map[string]interface{}{
-		"user_id":       userID,
-		"name":          req.Name,
-		"email":         req.Email,
-		"phone":         req.Phone,
-		"position":      req.Position,
-		"department_id": req.DepartmentID,
-		"status":        req.Status,
-	}
-
-	// Import utils untuk logging
-	utils.LogEditUser(userID, editData)
-
+// EditUser mengubah data user dan mencatat field yang berubah ke audit log
+func EditUser(userID int, req types.EditUserRequest, actorID int, ip string) (map[string]interface{}, error) {
 	// ambil data user lama
 	var oldData types.User
 	err := database.DB.QueryRow(`
-        SELECT id, name, email, phone, position, department_id, status
+        SELECT id, name, email, COALESCE(phone, ''), COALESCE(position, ''), department_id, status
         FROM users
         WHERE id = $1
     `, userID).Scan(
@@ -198,42 +280,44 @@ func EditUser(userID int, req types.EditUserRequest) (map[string]interface{}, er
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			utils.LogEditUser(userID, map[string]interface{}{
-				"error": "user tidak ditemukan",
-			})
 			return nil, fmt.Errorf("user tidak ditemukan")
 		}
 		return nil, fmt.Errorf("gagal query user: %w", err)
 	}
 
-	// 2. Buat map untuk menyimpan field yang akan diupdate
+	// 2. Buat map untuk menyimpan field yang akan diupdate beserta nilai lamanya
 	updateData := make(map[string]interface{})
+	previousData := make(map[string]interface{})
 
 	// 3. Cek setiap field, jika ada perubahan, tambahkan ke updateData
 	if req.Name != "" && req.Name != oldData.Name {
 		updateData["name"] = req.Name
+		previousData["name"] = oldData.Name
 	}
+	req.Email = NormalizeEmail(req.Email)
 	if req.Email != "" && req.Email != oldData.Email {
 		updateData["email"] = req.Email
+		previousData["email"] = oldData.Email
 	}
 	if req.Phone != "" && req.Phone != oldData.Phone {
 		updateData["phone"] = req.Phone
+		previousData["phone"] = oldData.Phone
 	}
 	if req.Position != "" && req.Position != oldData.Position {
 		updateData["position"] = req.Position
+		previousData["position"] = oldData.Position
 	}
 	if req.DepartmentID != 0 && req.DepartmentID != oldData.DepartmentID {
 		updateData["department_id"] = req.DepartmentID
+		previousData["department_id"] = oldData.DepartmentID
 	}
 	if req.Status != "" && req.Status != oldData.Status {
 		updateData["status"] = req.Status
+		previousData["status"] = oldData.Status
 	}
 
 	// 4. Jika tidak ada perubahan, return pesan tidak ada perubahan
 	if len(updateData) == 0 {
-		utils.LogEditUser(userID, map[string]interface{}{
-			"message": "tidak ada perubahan data",
-		})
 		return map[string]interface{}{
 			"message": "tidak ada perubahan data",
 			"user_id": userID,
@@ -243,9 +327,6 @@ func EditUser(userID int, req types.EditUserRequest) (map[string]interface{}, er
 	// 5. Mulai transaction
 	tx, err := database.DB.Begin()
 	if err != nil {
-		utils.LogEditUser(userID, map[string]interface{}{
-			"error": fmt.Sprintf("gagal memulai transaction: %v", err),
-		})
 		return nil, fmt.Errorf("gagal memulai transaction: %w", err)
 	}
 
@@ -270,9 +351,6 @@ func EditUser(userID int, req types.EditUserRequest) (map[string]interface{}, er
 	result, err := tx.Exec(updateQuery, args...)
 	if err != nil {
 		tx.Rollback()
-		utils.LogEditUser(userID, map[string]interface{}{
-			"error": fmt.Sprintf("gagal update user: %v", err),
-		})
 		return nil, fmt.Errorf("gagal update user: %w", err)
 	}
 
@@ -288,26 +366,43 @@ func EditUser(userID int, req types.EditUserRequest) (map[string]interface{}, er
 		return nil, fmt.Errorf("user tidak ditemukan")
 	}
 
-	// 9. Commit transaction
+	// User yang dinonaktifkan langsung dikeluarkan dari semua perangkat
+	if updateData["status"] == UserStatusInactive {
+		if err := revokeUserSessionsTx(tx, userID, ""); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	// 9. Catat perubahan ke audit log dalam transaction yang sama
+	err = recordAuditTx(tx, AuditEntry{
+		ActorID:    actorID,
+		Action:     AuditActionUserUpdate,
+		EntityType: AuditEntityUser,
+		EntityID:   userID,
+		Before:     previousData,
+		After:      updateData,
+		IP:         ip,
+	})
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// 10. Commit transaction
 	if err := tx.Commit(); err != nil {
-		utils.LogEditUser(userID, map[string]interface{}{
-			"error": fmt.Sprintf("gagal commit transaction: %v", err),
-		})
 		return nil, fmt.Errorf("gagal commit transaction: %w", err)
 	}
 
-	// 10. Log success dengan field yang berubah
+	if _, ok := updateData["status"]; ok {
+		InvalidateUserStatus(userID)
+	}
+
 	changedFields := make([]string, 0)
 	for field := range updateData {
 		changedFields = append(changedFields, field)
 	}
 
-	utils.LogEditUser(userID, map[string]interface{}{
-		"message":        "user berhasil diupdate",
-		"changed_fields": changedFields,
-		"updated_data":   updateData,
-	})
-
 	return map[string]interface{}{
 		"message":        "user berhasil diupdate",
 		"user_id":        userID,
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"backend/database"
+	"backend/types"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCreateUserWithoutPhone(t *testing.T) {
+	requireTestDB(t)
+
+	// User hasil provisioning SSO/LDAP tersimpan dengan phone kosong
+	existingID, _ := createTestUser(t, "user-no-phone")
+
+	var departmentID int
+	if err := database.DB.QueryRow(`SELECT department_id FROM users WHERE id = $1`, existingID).Scan(&departmentID); err != nil {
+		t.Fatal(err)
+	}
+
+	email := fmt.Sprintf("user-create-%d@test.local", time.Now().UnixNano())
+	t.Cleanup(func() { deleteTestUsers(t, email) })
+
+	_, err := CreateUser(types.CreateUserRequest{
+		Name:         "Tanpa Telepon",
+		Email:        email,
+		DepartmentID: departmentID,
+		Status:       UserStatusActive,
+	}, existingID)
+	if err != nil {
+		t.Fatalf("CreateUser tanpa nomor telepon: %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"backend/database"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Status user dicek di setiap request terautentikasi. Hasilnya di-cache sebentar
+// agar tidak menambah satu query per request; perubahan status dari instance
+// lain paling lambat terlihat setelah userStatusCacheTTL.
+const userStatusCacheTTL = 30 * time.Second
+
+type cachedUserStatus struct {
+	status             string
+	mustChangePassword bool
+	found              bool
+	expiresAt          time.Time
+}
+
+var userStatusCache = struct {
+	sync.Mutex
+	entries map[int]cachedUserStatus
+}{entries: make(map[int]cachedUserStatus)}
+
+// GetUserStatus mengembalikan status user (active, inactive, pending).
+// found false berarti user sudah tidak ada.
+func GetUserStatus(userID int) (status string, found bool, err error) {
+	cached, err := loadUserStatus(userID)
+	if err != nil {
+		return "", false, err
+	}
+	return cached.status, cached.found, nil
+}
+
+// UserMustChangePassword mengecek apakah user masih memakai password awal dan
+// wajib menggantinya sebelum memakai fitur lain
+func UserMustChangePassword(userID int) (bool, error) {
+	cached, err := loadUserStatus(userID)
+	if err != nil {
+		return false, err
+	}
+	return cached.mustChangePassword, nil
+}
+
+func loadUserStatus(userID int) (cachedUserStatus, error) {
+	now := time.Now()
+
+	userStatusCache.Lock()
+	cached, ok := userStatusCache.entries[userID]
+	userStatusCache.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached, nil
+	}
+
+	cached = cachedUserStatus{expiresAt: now.Add(userStatusCacheTTL)}
+	err := database.DB.QueryRow(`
+		SELECT status, must_change_password FROM users WHERE id = $1
+	`, userID).Scan(&cached.status, &cached.mustChangePassword)
+	if err == sql.ErrNoRows {
+		cached.found = false
+	} else if err != nil {
+		return cachedUserStatus{}, fmt.Errorf("gagal cek status user: %w", err)
+	} else {
+		cached.found = true
+	}
+
+	userStatusCache.Lock()
+	// Bersihkan entry yang sudah kedaluwarsa agar cache tidak tumbuh tanpa batas
+	for id, entry := range userStatusCache.entries {
+		if now.After(entry.expiresAt) {
+			delete(userStatusCache.entries, id)
+		}
+	}
+	userStatusCache.entries[userID] = cached
+	userStatusCache.Unlock()
+
+	return cached, nil
+}
+
+// InvalidateUserStatus menghapus status user dari cache (dipanggil setelah status
+// atau kewajiban ganti password berubah)
+func InvalidateUserStatus(userID int) {
+	userStatusCache.Lock()
+	delete(userStatusCache.entries, userID)
+	userStatusCache.Unlock()
+}
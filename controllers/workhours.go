@@ -3,9 +3,14 @@ package controllers
 import (
 	"backend/database"
 	"backend/types"
-	"log"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
 )
 
+var ErrInvalidWorkHours = errors.New("jam kerja tidak valid")
+
 func GetWorkHours() (types.WorkHours, error) {
 	var workHours types.WorkHours
 
@@ -24,9 +29,83 @@ func GetWorkHours() (types.WorkHours, error) {
 	)
 
 	if err != nil {
-		log.Printf("Error fetching work hours: %v", err)
+		slog.Error("Error fetching work hours", "error", err)
 		return types.WorkHours{}, err
 	}
 
 	return workHours, nil
 }
+
+// UpdateWorkHours menyimpan jam kerja baru. Baris lama tetap disimpan sebagai riwayat;
+// yang berlaku selalu baris terbaru.
+func UpdateWorkHours(req types.UpdateWorkHoursRequest, actorID int, ip string) (types.WorkHours, error) {
+	start, err := parseClock(req.WorkStartTime)
+	if err != nil {
+		return types.WorkHours{}, fmt.Errorf("%w: work_start_time harus format HH:MM:SS", ErrInvalidWorkHours)
+	}
+	end, err := parseClock(req.WorkEndTime)
+	if err != nil {
+		return types.WorkHours{}, fmt.Errorf("%w: work_end_time harus format HH:MM:SS", ErrInvalidWorkHours)
+	}
+	tolerance, err := parseClock(req.ToleranceTime)
+	if err != nil {
+		return types.WorkHours{}, fmt.Errorf("%w: tolerance_time harus format HH:MM:SS", ErrInvalidWorkHours)
+	}
+
+	if tolerance.Before(start) || !end.After(tolerance) {
+		return types.WorkHours{}, fmt.Errorf("%w: harus work_start_time <= tolerance_time < work_end_time", ErrInvalidWorkHours)
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return types.WorkHours{}, fmt.Errorf("gagal memulai transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Jam kerja yang berlaku sebelumnya (kosong jika belum pernah diatur)
+	var before interface{}
+	var previous types.UpdateWorkHoursRequest
+	err = tx.QueryRow(`
+		SELECT work_start_time::text, work_end_time::text, tolerance_time::text
+		FROM work_hours
+		ORDER BY id DESC
+		LIMIT 1
+	`).Scan(&previous.WorkStartTime, &previous.WorkEndTime, &previous.ToleranceTime)
+	if err != nil && err != sql.ErrNoRows {
+		return types.WorkHours{}, fmt.Errorf("gagal mengambil jam kerja lama: %w", err)
+	}
+	if err == nil {
+		before = previous
+	}
+
+	var workHoursID int
+	err = tx.QueryRow(`
+		INSERT INTO work_hours (work_start_time, work_end_time, tolerance_time)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, req.WorkStartTime, req.WorkEndTime, req.ToleranceTime).Scan(&workHoursID)
+
+	if err != nil {
+		return types.WorkHours{}, fmt.Errorf("gagal insert work hours: %w", err)
+	}
+
+	err = recordAuditTx(tx, AuditEntry{
+		ActorID:    actorID,
+		Action:     AuditActionWorkHoursUpdate,
+		EntityType: AuditEntityWorkHours,
+		EntityID:   workHoursID,
+		Before:     before,
+		After:      req,
+		IP:         ip,
+	})
+	if err != nil {
+		return types.WorkHours{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return types.WorkHours{}, fmt.Errorf("gagal commit transaction: %w", err)
+	}
+
+	slog.Info("Work hours updated", "work_start_time", req.WorkStartTime, "work_end_time", req.WorkEndTime, "tolerance_time", req.ToleranceTime)
+	return GetWorkHours()
+}
@@ -2,7 +2,7 @@ package database
 
 import (
 	"database/sql"
-	"log"
+	"log/slog"
 	"os"
 
 	"github.com/joho/godotenv"
@@ -14,7 +14,7 @@ var DB *sql.DB
 func Init() {
 	// Muat .env
 	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found")
+		slog.Warn("No .env file found")
 	}
 
 	// Koneksi PostgreSQL
@@ -28,10 +28,12 @@ func Init() {
 	var err error
 	DB, err = sql.Open("postgres", psqlInfo)
 	if err != nil {
-		log.Fatal(err)
+		slog.Error("Gagal membuka koneksi database", "error", err)
+		os.Exit(1)
 	}
 	if err = DB.Ping(); err != nil {
-		log.Fatal(err)
+		slog.Error("Gagal terhubung ke database", "error", err)
+		os.Exit(1)
 	}
-	log.Println("✅ Connected to PostgreSQL")
+	slog.Info("Connected to PostgreSQL")
 }
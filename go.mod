@@ -4,10 +4,9 @@ go 1.25.5
 
 require (
 	github.com/gorilla/mux v1.8.1
+	github.com/gorilla/securecookie v1.1.2
 	github.com/gorilla/sessions v1.4.0
 	github.com/joho/godotenv v1.5.1
 	github.com/lib/pq v1.10.9
 	golang.org/x/crypto v0.47.0
 )
-
-require github.com/gorilla/securecookie v1.1.2 // indirect
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"backend/controllers"
+	"backend/types"
+	"backend/utils"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+func GetRoles() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roles, err := controllers.GetRoles()
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error getting roles", "error", err)
+			http.Error(w, "Failed to get roles", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(roles)
+	}
+}
+
+func GetPermissions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		permissions, err := controllers.GetPermissions()
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error getting permissions", "error", err)
+			http.Error(w, "Failed to get permissions", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(permissions)
+	}
+}
+
+func UpdateRolePermissions(roleID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var updateReq types.UpdateRolePermissionsRequest
+		if err := json.NewDecoder(r.Body).Decode(&updateReq); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		actorID, _ := requestUserID(r)
+
+		err := controllers.SetRolePermissions(roleID, updateReq.Permissions, actorID, utils.ClientIP(r))
+		if errors.Is(err, controllers.ErrRoleNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, controllers.ErrUnknownPermission) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error updating role permissions", "role_id", roleID, "error", err)
+			http.Error(w, "Failed to update role permissions", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "Role permissions updated",
+		})
+	}
+}
+
+func UpdateUserRoles(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var updateReq types.UpdateUserRolesRequest
+		if err := json.NewDecoder(r.Body).Decode(&updateReq); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		actorID, _ := requestUserID(r)
+
+		err := controllers.SetUserRoles(userID, updateReq.Roles, actorID, utils.ClientIP(r))
+		if errors.Is(err, controllers.ErrUserNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, controllers.ErrUnknownRole) || errors.Is(err, controllers.ErrUserRolesRequired) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error updating user roles", "user_id", userID, "error", err)
+			http.Error(w, "Failed to update user roles", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "User roles updated",
+		})
+	}
+}
+
+func GetSystemSettings() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		settings, err := controllers.GetSystemSettings()
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error getting system settings", "error", err)
+			http.Error(w, "Failed to get system settings", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(settings)
+	}
+}
+
+func UpdateSystemSettings() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(r)
+		if !ok {
+			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+			return
+		}
+
+		var settings map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		updated, err := controllers.UpdateSystemSettings(settings, userID)
+		if errors.Is(err, controllers.ErrInvalidSetting) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error updating system settings", "error", err)
+			http.Error(w, "Failed to update system settings", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(updated)
+	}
+}
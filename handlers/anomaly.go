@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"backend/controllers"
+	"backend/types"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+func GetAttendanceAnomalies() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := r.URL.Query().Get("status")
+		anomalyType := r.URL.Query().Get("type")
+
+		anomalies, err := controllers.GetAttendanceAnomalies(status, anomalyType)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error getting attendance anomalies", "error", err)
+			http.Error(w, "Failed to get attendance anomalies", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(anomalies); err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func ReviewAttendanceAnomaly(anomalyID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reviewerID, ok := requestUserID(r)
+		if !ok {
+			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+			return
+		}
+
+		var reviewReq types.ReviewAnomalyRequest
+		if err := json.NewDecoder(r.Body).Decode(&reviewReq); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		err := controllers.ReviewAttendanceAnomaly(anomalyID, reviewerID, reviewReq)
+		if errors.Is(err, controllers.ErrInvalidReviewStatus) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, controllers.ErrAnomalyNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error reviewing anomaly", "anomaly_id", anomalyID, "error", err)
+			http.Error(w, "Failed to review anomaly", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "Anomaly reviewed",
+		})
+	}
+}
+
+// ScanAttendanceAnomalies menjalankan deteksi anomali secara manual (24 jam terakhir)
+func ScanAttendanceAnomalies() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		found, err := controllers.DetectAttendanceAnomalies(time.Now().Add(-24 * time.Hour))
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error scanning attendance anomalies", "error", err)
+			http.Error(w, "Failed to scan attendance anomalies", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(types.AnomalyScanResponse{NewAnomalies: found})
+	}
+}
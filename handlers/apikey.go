@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"backend/controllers"
+	"backend/types"
+	"backend/utils"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+func GetAPIKeys() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys, err := controllers.GetAPIKeys()
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error getting API keys", "error", err)
+			http.Error(w, "Failed to get API keys", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(keys)
+	}
+}
+
+// CreateAPIKey membuat personal access token atau service API key.
+// Key asli hanya ada di response ini.
+func CreateAPIKey() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		createdBy, ok := requestUserID(r)
+		if !ok {
+			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+			return
+		}
+
+		var createReq types.CreateAPIKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&createReq); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		key, err := controllers.CreateAPIKey(createReq, createdBy, utils.ClientIP(r))
+		if errors.Is(err, controllers.ErrInvalidAPIKeyRequest) || errors.Is(err, controllers.ErrUnknownPermission) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, controllers.ErrUserNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error creating API key", "error", err)
+			http.Error(w, "Failed to create API key", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(key)
+	}
+}
+
+func RevokeAPIKey(keyID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actorID, _ := requestUserID(r)
+		err := controllers.RevokeAPIKey(keyID, actorID, utils.ClientIP(r))
+		if errors.Is(err, controllers.ErrAPIKeyNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error revoking API key", "api_key_id", keyID, "error", err)
+			http.Error(w, "Failed to revoke API key", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -3,8 +3,11 @@ package handlers
 import (
 	"backend/controllers"
 	"backend/types"
+	"backend/utils"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
@@ -12,27 +15,21 @@ import (
 
 func GenerateToken() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// cek dulu session dan user_id
-		session, err := store.Get(r, "attendance-session")
-		if err != nil {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
-
-		userID, ok := session.Values["user_id"]
-		if !ok || userID == nil {
+		// cek dulu user yang login
+		userID, ok := requestUserID(r)
+		if !ok {
 			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
 			return
 		}
 
-		user, err := controllers.CheckAuthentication(userID.(int))
+		user, err := controllers.CheckAuthentication(userID)
 		if err != nil || !user.Authenticated {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
 		// generate token untuk user tersebut
-		attendanceToken, err := controllers.GenerateUserAttendanceToken(userID.(int))
+		attendanceToken, err := controllers.GenerateUserAttendanceToken(userID)
 
 		if err != nil {
 			http.Error(w, "Failed to generate attendance token", http.StatusInternalServerError)
@@ -62,9 +59,27 @@ func CheckAttendanceToken() http.HandlerFunc {
 			return
 		}
 
-		checkResp, err := controllers.CheckAttendanceToken(checkReq)
+		checkedBy, allowed, err := authorizeTokenAccess(r, checkReq.UserID, "check")
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Token authorization error", "error", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(types.CheckAttendanceTokenResponse{
+				Valid:   false,
+				Code:    controllers.TokenCodeForbidden,
+				Message: "Forbidden - token belongs to another user",
+			})
+			return
+		}
+
+		checkResp, err := controllers.CheckAttendanceToken(checkReq, checkedBy, utils.ClientIP(r))
 
 		if err != nil {
+			slog.ErrorContext(r.Context(), "Check attendance token error", "error", err)
 			http.Error(w, "Failed to check attendance token", http.StatusInternalServerError)
 			return
 		}
@@ -92,9 +107,28 @@ func SubmitAttendance() http.HandlerFunc {
 			return
 		}
 
-		submitResp, err := controllers.SubmitAttendance(submitReq)
+		redeemedBy, allowed, err := authorizeTokenAccess(r, submitReq.UserID, "submit")
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Token authorization error", "error", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(types.SubmitAttendanceResponse{
+				Success: false,
+				Code:    controllers.TokenCodeForbidden,
+				Message: "Forbidden - token belongs to another user",
+				UserID:  submitReq.UserID,
+			})
+			return
+		}
+
+		submitResp, err := controllers.SubmitAttendance(submitReq, redeemedBy, utils.ClientIP(r))
 
 		if err != nil {
+			slog.ErrorContext(r.Context(), "Submit attendance error", "error", err)
 			http.Error(w, "Failed to submit attendance", http.StatusInternalServerError)
 			return
 		}
@@ -108,6 +142,36 @@ func SubmitAttendance() http.HandlerFunc {
 	}
 }
 
+// authorizeTokenAccess memastikan token absensi hanya dicek atau ditukar oleh
+// pemiliknya sendiri, atau oleh scanner (user/API key dengan permission
+// attendance.scan). Percobaan terhadap token milik user lain dicatat sebagai
+// security event. actorID 0 berarti service API key.
+func authorizeTokenAccess(r *http.Request, tokenUserID int, action string) (actorID int, allowed bool, err error) {
+	p, ok := requestPrincipal(r)
+	if !ok {
+		return 0, false, nil
+	}
+
+	// Pemilik token hanya lewat session login; API key (termasuk personal access
+	// token milik pemilik token) selalu harus memiliki scope attendance.scan
+	if p.APIKeyID == 0 && p.UserID != 0 && p.UserID == tokenUserID {
+		return p.UserID, true, nil
+	}
+
+	allowed, err = principalHasPermission(p, "attendance.scan")
+	if err != nil {
+		return 0, false, err
+	}
+
+	if !allowed {
+		slog.WarnContext(r.Context(), "Attendance token owner mismatch", "security", true, "action", action, "token_user_id", tokenUserID)
+		controllers.RecordSecurityEvent(controllers.SecurityEventTokenOwnerMismatch, tokenUserID, p.UserID, utils.ClientIP(r),
+			fmt.Sprintf("Percobaan %s token absensi milik user ID %d", action, tokenUserID))
+	}
+
+	return p.UserID, allowed, nil
+}
+
 func GetTodayAttendance() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		attendances, err := controllers.GetTodayAttendance()
@@ -126,7 +190,13 @@ func GetTodayAttendance() http.HandlerFunc {
 
 func GetMonthlyAttendance() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		attendances, err := controllers.GetMonthlyAttendance()
+		month, year, err := parseMonthYear(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		attendances, err := controllers.GetMonthlyAttendance(month, year)
 		if err != nil {
 			http.Error(w, "Failed to get monthly attendance", http.StatusInternalServerError)
 			return
@@ -143,51 +213,88 @@ func GetMonthlyAttendance() http.HandlerFunc {
 func GetEmployeeMonthlyAttendance() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Get userID from query parameter
-		userIDStr := r.URL.Query().Get("user_id")
-		if userIDStr == "" {
-			http.Error(w, "user_id is required", http.StatusBadRequest)
+		userID, err := parseQueryUserID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		month, year, err := parseMonthYear(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		userID := 0
-		_, err := fmt.Sscanf(userIDStr, "%d", &userID)
+		attendance, err := controllers.GetEmployeeMonthlyAttendance(userID, month, year)
 		if err != nil {
-			http.Error(w, "Invalid user_id", http.StatusBadRequest)
+			http.Error(w, "Failed to get employee monthly attendance", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(attendance); err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
+	}
+}
+
+// parseQueryUserID membaca query parameter user_id (wajib)
+func parseQueryUserID(r *http.Request) (int, error) {
+	userIDStr := r.URL.Query().Get("user_id")
+	if userIDStr == "" {
+		return 0, errors.New("user_id is required")
+	}
+
+	userID := 0
+	if _, err := fmt.Sscanf(userIDStr, "%d", &userID); err != nil {
+		return 0, errors.New("Invalid user_id")
+	}
+
+	return userID, nil
+}
 
-		// Get month and year from query parameters, default to current month/year
-		month := time.Now().Month()
-		year := time.Now().Year()
+// parseMonthYear membaca query parameter month dan year,
+// default ke bulan dan tahun berjalan
+func parseMonthYear(r *http.Request) (int, int, error) {
+	month := int(time.Now().Month())
+	year := time.Now().Year()
 
-		if monthStr := r.URL.Query().Get("month"); monthStr != "" {
-			m, err := strconv.Atoi(monthStr)
-			if err != nil || m < 1 || m > 12 {
-				http.Error(w, "Invalid month", http.StatusBadRequest)
-				return
-			}
-			month = time.Month(m)
+	if monthStr := r.URL.Query().Get("month"); monthStr != "" {
+		m, err := strconv.Atoi(monthStr)
+		if err != nil || m < 1 || m > 12 {
+			return 0, 0, errors.New("Invalid month")
 		}
+		month = m
+	}
 
-		if yearStr := r.URL.Query().Get("year"); yearStr != "" {
-			y, err := strconv.Atoi(yearStr)
-			if err != nil || y < 2000 || y > 2100 {
-				http.Error(w, "Invalid year", http.StatusBadRequest)
-				return
-			}
-			year = y
+	if yearStr := r.URL.Query().Get("year"); yearStr != "" {
+		y, err := strconv.Atoi(yearStr)
+		if err != nil || y < 2000 || y > 2100 {
+			return 0, 0, errors.New("Invalid year")
 		}
+		year = y
+	}
+
+	return month, year, nil
+}
 
-		attendance, err := controllers.GetEmployeeMonthlyAttendance(userID, int(month), year)
+func ExportMonthlyAttendance() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		month, year, err := parseMonthYear(r)
 		if err != nil {
-			http.Error(w, "Failed to get employee monthly attendance", http.StatusInternalServerError)
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(attendance); err != nil {
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		data, err := controllers.MonthlyAttendanceCSV(month, year)
+		if err != nil {
+			http.Error(w, "Failed to export monthly attendance", http.StatusInternalServerError)
 			return
 		}
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"attendance-%d-%02d.csv\"", year, month))
+		w.Write(data)
 	}
 }
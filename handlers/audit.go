@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"backend/controllers"
+	"backend/types"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// GetAuditLogs menampilkan audit log dengan filter query parameter:
+// actor_id, action, entity_type, entity_id, from, to (YYYY-MM-DD, inklusif),
+// limit (maks 500) dan offset
+func GetAuditLogs() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseAuditLogFilter(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		logs, err := controllers.GetAuditLogs(filter)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error getting audit logs", "error", err)
+			http.Error(w, "Failed to get audit logs", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(logs); err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func parseAuditLogFilter(r *http.Request) (types.AuditLogFilter, error) {
+	query := r.URL.Query()
+	filter := types.AuditLogFilter{
+		Action:     query.Get("action"),
+		EntityType: query.Get("entity_type"),
+	}
+
+	ints := []struct {
+		name string
+		dest *int
+	}{
+		{"actor_id", &filter.ActorID},
+		{"entity_id", &filter.EntityID},
+		{"limit", &filter.Limit},
+		{"offset", &filter.Offset},
+	}
+	for _, param := range ints {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return filter, errors.New("Invalid " + param.name)
+		}
+		*param.dest = n
+	}
+
+	if value := query.Get("from"); value != "" {
+		from, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			return filter, errors.New("Invalid from, gunakan format YYYY-MM-DD")
+		}
+		filter.From = &from
+	}
+	if value := query.Get("to"); value != "" {
+		to, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			return filter, errors.New("Invalid to, gunakan format YYYY-MM-DD")
+		}
+		// "to" inklusif: ambil sampai akhir hari tersebut
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+
+	return filter, nil
+}
+
+// VerifyAuditChain menelusuri hash chain audit log dan melaporkan mata rantai
+// pertama yang rusak
+func VerifyAuditChain() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result, err := controllers.VerifyAuditChain()
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error verifying audit chain", "error", err)
+			http.Error(w, "Failed to verify audit chain", http.StatusInternalServerError)
+			return
+		}
+
+		if !result.Valid {
+			slog.WarnContext(r.Context(), "Audit chain broken", "security", true, "broken_at_id", *result.BrokenAtID, "reason", result.Reason)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// GetAuditDigest mengunduh digest harian audit chain yang ditandatangani
+// (query parameter date=YYYY-MM-DD, default kemarin)
+func GetAuditDigest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		date := time.Now().AddDate(0, 0, -1)
+		if value := r.URL.Query().Get("date"); value != "" {
+			parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+			if err != nil {
+				http.Error(w, "Invalid date, gunakan format YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+			date = parsed
+		}
+
+		digest, err := controllers.GenerateAuditDigest(date)
+		if errors.Is(err, controllers.ErrAuditDigestFutureDate) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, controllers.ErrAuditSigningKeyMissing) || errors.Is(err, controllers.ErrAuditSigningKeyInvalid) {
+			slog.ErrorContext(r.Context(), "Audit digest unavailable", "error", err)
+			http.Error(w, "Audit digest belum dikonfigurasi", http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error generating audit digest", "error", err)
+			http.Error(w, "Failed to generate audit digest", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"audit-digest-%s.json\"", date.Format("2006-01-02")))
+		json.NewEncoder(w).Encode(digest)
+	}
+}
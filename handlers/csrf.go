@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+// CSRFHeader adalah header yang wajib dikirim frontend pada request yang
+// mengubah data (POST, PUT, PATCH, DELETE)
+const CSRFHeader = "X-CSRF-Token"
+
+// RequireCSRF adalah middleware CSRF dengan pola synchronizer token: token acak
+// disimpan di session (server-side) dan harus dikirim ulang lewat header
+// X-CSRF-Token. Request dengan API key (Authorization: Bearer) dilewati karena
+// tidak memakai cookie sehingga tidak rentan CSRF.
+func RequireCSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if p, ok := requestPrincipal(r); ok && p.APIKeyID != 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		session, err := store.Get(r, "attendance-session")
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Session error", "error", err)
+			http.Error(w, "Forbidden - invalid CSRF token", http.StatusForbidden)
+			return
+		}
+
+		expected, _ := session.Values["csrf_token"].(string)
+		actual := r.Header.Get(CSRFHeader)
+		if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(actual)) != 1 {
+			http.Error(w, "Forbidden - invalid CSRF token", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// csrfToken mengembalikan token CSRF milik session, dan membuatnya jika belum ada
+func csrfToken(w http.ResponseWriter, r *http.Request) (string, error) {
+	session, err := store.Get(r, "attendance-session")
+	if err != nil {
+		return "", err
+	}
+
+	if token, ok := session.Values["csrf_token"].(string); ok && token != "" {
+		return token, nil
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	session.Values["csrf_token"] = token
+	if err := session.Save(r, w); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// GetCSRFToken mengembalikan token CSRF untuk request sebelum login (login dan
+// langkah 2FA). Token terikat ke session cookie (anonim) sehingga situs lain
+// tidak bisa membacanya; setelah login berhasil token diganti dan diambil
+// ulang lewat GET /api/auth/check.
+func GetCSRFToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, err := csrfToken(w, r)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "CSRF token error", "error", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(map[string]string{"csrf_token": token})
+	}
+}
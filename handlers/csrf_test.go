@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPreLoginCSRFToken(t *testing.T) {
+	requireTestDB(t)
+
+	protected := RequireCSRF(okHandler())
+
+	// Tanpa session (misalnya form dari situs lain) request ditolak
+	rec := httptest.NewRecorder()
+	protected.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/login", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("tanpa token: status = %d, want 403", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	GetCSRFToken()(rec, httptest.NewRequest(http.MethodGet, "/api/csrf", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/csrf status = %d", rec.Code)
+	}
+	var body struct {
+		CSRFToken string `json:"csrf_token"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.CSRFToken == "" {
+		t.Fatalf("response tanpa csrf_token: %v", err)
+	}
+	cookie := sessionCookie(t, rec.Result().Cookies())
+	if cookie.MaxAge != int(anonymousSessionMaxAge.Seconds()) {
+		t.Errorf("cookie session anonim MaxAge = %d, want %d", cookie.MaxAge, int(anonymousSessionMaxAge.Seconds()))
+	}
+
+	// Request berikutnya dengan cookie memakai session yang sama
+	req := httptest.NewRequest(http.MethodGet, "/api/csrf", nil)
+	req.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	GetCSRFToken()(rec, req)
+	var again struct {
+		CSRFToken string `json:"csrf_token"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&again); err != nil || again.CSRFToken != body.CSRFToken {
+		t.Errorf("token kedua = %q (%v), want token session yang sama", again.CSRFToken, err)
+	}
+	if len(rec.Result().Cookies()) != 0 {
+		t.Error("session baru dibuat walaupun cookie session masih berlaku")
+	}
+
+	tests := []struct {
+		name   string
+		cookie bool
+		token  string
+		want   int
+	}{
+		{"cookie dan token", true, body.CSRFToken, http.StatusOK},
+		{"cookie tanpa token", true, "", http.StatusForbidden},
+		{"cookie dengan token lain", true, body.CSRFToken + "x", http.StatusForbidden},
+		{"token tanpa cookie", false, body.CSRFToken, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/login", nil)
+			if tt.cookie {
+				req.AddCookie(cookie)
+			}
+			if tt.token != "" {
+				req.Header.Set(CSRFHeader, tt.token)
+			}
+
+			rec := httptest.NewRecorder()
+			protected.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -2,7 +2,10 @@ package handlers
 
 import (
 	"backend/controllers"
+	"backend/types"
 	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
 )
 
@@ -19,3 +22,67 @@ func GetDepartments() http.HandlerFunc {
 		json.NewEncoder(w).Encode(departments)
 	}
 }
+
+func CreateDepartment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var deptReq types.DepartmentRequest
+		if err := json.NewDecoder(r.Body).Decode(&deptReq); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		dept, err := controllers.CreateDepartment(deptReq)
+		if err != nil {
+			writeDepartmentError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(dept)
+	}
+}
+
+func UpdateDepartment(departmentID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var deptReq types.DepartmentRequest
+		if err := json.NewDecoder(r.Body).Decode(&deptReq); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		dept, err := controllers.UpdateDepartment(departmentID, deptReq)
+		if err != nil {
+			writeDepartmentError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(dept)
+	}
+}
+
+func DeleteDepartment(departmentID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := controllers.DeleteDepartment(departmentID); err != nil {
+			writeDepartmentError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeDepartmentError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, controllers.ErrDepartmentName), errors.Is(err, controllers.ErrManagerNotFound):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, controllers.ErrDepartmentNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, controllers.ErrDepartmentExists), errors.Is(err, controllers.ErrDepartmentInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		slog.ErrorContext(r.Context(), "Department error", "error", err)
+		http.Error(w, "Gagal memproses departemen", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"backend/controllers"
+	"backend/types"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// AcceptInvitation dipanggil dari halaman undangan (tanpa login): user membuat
+// password sendiri dan akun menjadi aktif
+func AcceptInvitation() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var acceptReq types.AcceptInvitationRequest
+		if err := json.NewDecoder(r.Body).Decode(&acceptReq); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		err := controllers.AcceptInvitation(acceptReq.Token, acceptReq.Password)
+		if errors.Is(err, controllers.ErrInvalidInvitation) || isPasswordInputError(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error accepting invitation", "error", err)
+			http.Error(w, "Failed to accept invitation", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "Invitation accepted, you can now log in",
+		})
+	}
+}
+
+func ResendInvitation(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		invitedBy, ok := requestUserID(r)
+		if !ok {
+			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+			return
+		}
+
+		err := controllers.ResendInvitation(userID, invitedBy)
+		if errors.Is(err, controllers.ErrUserNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, controllers.ErrUserNotPending) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error resending invitation", "user_id", userID, "error", err)
+			http.Error(w, "Failed to resend invitation", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "Invitation sent",
+		})
+	}
+}
+
+func RevokeInvitation(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := controllers.RevokeInvitation(userID)
+		if errors.Is(err, controllers.ErrNoActiveInvite) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error revoking invitation", "user_id", userID, "error", err)
+			http.Error(w, "Failed to revoke invitation", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
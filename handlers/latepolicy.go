@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"backend/controllers"
+	"backend/types"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+func GetLatePolicy() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		policy, err := controllers.GetLatePolicy()
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error getting late policy", "error", err)
+			http.Error(w, "Failed to get late policy", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(policy); err != nil {
+			slog.ErrorContext(r.Context(), "JSON encoding error", "error", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func UpdateLatePolicy() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var updateReq types.UpdateLatePolicyRequest
+		if err := json.NewDecoder(r.Body).Decode(&updateReq); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		policy, err := controllers.UpdateLatePolicy(updateReq)
+		if errors.Is(err, controllers.ErrInvalidLatePolicy) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error updating late policy", "error", err)
+			http.Error(w, "Failed to update late policy", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(policy); err != nil {
+			slog.ErrorContext(r.Context(), "JSON encoding error", "error", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"backend/controllers"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// SyncLDAPUsers menjalankan sinkronisasi user dari LDAP. Dengan ?dry_run=true
+// hanya mengembalikan rencana perubahan tanpa menyimpan apa pun.
+func SyncLDAPUsers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dryRun := r.URL.Query().Get("dry_run") == "true"
+
+		report, err := controllers.SyncLDAPUsers(dryRun)
+		if errors.Is(err, controllers.ErrLDAPNotConfigured) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, controllers.ErrLDAPSyncEmptyDirectory) || errors.Is(err, controllers.ErrLDAPSyncTooManyDeactivations) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "LDAP sync error", "error", err)
+			http.Error(w, "Failed to sync users from LDAP", http.StatusBadGateway)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	}
+}
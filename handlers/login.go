@@ -1,41 +1,69 @@
 package handlers
 
 import (
+	"backend/controllers"
 	"backend/database"
 	"backend/types"
+	"backend/utils"
 	"database/sql"
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
-	"os"
+	"strconv"
 	"strings"
 
 	"github.com/gorilla/sessions"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// Store akan diinisialisasi setelah .env dimuat
-var store *sessions.CookieStore
+// defaultSessionSecret hanya boleh dipakai di mode development
+const defaultSessionSecret = "default-secret-key-change-this"
 
-func init() {
-	// Tunggu sampai SESSION_SECRET tersedia
-	sessionSecret := os.Getenv("SESSION_SECRET")
-	if sessionSecret == "" {
-		log.Println("Warning: SESSION_SECRET tidak ditemukan, menggunakan default (tidak aman untuk production)")
-		sessionSecret = "default-secret-key-change-this"
+// ErrInsecureSessionSecret dikembalikan jika SESSION_SECRET kosong atau masih
+// memakai nilai default di luar mode development
+var ErrInsecureSessionSecret = errors.New("SESSION_SECRET wajib diisi dengan nilai acak di luar mode development")
+
+// SessionConfig berisi konfigurasi session dan cookie session
+type SessionConfig struct {
+	Secret       string
+	CookieSecure bool   // true jika aplikasi diakses lewat HTTPS
+	CookieDomain string // kosong = hanya host saat ini
+	Development  bool   // mode development mengizinkan secret default
+}
+
+// Store diinisialisasi lewat InitSessionStore setelah .env dimuat
+var store *dbStore
+
+// InitSessionStore menyiapkan session store. Di luar mode development server
+// menolak berjalan dengan SESSION_SECRET kosong atau default karena cookie
+// session bisa dipalsukan oleh siapa pun yang mengetahui secret tersebut.
+func InitSessionStore(cfg SessionConfig) error {
+	sessionSecret := cfg.Secret
+	if sessionSecret == "" || sessionSecret == defaultSessionSecret {
+		if !cfg.Development {
+			return ErrInsecureSessionSecret
+		}
+		slog.Warn("SESSION_SECRET tidak diset, menggunakan default (hanya untuk development)")
+		sessionSecret = defaultSessionSecret
+	} else if len(sessionSecret) < 32 {
+		slog.Warn("SESSION_SECRET sebaiknya minimal 32 karakter")
 	}
 
-	// Inisialisasi session store
-	store = sessions.NewCookieStore([]byte(sessionSecret))
+	// Inisialisasi session store (data session di database, cookie hanya berisi token)
+	store = newDBStore([]byte(sessionSecret))
 
 	// Konfigurasi session
 	store.Options = &sessions.Options{
 		Path:     "/",
-		MaxAge:   60 * 60 * 24, // 24 jam
-		HttpOnly: true,         // Mencegah JavaScript akses cookie
-		Secure:   false,        // Set true jika pakai HTTPS
+		Domain:   cfg.CookieDomain,
+		MaxAge:   60 * 60 * 24,     // 24 jam
+		HttpOnly: true,             // Mencegah JavaScript akses cookie
+		Secure:   cfg.CookieSecure, // Cookie hanya dikirim lewat HTTPS
 		SameSite: http.SameSiteLaxMode,
 	}
+
+	return nil
 }
 
 func LoginHandler(w http.ResponseWriter, r *http.Request) {
@@ -63,43 +91,107 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Format email tidak valid", http.StatusBadRequest)
 		return
 	}
+	loginReq.Email = controllers.NormalizeEmail(loginReq.Email)
+
+	ip := utils.ClientIP(r)
+
+	// Proteksi brute-force: cek lock akun, penundaan bertahap dan batas per IP
+	if !checkLoginGuard(w, loginReq.Email, ip) {
+		return
+	}
 
 	var userID int
 	var hashedPassword string
+	var status string
+	var authSource string
 
 	err := database.DB.QueryRow(`
-		SELECT id, password_hash 
+		SELECT id, password_hash, status, auth_source
 		FROM users 
-		WHERE email = $1
-	`, loginReq.Email).Scan(&userID, &hashedPassword)
+		WHERE LOWER(email) = $1
+	`, loginReq.Email).Scan(&userID, &hashedPassword, &status, &authSource)
 
 	if err == sql.ErrNoRows {
+		if err := controllers.RecordLoginFailure(loginReq.Email, 0, ip); err != nil {
+			slog.ErrorContext(r.Context(), "Failed to record login failure", "error", err)
+		}
 		http.Error(w, "Invalid email or password", http.StatusUnauthorized)
 		return
 	} else if err != nil {
-		log.Printf("DB error: %v", err)
+		slog.ErrorContext(r.Context(), "DB error", "error", err)
 		http.Error(w, "Server error", http.StatusInternalServerError)
 		return
 	}
 
-	// Verifikasi password
-	if err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(loginReq.Password)); err != nil {
+	// Verifikasi password: user hasil sinkronisasi LDAP diverifikasi dengan bind
+	// ke directory, user lainnya dengan hash password lokal
+	var passwordErr error
+	if authSource == controllers.AuthSourceLDAP {
+		passwordErr = controllers.AuthenticateLDAP(loginReq.Email, loginReq.Password)
+		if passwordErr != nil && !errors.Is(passwordErr, controllers.ErrLDAPInvalidCredentials) {
+			slog.ErrorContext(r.Context(), "LDAP authentication error", "error", passwordErr)
+			http.Error(w, "Directory login tidak tersedia, silakan coba lagi nanti", http.StatusServiceUnavailable)
+			return
+		}
+	} else {
+		passwordErr = bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(loginReq.Password))
+	}
+
+	if passwordErr != nil {
+		if err := controllers.RecordLoginFailure(loginReq.Email, userID, ip); err != nil {
+			slog.ErrorContext(r.Context(), "Failed to record login failure", "error", err)
+		}
+		recordLoginFailureAudit(loginReq.Email, userID, ip)
 		http.Error(w, "Invalid email or password", http.StatusUnauthorized)
 		return
 	}
 
-	// Simpan sesi
-	session, err := store.Get(r, "attendance-session")
+	// Akun undangan yang belum diterima belum bisa dipakai login
+	if status == controllers.UserStatusPending {
+		http.Error(w, "Akun belum aktif, silakan terima undangan melalui email", http.StatusForbidden)
+		return
+	}
+
+	// Akun yang dinonaktifkan HR tidak bisa login
+	if status != controllers.UserStatusActive {
+		http.Error(w, "Akun tidak aktif, silakan hubungi HR", http.StatusForbidden)
+		return
+	}
+
+	// Jika user memakai 2FA (atau role-nya mewajibkan 2FA), session belum
+	// terautentikasi penuh sampai langkah kedua selesai
+	twoFactorEnabled, twoFactorRequired, err := controllers.TwoFactorStatus(userID)
 	if err != nil {
-		log.Printf("Session error: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "2FA status error", "error", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
 		return
 	}
 
-	session.Values["user_id"] = userID
+	if twoFactorEnabled || twoFactorRequired {
+		enrollmentRequired := !twoFactorEnabled
+		if err := startTwoFactorLogin(w, r, userID, loginReq.Email, enrollmentRequired); err != nil {
+			slog.ErrorContext(r.Context(), "Failed to save session", "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
 
-	if err := session.Save(r, w); err != nil {
-		log.Printf("Failed to save session: %v", err)
+		message := "Masukkan kode dari aplikasi authenticator"
+		if enrollmentRequired {
+			message = "Role Anda mewajibkan 2FA, silakan aktifkan 2FA terlebih dahulu"
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":             true,
+			"two_factor_required": true,
+			"enrollment_required": enrollmentRequired,
+			"message":             message,
+		})
+		return
+	}
+
+	if err := completeLogin(w, r, userID, loginReq.Email); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to save session", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -111,6 +203,75 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// checkLoginGuard menolak request login (429 + Retry-After) jika akun terkunci,
+// masih dalam masa penundaan, atau IP sudah melewati batas percobaan gagal
+func checkLoginGuard(w http.ResponseWriter, email string, ip string) bool {
+	wait, err := controllers.CheckLoginAllowed(email, ip)
+	if errors.Is(err, controllers.ErrAccountLocked) || errors.Is(err, controllers.ErrLoginThrottled) || errors.Is(err, controllers.ErrTooManyAttempts) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())))
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return false
+	}
+	if err != nil {
+		slog.Error("Login guard error", "error", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// completeLogin menandai session sebagai terautentikasi penuh
+func completeLogin(w http.ResponseWriter, r *http.Request, userID int, email string) error {
+	if err := controllers.RecordLoginSuccess(email, userID, utils.ClientIP(r)); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to record login success", "error", err)
+	}
+
+	session, err := store.Get(r, "attendance-session")
+	if err != nil {
+		return err
+	}
+
+	// Token session selalu diganti setelah login berhasil
+	if err := store.Renew(session); err != nil {
+		return err
+	}
+
+	clearTwoFactorLogin(session)
+	delete(session.Values, "csrf_token")
+	session.Values["user_id"] = userID
+
+	if err := session.Save(r, w); err != nil {
+		return err
+	}
+
+	controllers.RecordAudit(controllers.AuditEntry{
+		ActorID:    userID,
+		Action:     controllers.AuditActionLogin,
+		EntityType: controllers.AuditEntityUser,
+		EntityID:   userID,
+		After:      map[string]interface{}{"email": email},
+		IP:         utils.ClientIP(r),
+	})
+	return nil
+}
+
+// recordLoginFailureAudit mencatat login gagal ke audit log, hanya untuk akun
+// yang ada. Percobaan dengan email tidak terdaftar sudah tercatat di
+// login_attempts dan tidak dimasukkan ke hash chain, agar request anonim tidak
+// bisa membanjiri audit_logs dan lock penulisannya.
+func recordLoginFailureAudit(email string, userID int, ip string) {
+	if userID == 0 {
+		return
+	}
+	controllers.RecordAudit(controllers.AuditEntry{
+		Action:     controllers.AuditActionLoginFailed,
+		EntityType: controllers.AuditEntityUser,
+		EntityID:   userID,
+		After:      map[string]interface{}{"email": email},
+		IP:         ip,
+	})
+}
+
 // LogoutHandler menghapus session user
 func LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -120,7 +281,7 @@ func LogoutHandler(w http.ResponseWriter, r *http.Request) {
 
 	session, err := store.Get(r, "attendance-session")
 	if err != nil {
-		log.Printf("Session error: %v", err)
+		slog.ErrorContext(r.Context(), "Session error", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
@@ -128,7 +289,7 @@ func LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	// Hapus session dengan set MaxAge ke -1
 	session.Options.MaxAge = -1
 	if err := session.Save(r, w); err != nil {
-		log.Printf("Failed to delete session: %v", err)
+		slog.ErrorContext(r.Context(), "Failed to delete session", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
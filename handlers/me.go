@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"backend/controllers"
+	"backend/types"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// GetMyAttendance mengembalikan riwayat absensi user yang sedang login.
+// user_id selalu diambil dari session, bukan dari query parameter,
+// sehingga karyawan tidak bisa membaca data karyawan lain.
+func GetMyAttendance() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(r)
+		if !ok {
+			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+			return
+		}
+
+		month, year, err := parseMonthYear(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		attendance, err := controllers.GetMyAttendance(userID, month, year)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error getting attendance history", "user_id", userID, "error", err)
+			http.Error(w, "Failed to get attendance history", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(attendance); err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// CreateMyLeaveRequest membuat pengajuan cuti untuk user yang sedang login
+func CreateMyLeaveRequest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(r)
+		if !ok {
+			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+			return
+		}
+
+		var leaveReq types.CreateLeaveRequest
+		if err := json.NewDecoder(r.Body).Decode(&leaveReq); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		leave, err := controllers.CreateLeaveRequest(userID, leaveReq)
+		if errors.Is(err, controllers.ErrInvalidLeaveRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error creating leave request", "user_id", userID, "error", err)
+			http.Error(w, "Failed to create leave request", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(leave)
+	}
+}
+
+// CreateMyAttendanceCorrection membuat pengajuan koreksi absensi untuk user yang sedang login
+func CreateMyAttendanceCorrection() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(r)
+		if !ok {
+			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+			return
+		}
+
+		var correctionReq types.CreateAttendanceCorrectionRequest
+		if err := json.NewDecoder(r.Body).Decode(&correctionReq); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		correction, err := controllers.CreateAttendanceCorrection(userID, correctionReq)
+		if errors.Is(err, controllers.ErrInvalidCorrectionRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error creating attendance correction", "user_id", userID, "error", err)
+			http.Error(w, "Failed to create attendance correction", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(correction)
+	}
+}
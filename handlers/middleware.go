@@ -2,99 +2,316 @@ package handlers
 
 import (
 	"backend/controllers"
+	"backend/utils"
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
 )
 
+// principal adalah identitas yang mengakses route terproteksi: user dari
+// session cookie, atau API key dari header Authorization: Bearer
+type principal struct {
+	UserID      int      // 0 untuk service API key
+	APIKeyID    int      // 0 untuk session cookie
+	Permissions []string // scope API key, tidak dipakai untuk session cookie
+}
+
+type principalContextKey struct{}
+
+// passwordChangeExempt adalah route terproteksi yang tetap boleh diakses user yang
+// masih wajib mengganti password awal
+var passwordChangeExempt = map[string]bool{
+	"/api/auth/check":  true,
+	"/api/me/password": true,
+}
+
 // RequireAuth adalah middleware untuk memastikan user sudah login
-// Middleware ini mengecek apakah ada session yang valid
+// Middleware ini mengecek session yang valid atau API key di header
+// Authorization: Bearer, lalu menyimpan principal-nya di context request
 func RequireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Ambil session
-		session, err := store.Get(r, "attendance-session")
-		if err != nil {
-			log.Printf("Session error: %v", err)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
+		var p principal
+
+		if token, ok := bearerToken(r); ok {
+			key, err := controllers.AuthenticateAPIKey(token, utils.ClientIP(r))
+			if errors.Is(err, controllers.ErrInvalidAPIKey) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				http.Error(w, "Unauthorized - invalid API key", http.StatusUnauthorized)
+				return
+			}
+			if err != nil {
+				slog.ErrorContext(r.Context(), "API key check error", "error", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			p = principal{UserID: key.UserID, APIKeyID: key.KeyID, Permissions: key.Permissions}
+
+			// API key hanya berlaku di route yang dijaga permission (default-deny);
+			// route tanpa permission gate hanya untuk session login
+			if !routeHasPermissionGate(r) {
+				http.Error(w, "Forbidden - this endpoint cannot be accessed with an API key", http.StatusForbidden)
+				return
+			}
+		} else {
+			// Ambil session
+			session, err := store.Get(r, "attendance-session")
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Session error", "error", err)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			// Cek apakah user_id ada di session
+			userID, ok := session.Values["user_id"].(int)
+			if !ok {
+				http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+				return
+			}
+			p = principal{UserID: userID}
 		}
 
-		// Cek apakah user_id ada di session
-		userID, ok := session.Values["user_id"]
-		if !ok || userID == nil {
-			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
-			return
+		// User yang sudah dihapus atau dinonaktifkan tidak boleh memakai session
+		// lamanya maupun personal access token miliknya
+		if p.UserID != 0 {
+			status, found, err := controllers.GetUserStatus(p.UserID)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "User status check error", "error", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+			if !found {
+				http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+				return
+			}
+			if status != controllers.UserStatusActive {
+				http.Error(w, "Forbidden - account is not active", http.StatusForbidden)
+				return
+			}
+
+			// User dengan password awal hanya boleh mengganti password-nya dulu
+			if p.APIKeyID == 0 && !passwordChangeExempt[r.URL.Path] {
+				mustChange, err := controllers.UserMustChangePassword(p.UserID)
+				if err != nil {
+					slog.ErrorContext(r.Context(), "User status check error", "error", err)
+					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					return
+				}
+				if mustChange {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusForbidden)
+					json.NewEncoder(w).Encode(map[string]string{
+						"error": "Forbidden - password awal harus diganti terlebih dahulu",
+						"code":  "password_change_required",
+					})
+					return
+				}
+			}
+		}
+
+		// User dan API key ikut dicatat di setiap log request ini
+		if fields := utils.LogFieldsFromContext(r.Context()); fields != nil {
+			fields.UserID = p.UserID
+			fields.APIKeyID = p.APIKeyID
 		}
 
 		// Jika sudah login, lanjutkan ke handler berikutnya
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalContextKey{}, p)))
 	})
 }
 
-func CheckAuthentication() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		session, err := store.Get(r, "attendance-session")
-		if err != nil {
-			log.Printf("Session error: %v", err)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+// RequireSession menolak request yang memakai API key. Dipasang pada route
+// self-service yang sensitif (password, 2FA, session) dan route Admin.
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := requestPrincipal(r); ok && p.APIKeyID != 0 {
+			http.Error(w, "Forbidden - this endpoint requires a login session", http.StatusForbidden)
 			return
 		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func requestPrincipal(r *http.Request) (principal, bool) {
+	p, ok := r.Context().Value(principalContextKey{}).(principal)
+	return p, ok
+}
+
+// requestUserID mengambil user yang sedang mengakses: dari principal yang dipasang
+// RequireAuth (session cookie atau personal access token), atau langsung dari
+// session cookie untuk route publik. Service API key tidak memiliki user.
+func requestUserID(r *http.Request) (int, bool) {
+	if p, ok := requestPrincipal(r); ok {
+		return p.UserID, p.UserID != 0
+	}
+
+	session, err := store.Get(r, "attendance-session")
+	if err != nil {
+		return 0, false
+	}
 
-		userID, ok := session.Values["user_id"]
-		if !ok || userID == nil {
+	userID, ok := session.Values["user_id"].(int)
+	return userID, ok
+}
+
+// bearerToken mengambil token dari header Authorization: Bearer <token>
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return "", false
+	}
+
+	token := strings.TrimSpace(header[7:])
+	return token, token != ""
+}
+
+func CheckAuthentication() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(r)
+		if !ok {
 			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
 			return
 		}
 
-		users, err := controllers.CheckAuthentication(userID.(int))
+		users, err := controllers.CheckAuthentication(userID)
 		if err != nil {
-			log.Printf("Authentication check error: %v", err)
+			slog.ErrorContext(r.Context(), "Authentication check error", "error", err)
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
+		// Token CSRF hanya relevan untuk session cookie (bukan API key)
+		if p, ok := requestPrincipal(r); ok && p.APIKeyID == 0 && users.Authenticated {
+			users.CSRFToken, err = csrfToken(w, r)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "CSRF token error", "error", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(users); err != nil {
-			log.Printf("JSON encoding error: %v", err)
+			slog.ErrorContext(r.Context(), "JSON encoding error", "error", err)
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
 	}
 }
 
-func RequireHR(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Ambil session
-		session, err := store.Get(r, "attendance-session")
-		if err != nil {
-			log.Printf("Session error: %v", err)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
+// RequireRole adalah middleware yang memastikan user memiliki role tertentu atau
+// role di atasnya dalam hirarki (Admin > HR > Employee). Contoh: RequireRole("HR")
+// juga meloloskan Admin karena role Admin mewarisi HR.
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := requestUserID(r)
+			if !ok {
+				http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+				return
+			}
 
-		// cek apakah ada session user_id
-		userID, ok := session.Values["user_id"]
-		if !ok || userID == nil {
-			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
-			return
-		}
+			allowed, err := controllers.UserHasRole(userID, role)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Role check error", "error", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				return
+			}
 
-		// Cek apakah user adalah HR
-		authResponse, err := controllers.CheckAuthentication(userID.(int))
-		if err != nil {
-			log.Printf("Authentication check error: %v", err)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
+			if !allowed {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(map[string]string{"error": "Forbidden - " + role + " access only"})
+				return
+			}
 
-		if !authResponse.Authenticated || authResponse.User == nil || authResponse.User.Role != "HR" {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusForbidden)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Forbidden - HR access only"})
-			return
-		}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
 
-		// jika user adalah dari department HR
-		next.ServeHTTP(w, r)
-	})
+// RequirePermission adalah middleware yang memastikan user yang login memiliki
+// permission tertentu (contoh: "attendance.report.read", "users.write").
+// Dipasang per route di main.go.
+func RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return permissionGate{permission: permission, next: next}
+	}
+}
+
+// RequireAPIKeyScope hanya mengecek permission untuk API key; request dengan
+// session login diteruskan dan otorisasinya dilakukan oleh handler (misalnya
+// pemilik token absensi). Dipasang paling luar pada route yang boleh diakses
+// API key tetapi juga dipakai user biasa tanpa permission tersebut.
+func RequireAPIKeyScope(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return permissionGate{permission: permission, apiKeyOnly: true, next: next}
+	}
+}
+
+// permissionGate adalah handler hasil RequirePermission dan RequireAPIKeyScope.
+// RequireAuth mengenali tipe ini untuk menentukan route mana yang boleh diakses
+// dengan API key.
+type permissionGate struct {
+	permission string
+	apiKeyOnly bool
+	next       http.Handler
+}
+
+func (g permissionGate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p, ok := requestPrincipal(r)
+	if !ok {
+		http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+		return
+	}
+
+	if g.apiKeyOnly && p.APIKeyID == 0 {
+		g.next.ServeHTTP(w, r)
+		return
+	}
+
+	allowed, err := principalHasPermission(p, g.permission)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Permission check error", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	if !allowed {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Forbidden - missing permission " + g.permission})
+		return
+	}
+
+	g.next.ServeHTTP(w, r)
+}
+
+// routeHasPermissionGate mengecek apakah handler route yang cocok dibungkus
+// langsung oleh RequirePermission atau RequireAPIKeyScope
+func routeHasPermissionGate(r *http.Request) bool {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return false
+	}
+	_, ok := route.GetHandler().(permissionGate)
+	return ok
+}
+
+// principalHasPermission mengecek permission milik principal. API key hanya boleh
+// memakai permission yang ada di scope-nya. Personal access token juga dibatasi
+// permission pemiliknya saat ini.
+func principalHasPermission(p principal, permission string) (bool, error) {
+	if p.APIKeyID != 0 && !slices.Contains(p.Permissions, permission) {
+		return false, nil
+	}
+	if p.UserID != 0 {
+		return controllers.UserHasPermission(p.UserID, permission)
+	}
+	return true, nil
 }
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func withPrincipal(p principal) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalContextKey{}, p)))
+		})
+	}
+}
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestRouteHasPermissionGate(t *testing.T) {
+	gated := map[string]bool{}
+
+	r := mux.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			gated[req.URL.Path] = routeHasPermissionGate(req)
+			next.ServeHTTP(w, req)
+		})
+	})
+	r.Handle("/permission", RequirePermission("departments.read")(okHandler()))
+	r.Handle("/scope", RequireAPIKeyScope("attendance.scan")(okHandler()))
+	r.Handle("/plain", okHandler())
+	r.Handle("/wrapped", RequireSession(RequirePermission("departments.read")(okHandler())))
+
+	want := map[string]bool{"/permission": true, "/scope": true, "/plain": false, "/wrapped": false}
+	for path := range want {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	for path, expected := range want {
+		if gated[path] != expected {
+			t.Errorf("routeHasPermissionGate(%s) = %v, want %v", path, gated[path], expected)
+		}
+	}
+}
+
+func TestPermissionGateAPIKeyScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		p      principal
+		gate   func(string) func(http.Handler) http.Handler
+		wantOK bool
+	}{
+		{"API key dengan scope", principal{APIKeyID: 1, Permissions: []string{"attendance.scan"}}, RequirePermission, true},
+		{"API key tanpa scope", principal{APIKeyID: 1, Permissions: []string{"departments.read"}}, RequirePermission, false},
+		{"scope gate API key tanpa scope", principal{APIKeyID: 1, Permissions: []string{"departments.read"}}, RequireAPIKeyScope, false},
+		{"scope gate API key dengan scope", principal{APIKeyID: 1, Permissions: []string{"attendance.scan"}}, RequireAPIKeyScope, true},
+		{"scope gate session login", principal{UserID: 7}, RequireAPIKeyScope, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := mux.NewRouter()
+			r.Use(withPrincipal(tt.p))
+			r.Handle("/scan", tt.gate("attendance.scan")(okHandler()))
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/scan", nil))
+
+			if ok := rec.Code == http.StatusOK; ok != tt.wantOK {
+				t.Errorf("status = %d, want ok = %v", rec.Code, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"backend/controllers"
+	"backend/oidc"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// Batas waktu antara redirect ke identity provider dan callback
+const oidcLoginTTL = 10 * time.Minute
+
+// Provider OIDC di-discover saat pertama dipakai, sehingga server tetap bisa
+// start walaupun identity provider sedang tidak bisa dihubungi
+var oidcState struct {
+	sync.Mutex
+	provider *oidc.Provider
+}
+
+func oidcProvider() (*oidc.Provider, error) {
+	oidcState.Lock()
+	defer oidcState.Unlock()
+
+	if oidcState.provider != nil {
+		return oidcState.provider, nil
+	}
+
+	provider, err := oidc.Discover(
+		os.Getenv("OIDC_ISSUER_URL"),
+		os.Getenv("OIDC_CLIENT_ID"),
+		os.Getenv("OIDC_CLIENT_SECRET"),
+		os.Getenv("OIDC_REDIRECT_URL"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	oidcState.provider = provider
+	return provider, nil
+}
+
+func oidcEnabled() bool {
+	return os.Getenv("OIDC_ISSUER_URL") != "" && os.Getenv("OIDC_CLIENT_ID") != ""
+}
+
+// OIDCLogin memulai login SSO: menyimpan state, nonce dan PKCE verifier di
+// session lalu mengarahkan browser ke identity provider
+func OIDCLogin() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !oidcEnabled() {
+			http.Error(w, "Login SSO tidak dikonfigurasi", http.StatusNotFound)
+			return
+		}
+
+		provider, err := oidcProvider()
+		if err != nil {
+			slog.ErrorContext(r.Context(), "OIDC discovery error", "error", err)
+			http.Error(w, "Identity provider tidak bisa dihubungi", http.StatusBadGateway)
+			return
+		}
+
+		state, errState := oidc.RandomString()
+		nonce, errNonce := oidc.RandomString()
+		verifier, challenge, errPKCE := oidc.NewPKCE()
+		if err := errors.Join(errState, errNonce, errPKCE); err != nil {
+			slog.ErrorContext(r.Context(), "OIDC random error", "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		session, err := store.Get(r, "attendance-session")
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Session error", "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		session.Values["oidc_state"] = state
+		session.Values["oidc_nonce"] = nonce
+		session.Values["oidc_verifier"] = verifier
+		session.Values["oidc_expires"] = time.Now().Add(oidcLoginTTL).Unix()
+		if err := session.Save(r, w); err != nil {
+			slog.ErrorContext(r.Context(), "Failed to save session", "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, provider.AuthCodeURL(state, nonce, challenge), http.StatusFound)
+	}
+}
+
+// OIDCCallback menerima authorization code dari identity provider, memverifikasi
+// ID token, mencocokkan user lalu membuat session. Browser selalu diarahkan
+// kembali ke frontend; jika gagal, dengan parameter sso_error.
+func OIDCCallback() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !oidcEnabled() {
+			http.Error(w, "Login SSO tidak dikonfigurasi", http.StatusNotFound)
+			return
+		}
+
+		session, err := store.Get(r, "attendance-session")
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Session error", "error", err)
+			redirectSSOError(w, r, "server_error")
+			return
+		}
+
+		state, _ := session.Values["oidc_state"].(string)
+		nonce, _ := session.Values["oidc_nonce"].(string)
+		verifier, _ := session.Values["oidc_verifier"].(string)
+		expires, _ := session.Values["oidc_expires"].(int64)
+
+		// state hanya boleh dipakai sekali
+		delete(session.Values, "oidc_state")
+		delete(session.Values, "oidc_nonce")
+		delete(session.Values, "oidc_verifier")
+		delete(session.Values, "oidc_expires")
+		if err := session.Save(r, w); err != nil {
+			slog.ErrorContext(r.Context(), "Failed to save session", "error", err)
+		}
+
+		query := r.URL.Query()
+		if state == "" || query.Get("state") != state || time.Now().Unix() > expires {
+			redirectSSOError(w, r, "invalid_state")
+			return
+		}
+		if providerError := query.Get("error"); providerError != "" {
+			slog.WarnContext(r.Context(), "OIDC provider returned error", "provider_error", providerError, "description", query.Get("error_description"))
+			redirectSSOError(w, r, "provider_error")
+			return
+		}
+
+		provider, err := oidcProvider()
+		if err != nil {
+			slog.ErrorContext(r.Context(), "OIDC discovery error", "error", err)
+			redirectSSOError(w, r, "provider_error")
+			return
+		}
+
+		idToken, err := provider.Exchange(query.Get("code"), verifier)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "OIDC code exchange error", "error", err)
+			redirectSSOError(w, r, "provider_error")
+			return
+		}
+
+		claims, err := provider.VerifyIDToken(idToken, nonce)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "OIDC ID token rejected", "error", err)
+			redirectSSOError(w, r, "invalid_token")
+			return
+		}
+
+		userID, status, err := controllers.ResolveOIDCUser(claims.Subject, claims.Email, claims.Name, claims.EmailVerified)
+		switch {
+		case errors.Is(err, controllers.ErrOIDCEmailNotVerified):
+			redirectSSOError(w, r, "email_not_verified")
+			return
+		case errors.Is(err, controllers.ErrOIDCUserNotFound):
+			redirectSSOError(w, r, "user_not_found")
+			return
+		case errors.Is(err, controllers.ErrOIDCSubjectMismatch):
+			redirectSSOError(w, r, "account_conflict")
+			return
+		case err != nil:
+			slog.ErrorContext(r.Context(), "OIDC user resolve error", "error", err)
+			redirectSSOError(w, r, "server_error")
+			return
+		}
+
+		if status != controllers.UserStatusActive {
+			redirectSSOError(w, r, "account_inactive")
+			return
+		}
+
+		// 2FA lokal tetap berlaku untuk user yang mengaktifkannya atau role-nya mewajibkan
+		twoFactorEnabled, twoFactorRequired, err := controllers.TwoFactorStatus(userID)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "2FA status error", "error", err)
+			redirectSSOError(w, r, "server_error")
+			return
+		}
+
+		if twoFactorEnabled || twoFactorRequired {
+			enrollmentRequired := !twoFactorEnabled
+			if err := startTwoFactorLogin(w, r, userID, claims.Email, enrollmentRequired); err != nil {
+				slog.ErrorContext(r.Context(), "Failed to save session", "error", err)
+				redirectSSOError(w, r, "server_error")
+				return
+			}
+
+			next := "/login/2fa"
+			if enrollmentRequired {
+				next = "/login/2fa/setup"
+			}
+			http.Redirect(w, r, controllers.AppURL(next), http.StatusFound)
+			return
+		}
+
+		if err := completeLogin(w, r, userID, claims.Email); err != nil {
+			slog.ErrorContext(r.Context(), "Failed to save session", "error", err)
+			redirectSSOError(w, r, "server_error")
+			return
+		}
+
+		http.Redirect(w, r, controllers.AppURL("/"), http.StatusFound)
+	}
+}
+
+func redirectSSOError(w http.ResponseWriter, r *http.Request, code string) {
+	http.Redirect(w, r, controllers.AppURL("/login?sso_error="+url.QueryEscape(code)), http.StatusFound)
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"backend/controllers"
+	"backend/database"
+	"backend/oidc"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+const testOIDCRedirectURL = "http://app.test/api/login/oidc/callback"
+
+// startMockOIDC menjalankan mock identity provider dan mengarahkan konfigurasi
+// OIDC server ke provider tersebut selama test
+func startMockOIDC(t *testing.T) {
+	t.Helper()
+
+	mock, err := oidc.NewMockProvider("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(mock.Handler())
+	mock.Issuer = "http://" + srv.Listener.Addr().String()
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	t.Setenv("OIDC_ISSUER_URL", mock.Issuer)
+	t.Setenv("OIDC_CLIENT_ID", "attendance-test")
+	t.Setenv("OIDC_CLIENT_SECRET", "")
+	t.Setenv("OIDC_REDIRECT_URL", testOIDCRedirectURL)
+	t.Setenv("OIDC_JIT_PROVISIONING", "false")
+	t.Setenv("APP_BASE_URL", "http://frontend.test")
+
+	resetOIDCProvider := func() {
+		oidcState.Lock()
+		oidcState.provider = nil
+		oidcState.Unlock()
+	}
+	resetOIDCProvider()
+	t.Cleanup(resetOIDCProvider)
+}
+
+// startOIDCLogin memanggil OIDCLogin, lalu login di mock provider sebagai
+// email. Mengembalikan request callback (dengan cookie session) yang akan
+// dikirim browser.
+func startOIDCLogin(t *testing.T, email string) *http.Request {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	OIDCLogin()(rec, httptest.NewRequest(http.MethodGet, "/api/login/oidc", nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("OIDCLogin status = %d, want 302: %s", rec.Code, rec.Body.String())
+	}
+	cookie := sessionCookie(t, rec.Result().Cookies())
+
+	authURL, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	form := authURL.Query()
+	form.Set("email", email)
+	form.Set("email_verified", "true")
+	authURL.RawQuery = ""
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.PostForm(authURL.String(), form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("mock authorize status = %d, want 302", resp.StatusCode)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, resp.Header.Get("Location"), nil)
+	req.AddCookie(cookie)
+	return req
+}
+
+func callOIDCCallback(req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	OIDCCallback()(rec, req)
+	return rec
+}
+
+func TestOIDCLoginFlowCreatesSession(t *testing.T) {
+	requireTestDB(t)
+	startMockOIDC(t)
+
+	userID, email := createTestUser(t, "oidc-flow")
+
+	rec := callOIDCCallback(startOIDCLogin(t, email))
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != controllers.AppURL("/") {
+		t.Fatalf("callback = %d %s, want 302 ke %s", rec.Code, rec.Header().Get("Location"), controllers.AppURL("/"))
+	}
+
+	cookie := sessionCookie(t, rec.Result().Cookies())
+	req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+	req.AddCookie(cookie)
+	session, err := store.Get(req, "attendance-session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := session.Values["user_id"].(int); got != userID {
+		t.Fatalf("session user_id = %d, want %d", got, userID)
+	}
+
+	var subject string
+	if err := database.DB.QueryRow(`SELECT oidc_subject FROM users WHERE id = $1`, userID).Scan(&subject); err != nil {
+		t.Fatal(err)
+	}
+	if subject != "mock|"+email {
+		t.Errorf("oidc_subject = %q, want %q", subject, "mock|"+email)
+	}
+}
+
+func TestOIDCCallbackRejectsInvalidState(t *testing.T) {
+	requireTestDB(t)
+	startMockOIDC(t)
+
+	_, email := createTestUser(t, "oidc-state")
+
+	tests := []struct {
+		name   string
+		mutate func(req *http.Request) *http.Request
+	}{
+		{"state diubah", func(req *http.Request) *http.Request {
+			query := req.URL.Query()
+			query.Set("state", "state-lain")
+			tampered := httptest.NewRequest(http.MethodGet, req.URL.Path+"?"+query.Encode(), nil)
+			for _, c := range req.Cookies() {
+				tampered.AddCookie(c)
+			}
+			return tampered
+		}},
+		{"tanpa cookie session", func(req *http.Request) *http.Request {
+			return httptest.NewRequest(http.MethodGet, req.URL.String(), nil)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := callOIDCCallback(tt.mutate(startOIDCLogin(t, email)))
+			want := controllers.AppURL("/login?sso_error=invalid_state")
+			if rec.Header().Get("Location") != want {
+				t.Fatalf("Location = %q, want %q", rec.Header().Get("Location"), want)
+			}
+		})
+	}
+}
+
+func TestOIDCCallbackStateIsSingleUse(t *testing.T) {
+	requireTestDB(t)
+	startMockOIDC(t)
+
+	_, email := createTestUser(t, "oidc-replay")
+
+	req := startOIDCLogin(t, email)
+	if rec := callOIDCCallback(req); rec.Header().Get("Location") != controllers.AppURL("/") {
+		t.Fatalf("callback pertama gagal: %s", rec.Header().Get("Location"))
+	}
+
+	replay := httptest.NewRequest(http.MethodGet, req.URL.String(), nil)
+	for _, c := range req.Cookies() {
+		replay.AddCookie(c)
+	}
+	rec := callOIDCCallback(replay)
+	if want := controllers.AppURL("/login?sso_error=invalid_state"); rec.Header().Get("Location") != want {
+		t.Fatalf("callback ulang: Location = %q, want %q", rec.Header().Get("Location"), want)
+	}
+}
+
+func TestOIDCCallbackUnknownUser(t *testing.T) {
+	requireTestDB(t)
+	startMockOIDC(t)
+
+	rec := callOIDCCallback(startOIDCLogin(t, "tidak-terdaftar@test.local"))
+	if want := controllers.AppURL("/login?sso_error=user_not_found"); rec.Header().Get("Location") != want {
+		t.Fatalf("Location = %q, want %q", rec.Header().Get("Location"), want)
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"backend/controllers"
+	"backend/types"
+	"backend/utils"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// ChangeMyPassword mengganti password user yang sedang login
+func ChangeMyPassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(r)
+		if !ok {
+			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+			return
+		}
+
+		var changeReq types.ChangePasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&changeReq); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		// Session yang sedang dipakai tetap berlaku, session lain dicabut
+		err := controllers.ChangePassword(userID, changeReq.CurrentPassword, changeReq.NewPassword, currentSessionToken(r), utils.ClientIP(r))
+		if errors.Is(err, controllers.ErrWrongPassword) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if errors.Is(err, controllers.ErrPasswordManagedByLDAP) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if isPasswordInputError(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error changing password", "user_id", userID, "error", err)
+			http.Error(w, "Failed to change password", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "Password changed successfully",
+		})
+	}
+}
+
+// ForgotPassword mengirim email reset password. Response selalu sama, baik email
+// terdaftar maupun tidak.
+func ForgotPassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var forgotReq types.ForgotPasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&forgotReq); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := controllers.RequestPasswordReset(forgotReq.Email, utils.ClientIP(r)); err != nil {
+			slog.ErrorContext(r.Context(), "Error requesting password reset", "error", err)
+			http.Error(w, "Failed to process password reset request", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "Jika email terdaftar, tautan reset password telah dikirim",
+		})
+	}
+}
+
+// ResetPassword mengganti password memakai token dari email reset password
+func ResetPassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var resetReq types.ResetPasswordRequest
+		if err := json.NewDecoder(r.Body).Decode(&resetReq); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		err := controllers.ResetPassword(resetReq.Token, resetReq.NewPassword)
+		if errors.Is(err, controllers.ErrInvalidResetToken) || isPasswordInputError(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error resetting password", "error", err)
+			http.Error(w, "Failed to reset password", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "Password reset successfully",
+		})
+	}
+}
+
+func isPasswordInputError(err error) bool {
+	return errors.Is(err, controllers.ErrWeakPassword) ||
+		errors.Is(err, controllers.ErrPasswordUnchanged) ||
+		errors.Is(err, controllers.ErrPasswordIsRequired)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"backend/ratelimit"
+	"backend/utils"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// rateLimiter adalah backend rate limiter yang dipakai semua route,
+// default in-memory. Ganti lewat SetRateLimiter sebelum router dibuat.
+var rateLimiter ratelimit.Limiter = ratelimit.NewMemoryLimiter()
+
+// SetRateLimiter mengganti backend rate limiter (misalnya shared store untuk
+// deployment dengan beberapa instance)
+func SetRateLimiter(limiter ratelimit.Limiter) {
+	rateLimiter = limiter
+}
+
+// RateLimitIP membatasi request per alamat IP client
+func RateLimitIP(rule ratelimit.Rule) func(http.Handler) http.Handler {
+	return rateLimit(rule, func(r *http.Request) string {
+		return "ip:" + utils.ClientIP(r)
+	})
+}
+
+// RateLimitUser membatasi request per user yang login (session maupun personal
+// access token). Service API key (tanpa user) dibatasi per key, dan request
+// tanpa principal dibatasi per IP. Harus dipasang setelah RequireAuth.
+func RateLimitUser(rule ratelimit.Rule) func(http.Handler) http.Handler {
+	return rateLimit(rule, func(r *http.Request) string {
+		p, ok := requestPrincipal(r)
+		switch {
+		case ok && p.UserID != 0:
+			return "user:" + strconv.Itoa(p.UserID)
+		case ok && p.APIKeyID != 0:
+			return "apikey:" + strconv.Itoa(p.APIKeyID)
+		}
+		return "ip:" + utils.ClientIP(r)
+	})
+}
+
+func rateLimit(rule ratelimit.Rule, key func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			allowed, retryAfter, err := rateLimiter.Allow(rule.Name+":"+key(r), rule)
+			if err != nil {
+				// Gangguan backend rate limiter tidak boleh membuat API tidak bisa dipakai
+				slog.ErrorContext(r.Context(), "Rate limiter error", "rule", rule.Name, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !allowed {
+				seconds := int(retryAfter.Seconds())
+				if seconds < 1 {
+					seconds = 1
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(seconds))
+				http.Error(w, "Terlalu banyak request, silakan coba lagi nanti", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
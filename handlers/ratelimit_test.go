@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"backend/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRateLimitUserKeys(t *testing.T) {
+	SetRateLimiter(ratelimit.NewMemoryLimiter())
+	t.Cleanup(func() { SetRateLimiter(ratelimit.NewMemoryLimiter()) })
+
+	limited := RateLimitUser(ratelimit.PerMinute("test-user-key", 1))(okHandler())
+	call := func(p principal) int {
+		rec := httptest.NewRecorder()
+		withPrincipal(p)(limited).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec.Code
+	}
+
+	// Setiap service API key (UserID 0) punya bucket sendiri, tidak berbagi
+	// bucket "user:0" dengan key lain
+	if code := call(principal{APIKeyID: 1}); code != http.StatusOK {
+		t.Fatalf("API key 1 request pertama = %d", code)
+	}
+	if code := call(principal{APIKeyID: 2}); code != http.StatusOK {
+		t.Fatalf("API key 2 request pertama = %d, want 200", code)
+	}
+	if code := call(principal{APIKeyID: 1}); code != http.StatusTooManyRequests {
+		t.Fatalf("API key 1 request kedua = %d, want 429", code)
+	}
+
+	// Personal access token tetap berbagi bucket dengan session user pemiliknya
+	if code := call(principal{UserID: 7}); code != http.StatusOK {
+		t.Fatalf("session user 7 = %d", code)
+	}
+	if code := call(principal{UserID: 7, APIKeyID: 3}); code != http.StatusTooManyRequests {
+		t.Fatalf("token milik user 7 = %d, want 429", code)
+	}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"backend/controllers"
+	"backend/types"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+func GetReportSubscriptions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		subscriptions, err := controllers.GetReportSubscriptions()
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error getting report subscriptions", "error", err)
+			http.Error(w, "Failed to get report subscriptions", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(subscriptions)
+	}
+}
+
+func CreateReportSubscription() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		createdBy, ok := requestUserID(r)
+		if !ok {
+			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+			return
+		}
+
+		var createReq types.CreateReportSubscriptionRequest
+		if err := json.NewDecoder(r.Body).Decode(&createReq); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		subscription, err := controllers.CreateReportSubscription(createReq, createdBy)
+		if errors.Is(err, controllers.ErrInvalidReportSubscription) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error creating report subscription", "error", err)
+			http.Error(w, "Failed to create report subscription", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(subscription)
+	}
+}
+
+func DeleteReportSubscription(subscriptionID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := controllers.DeleteReportSubscription(subscriptionID)
+		if errors.Is(err, controllers.ErrReportSubscriptionNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error deleting report subscription", "subscription_id", subscriptionID, "error", err)
+			http.Error(w, "Failed to delete report subscription", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func SendReportSubscription(subscriptionID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := controllers.SendReportSubscriptionNow(subscriptionID)
+		if errors.Is(err, controllers.ErrReportSubscriptionNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, controllers.ErrReportNotWorkingDay) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error sending report subscription", "subscription_id", subscriptionID, "error", err)
+			http.Error(w, "Failed to send report", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "Report sent",
+		})
+	}
+}
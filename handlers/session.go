@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"backend/controllers"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// GetMySessions mengembalikan daftar session aktif milik user yang sedang login
+func GetMySessions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(r)
+		if !ok {
+			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+			return
+		}
+
+		sessions, err := controllers.GetUserSessions(userID, currentSessionToken(r))
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error getting sessions", "user_id", userID, "error", err)
+			http.Error(w, "Failed to get sessions", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(sessions); err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// RevokeMySession mencabut salah satu session milik user yang sedang login
+func RevokeMySession(sessionID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(r)
+		if !ok {
+			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+			return
+		}
+
+		err := controllers.RevokeUserSession(userID, sessionID)
+		if errors.Is(err, controllers.ErrSessionNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error revoking session", "session_id", sessionID, "user_id", userID, "error", err)
+			http.Error(w, "Failed to revoke session", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// RevokeMySessions mencabut semua session milik user yang sedang login.
+// Dengan ?keep_current=true, session yang sedang dipakai tidak ikut dicabut.
+func RevokeMySessions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(r)
+		if !ok {
+			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+			return
+		}
+
+		exceptToken := ""
+		if r.URL.Query().Get("keep_current") == "true" {
+			exceptToken = currentSessionToken(r)
+		}
+
+		revoked, err := controllers.RevokeUserSessions(userID, exceptToken)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error revoking sessions", "user_id", userID, "error", err)
+			http.Error(w, "Failed to revoke sessions", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"revoked": revoked,
+		})
+	}
+}
+
+// RevokeUserSessions memaksa logout user lain dari semua perangkat (oleh HR)
+func RevokeUserSessions(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		revoked, err := controllers.RevokeUserSessions(userID, "")
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error revoking sessions", "user_id", userID, "error", err)
+			http.Error(w, "Failed to revoke sessions", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"revoked": revoked,
+		})
+	}
+}
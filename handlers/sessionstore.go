@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"backend/controllers"
+	"backend/utils"
+	"bytes"
+	"encoding/gob"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+)
+
+// anonymousSessionMaxAge adalah umur session sebelum login (hanya berisi token
+// CSRF atau status 2FA yang tertunda), jauh lebih pendek dari session login
+// agar request tanpa cookie tidak menumpuk session panjang di database
+const anonymousSessionMaxAge = 15 * time.Minute
+
+// dbStore adalah implementasi sessions.Store yang menyimpan data session di
+// database (tabel user_sessions). Cookie hanya berisi token session yang
+// ditandatangani, sehingga session bisa dicabut dari server kapan saja
+// (logout, user dinonaktifkan, atau dicabut oleh user sendiri).
+type dbStore struct {
+	Codecs  []securecookie.Codec
+	Options *sessions.Options
+}
+
+func newDBStore(keyPairs ...[]byte) *dbStore {
+	return &dbStore{
+		Codecs: securecookie.CodecsFromPairs(keyPairs...),
+		Options: &sessions.Options{
+			Path:   "/",
+			MaxAge: 86400 * 30,
+		},
+	}
+}
+
+// Get mengambil session dari registry request (hanya dimuat sekali per request)
+func (s *dbStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New memuat session berdasarkan cookie. Cookie yang tidak valid atau session
+// yang sudah dicabut/kedaluwarsa menghasilkan session baru yang kosong.
+func (s *dbStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	opts := *s.Options
+	session.Options = &opts
+	session.IsNew = true
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+
+	var token string
+	if err := securecookie.DecodeMulti(name, cookie.Value, &token, s.Codecs...); err != nil {
+		return session, nil
+	}
+
+	data, found, err := controllers.LoadSession(token)
+	if err != nil {
+		return session, err
+	}
+	if !found {
+		return session, nil
+	}
+
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&session.Values); err != nil {
+		return session, nil
+	}
+
+	session.ID = token
+	session.IsNew = false
+	return session, nil
+}
+
+// Save menyimpan session ke database dan mengirim cookie berisi token-nya.
+// MaxAge < 0 berarti session dihapus (logout).
+func (s *dbStore) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if err := controllers.EndSession(session.ID); err != nil {
+				return err
+			}
+		}
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	var data bytes.Buffer
+	if err := gob.NewEncoder(&data).Encode(session.Values); err != nil {
+		return err
+	}
+
+	userID, _ := session.Values["user_id"].(int)
+	options := *session.Options
+	if userID == 0 && time.Duration(options.MaxAge)*time.Second > anonymousSessionMaxAge {
+		options.MaxAge = int(anonymousSessionMaxAge.Seconds())
+	}
+	maxAge := time.Duration(options.MaxAge) * time.Second
+
+	if session.ID == "" {
+		token, err := controllers.CreateSession(userID, data.Bytes(), r.UserAgent(), utils.ClientIP(r), maxAge)
+		if err != nil {
+			return err
+		}
+		session.ID = token
+	} else if err := controllers.UpdateSession(session.ID, userID, data.Bytes(), maxAge); err != nil {
+		return err
+	}
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.Codecs...)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, &options))
+	return nil
+}
+
+// Renew mencabut session lama dan membuat token baru saat Save berikutnya
+// (mencegah session fixation setelah login). Data session tetap dipertahankan.
+func (s *dbStore) Renew(session *sessions.Session) error {
+	if session.ID == "" {
+		return nil
+	}
+	if err := controllers.EndSession(session.ID); err != nil {
+		return err
+	}
+	session.ID = ""
+	return nil
+}
+
+// currentSessionToken mengembalikan token session request ini (kosong jika belum ada)
+func currentSessionToken(r *http.Request) string {
+	session, err := store.Get(r, "attendance-session")
+	if err != nil {
+		return ""
+	}
+	return session.ID
+}
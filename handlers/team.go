@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"backend/controllers"
+	"backend/types"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// Endpoint /api/team/* selalu dibatasi pada tim milik user yang sedang login
+// (karyawan di departemen yang dipimpinnya), tanpa perlu parameter manager.
+
+func GetTeamTodayAttendance() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		managerID, ok := requestUserID(r)
+		if !ok {
+			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+			return
+		}
+
+		attendances, err := controllers.GetTeamTodayAttendance(managerID)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error getting team attendance", "manager_id", managerID, "error", err)
+			http.Error(w, "Failed to get team attendance", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(attendances); err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func GetTeamMonthlyAttendance() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		managerID, ok := requestUserID(r)
+		if !ok {
+			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+			return
+		}
+
+		month, year, err := parseMonthYear(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		attendances, err := controllers.GetTeamMonthlyAttendance(managerID, month, year)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error getting team monthly attendance", "manager_id", managerID, "error", err)
+			http.Error(w, "Failed to get team monthly attendance", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(attendances); err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func GetTeamEmployeeMonthlyAttendance() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		managerID, ok := requestUserID(r)
+		if !ok {
+			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+			return
+		}
+
+		userID, err := parseQueryUserID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		month, year, err := parseMonthYear(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		attendance, err := controllers.GetTeamEmployeeMonthlyAttendance(managerID, userID, month, year)
+		if errors.Is(err, controllers.ErrNotTeamMember) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error getting team member attendance", "member_id", userID, "manager_id", managerID, "error", err)
+			http.Error(w, "Failed to get employee monthly attendance", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(attendance); err != nil {
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func GetTeamLeaveRequests() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reviewerID, ok := requestUserID(r)
+		if !ok {
+			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+			return
+		}
+
+		leaves, err := controllers.GetReviewableLeaveRequests(reviewerID, r.URL.Query().Get("status"))
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error getting leave requests", "reviewer_id", reviewerID, "error", err)
+			http.Error(w, "Failed to get leave requests", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(leaves)
+	}
+}
+
+func GetTeamAttendanceCorrections() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reviewerID, ok := requestUserID(r)
+		if !ok {
+			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+			return
+		}
+
+		corrections, err := controllers.GetReviewableAttendanceCorrections(reviewerID, r.URL.Query().Get("status"))
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error getting attendance corrections", "reviewer_id", reviewerID, "error", err)
+			http.Error(w, "Failed to get attendance corrections", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(corrections)
+	}
+}
+
+func ReviewLeaveRequest(leaveID int) http.HandlerFunc {
+	return reviewRequestHandler(func(reviewerID int, req types.ApprovalRequest) error {
+		return controllers.ReviewLeaveRequest(leaveID, reviewerID, req)
+	})
+}
+
+func ReviewAttendanceCorrection(correctionID int) http.HandlerFunc {
+	return reviewRequestHandler(func(reviewerID int, req types.ApprovalRequest) error {
+		return controllers.ReviewAttendanceCorrection(correctionID, reviewerID, req)
+	})
+}
+
+// reviewRequestHandler berisi alur yang sama untuk approve/reject cuti dan koreksi absensi
+func reviewRequestHandler(review func(reviewerID int, req types.ApprovalRequest) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reviewerID, ok := requestUserID(r)
+		if !ok {
+			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+			return
+		}
+
+		var approvalReq types.ApprovalRequest
+		if err := json.NewDecoder(r.Body).Decode(&approvalReq); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		err := review(reviewerID, approvalReq)
+		switch {
+		case errors.Is(err, controllers.ErrInvalidApprovalStatus):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case errors.Is(err, controllers.ErrRequestNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, controllers.ErrNotApprover):
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		case errors.Is(err, controllers.ErrRequestAlreadyReviewed):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			slog.ErrorContext(r.Context(), "Error reviewing request", "reviewer_id", reviewerID, "error", err)
+			http.Error(w, "Failed to review request", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "Request " + approvalReq.Status,
+		})
+	}
+}
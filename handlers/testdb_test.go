@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"backend/database"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Sama seperti di package controllers: test yang membutuhkan PostgreSQL memakai
+// TEST_DATABASE_URL (sudah disiapkan dengan `go run ./seed`) dan di-skip jika
+// variabel tersebut tidak diset. Session store ikut diinisialisasi karena
+// session disimpan di database.
+var testDB struct {
+	once sync.Once
+	err  error
+}
+
+func requireTestDB(t *testing.T) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL tidak diset, test database di-skip")
+	}
+
+	testDB.once.Do(func() {
+		db, err := sql.Open("postgres", dsn)
+		if err == nil {
+			err = db.Ping()
+		}
+		if err == nil {
+			err = InitSessionStore(SessionConfig{Secret: "test-session-secret-0123456789abcdef"})
+		}
+		testDB.err = err
+		database.DB = db
+	})
+	if testDB.err != nil {
+		t.Fatalf("gagal menyiapkan database test: %v", testDB.err)
+	}
+}
+
+// createTestUser membuat user aktif dengan email unik dan menghapusnya setelah test
+func createTestUser(t *testing.T, prefix string) (int, string) {
+	t.Helper()
+
+	email := fmt.Sprintf("%s-%d@test.local", prefix, time.Now().UnixNano())
+	var userID int
+	err := database.DB.QueryRow(`
+		INSERT INTO users (name, email, phone, position, department_id, status, password_hash)
+		VALUES ($1, $2, '', '', (SELECT id FROM departments ORDER BY id LIMIT 1), 'active', 'x')
+		RETURNING id
+	`, prefix, email).Scan(&userID)
+	if err != nil {
+		t.Fatalf("gagal membuat user test: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if _, err := database.DB.Exec(`DELETE FROM users WHERE id = $1`, userID); err != nil {
+			t.Errorf("gagal menghapus user test %s: %v", email, err)
+		}
+	})
+	return userID, email
+}
+
+// sessionCookie mengambil cookie session dari response
+func sessionCookie(t *testing.T, cookies []*http.Cookie) *http.Cookie {
+	t.Helper()
+
+	for _, c := range cookies {
+		if c.Name == "attendance-session" && c.Value != "" {
+			return c
+		}
+	}
+	t.Fatal("response tidak berisi cookie session")
+	return nil
+}
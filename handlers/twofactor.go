@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"backend/controllers"
+	"backend/types"
+	"backend/utils"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/sessions"
+)
+
+// Batas waktu antara langkah password dan langkah kode 2FA
+const pendingTwoFactorTTL = 5 * time.Minute
+
+// startTwoFactorLogin menyimpan user yang sudah lolos verifikasi password tetapi
+// belum menyelesaikan 2FA. Session ini tidak berisi user_id sehingga belum bisa
+// mengakses route yang dilindungi RequireAuth.
+func startTwoFactorLogin(w http.ResponseWriter, r *http.Request, userID int, email string, enrollmentRequired bool) error {
+	session, err := store.Get(r, "attendance-session")
+	if err != nil {
+		return err
+	}
+
+	delete(session.Values, "user_id")
+	session.Values["pending_2fa_user_id"] = userID
+	session.Values["pending_2fa_email"] = email
+	session.Values["pending_2fa_enroll"] = enrollmentRequired
+	session.Values["pending_2fa_expires"] = time.Now().Add(pendingTwoFactorTTL).Unix()
+
+	return session.Save(r, w)
+}
+
+func clearTwoFactorLogin(session *sessions.Session) {
+	delete(session.Values, "pending_2fa_user_id")
+	delete(session.Values, "pending_2fa_email")
+	delete(session.Values, "pending_2fa_enroll")
+	delete(session.Values, "pending_2fa_expires")
+}
+
+// pendingTwoFactor membaca login 2FA yang sedang berjalan dari session
+func pendingTwoFactor(r *http.Request) (userID int, email string, enroll bool, ok bool) {
+	session, err := store.Get(r, "attendance-session")
+	if err != nil {
+		return 0, "", false, false
+	}
+
+	userID, ok = session.Values["pending_2fa_user_id"].(int)
+	if !ok {
+		return 0, "", false, false
+	}
+
+	expires, _ := session.Values["pending_2fa_expires"].(int64)
+	if time.Now().Unix() > expires {
+		return 0, "", false, false
+	}
+
+	email, _ = session.Values["pending_2fa_email"].(string)
+	enroll, _ = session.Values["pending_2fa_enroll"].(bool)
+	return userID, email, enroll, true
+}
+
+// VerifyTwoFactorLogin adalah langkah kedua login: memverifikasi kode TOTP atau
+// recovery code, lalu menandai session sebagai terautentikasi penuh
+func VerifyTwoFactorLogin() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, email, enroll, ok := pendingTwoFactor(r)
+		if !ok || enroll {
+			http.Error(w, "Tidak ada login 2FA yang sedang berjalan, silakan login ulang", http.StatusUnauthorized)
+			return
+		}
+
+		var codeReq types.TwoFactorCodeRequest
+		if err := json.NewDecoder(r.Body).Decode(&codeReq); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		// Percobaan kode 2FA ikut dihitung oleh proteksi brute-force login
+		ip := utils.ClientIP(r)
+		if !checkLoginGuard(w, email, ip) {
+			return
+		}
+
+		err := controllers.VerifyTwoFactor(userID, codeReq.Code)
+		if errors.Is(err, controllers.ErrInvalidTwoFactorCode) {
+			if err := controllers.RecordLoginFailure(email, userID, ip); err != nil {
+				slog.ErrorContext(r.Context(), "Failed to record login failure", "error", err)
+			}
+			recordLoginFailureAudit(email, userID, ip)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "2FA verification error", "user_id", userID, "error", err)
+			http.Error(w, "Server error", http.StatusInternalServerError)
+			return
+		}
+
+		if err := completeLogin(w, r, userID, email); err != nil {
+			slog.ErrorContext(r.Context(), "Failed to save session", "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "Login successful",
+		})
+	}
+}
+
+// SetupTwoFactor membuat secret TOTP baru. Bisa dipanggil oleh user yang sudah
+// login, atau saat login jika role user mewajibkan 2FA yang belum diaktifkan.
+func SetupTwoFactor() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, _, ok := twoFactorEnrollmentUser(r)
+		if !ok {
+			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+			return
+		}
+
+		setup, err := controllers.BeginTwoFactorSetup(userID)
+		if errors.Is(err, controllers.ErrTwoFactorAlreadyEnabled) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "2FA setup error", "user_id", userID, "error", err)
+			http.Error(w, "Failed to set up 2FA", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(setup)
+	}
+}
+
+// EnableTwoFactor mengaktifkan 2FA dengan kode pertama dari aplikasi authenticator.
+// Jika dipanggil di tengah login (enrollment wajib), login langsung diselesaikan.
+func EnableTwoFactor() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, pendingEmail, ok := twoFactorEnrollmentUser(r)
+		if !ok {
+			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+			return
+		}
+
+		var codeReq types.TwoFactorCodeRequest
+		if err := json.NewDecoder(r.Body).Decode(&codeReq); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		codes, err := controllers.EnableTwoFactor(userID, codeReq.Code)
+		if errors.Is(err, controllers.ErrInvalidTwoFactorCode) || errors.Is(err, controllers.ErrTwoFactorNotSetup) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, controllers.ErrTwoFactorAlreadyEnabled) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "2FA enable error", "user_id", userID, "error", err)
+			http.Error(w, "Failed to enable 2FA", http.StatusInternalServerError)
+			return
+		}
+
+		if pendingEmail != "" {
+			if err := completeLogin(w, r, userID, pendingEmail); err != nil {
+				slog.ErrorContext(r.Context(), "Failed to save session", "error", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(types.RecoveryCodesResponse{RecoveryCodes: codes})
+	}
+}
+
+func DisableTwoFactor() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(r)
+		if !ok {
+			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+			return
+		}
+
+		var disableReq types.DisableTwoFactorRequest
+		if err := json.NewDecoder(r.Body).Decode(&disableReq); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		err := controllers.DisableTwoFactor(userID, disableReq.Password, disableReq.Code)
+		switch {
+		case errors.Is(err, controllers.ErrWrongPassword), errors.Is(err, controllers.ErrInvalidTwoFactorCode):
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		case errors.Is(err, controllers.ErrTwoFactorRequired):
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		case errors.Is(err, controllers.ErrTwoFactorNotEnabled):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			slog.ErrorContext(r.Context(), "2FA disable error", "user_id", userID, "error", err)
+			http.Error(w, "Failed to disable 2FA", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "2FA disabled",
+		})
+	}
+}
+
+func RegenerateRecoveryCodes() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestUserID(r)
+		if !ok {
+			http.Error(w, "Unauthorized - Please login first", http.StatusUnauthorized)
+			return
+		}
+
+		var codeReq types.TwoFactorCodeRequest
+		if err := json.NewDecoder(r.Body).Decode(&codeReq); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		codes, err := controllers.RegenerateRecoveryCodes(userID, codeReq.Code)
+		if errors.Is(err, controllers.ErrInvalidTwoFactorCode) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if errors.Is(err, controllers.ErrTwoFactorNotEnabled) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Recovery code error", "user_id", userID, "error", err)
+			http.Error(w, "Failed to regenerate recovery codes", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(types.RecoveryCodesResponse{RecoveryCodes: codes})
+	}
+}
+
+// UpdateRoleTwoFA mengatur kewajiban 2FA untuk sebuah role (khusus Admin)
+func UpdateRoleTwoFA(roleID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var updateReq types.UpdateRoleTwoFARequest
+		if err := json.NewDecoder(r.Body).Decode(&updateReq); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		actorID, _ := requestUserID(r)
+
+		err := controllers.SetRoleRequireTwoFA(roleID, updateReq.RequireTwoFA, actorID, utils.ClientIP(r))
+		if errors.Is(err, controllers.ErrRoleNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error updating 2FA requirement", "role_id", roleID, "error", err)
+			http.Error(w, "Failed to update role", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "Role 2FA requirement updated",
+		})
+	}
+}
+
+// twoFactorEnrollmentUser mengembalikan user yang boleh mendaftarkan 2FA: user yang
+// sudah login penuh, atau user di tengah login yang wajib enrollment. pendingEmail
+// hanya diisi untuk kasus kedua.
+func twoFactorEnrollmentUser(r *http.Request) (userID int, pendingEmail string, ok bool) {
+	if userID, ok := requestUserID(r); ok {
+		return userID, "", true
+	}
+
+	userID, email, enroll, ok := pendingTwoFactor(r)
+	if !ok || !enroll {
+		return 0, "", false
+	}
+	return userID, email, true
+}
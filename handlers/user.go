@@ -5,7 +5,9 @@ import (
 	"backend/types"
 	"backend/utils"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 )
 
@@ -46,8 +48,6 @@ func CreateUser() http.HandlerFunc {
 		}
 		defer r.Body.Close()
 
-		fmt.Println("Parsed form (handlers):", newUser)
-
 		// Validasi department_id
 		if newUser.DepartmentID == 0 {
 			http.Error(w, "department_id is required", http.StatusBadRequest)
@@ -61,17 +61,22 @@ func CreateUser() http.HandlerFunc {
 		}
 
 		// Panggil controller untuk membuat user baru
-		result, err := controllers.CreateUser(newUser)
+		createdBy, _ := requestUserID(r)
+		result, err := controllers.CreateUser(newUser, createdBy)
+		if errors.Is(err, controllers.ErrWeakPassword) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Gagal membuat pengguna baru: %v", err), http.StatusInternalServerError)
 			return
 		}
 
-		fmt.Println("User created successfully (handlers):", result)
+		slog.InfoContext(r.Context(), "User created", "created_user_id", result.ID)
 
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
-		// w.Write([]byte("Pengguna baru berhasil dibuat"))
-		// json.NewEncoder(w).Encode(result)
+		json.NewEncoder(w).Encode(result)
 
 	}
 }
@@ -147,19 +152,40 @@ func EditUser(userID int) http.HandlerFunc {
 			return
 		}
 
-		fmt.Println("Edit User Request (handlers):", editRequest)
+		actorID, _ := requestUserID(r)
 
 		// Panggil controller untuk edit user
-		result, err := controllers.EditUser(userID, editRequest)
+		result, err := controllers.EditUser(userID, editRequest, actorID, utils.ClientIP(r))
 		if err != nil {
 			http.Error(w, fmt.Sprintf("Gagal mengedit pengguna: %v", err), http.StatusInternalServerError)
 			return
 		}
 
-		utils.LogEditUser(userID, result)
-
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(result)
 	}
 }
+
+// UnlockUser membuka kunci akun yang terkunci karena terlalu banyak login gagal
+func UnlockUser(userID int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actorID, _ := requestUserID(r)
+
+		err := controllers.UnlockUser(userID, actorID, utils.ClientIP(r))
+		if errors.Is(err, controllers.ErrUserNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Gagal membuka kunci akun: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "Akun berhasil dibuka",
+		})
+	}
+}
@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"backend/controllers"
+	"backend/types"
+	"backend/utils"
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
 )
 
@@ -11,14 +14,44 @@ func GetWorkHours() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		workHours, err := controllers.GetWorkHours()
 		if err != nil {
-			log.Printf("Error getting work hours: %v", err)
+			slog.ErrorContext(r.Context(), "Error getting work hours", "error", err)
 			http.Error(w, "Failed to get work hours", http.StatusInternalServerError)
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(workHours); err != nil {
-			log.Printf("JSON encoding error: %v", err)
+			slog.ErrorContext(r.Context(), "JSON encoding error", "error", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func UpdateWorkHours() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var updateReq types.UpdateWorkHoursRequest
+		if err := json.NewDecoder(r.Body).Decode(&updateReq); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		actorID, _ := requestUserID(r)
+
+		workHours, err := controllers.UpdateWorkHours(updateReq, actorID, utils.ClientIP(r))
+		if errors.Is(err, controllers.ErrInvalidWorkHours) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error updating work hours", "error", err)
+			http.Error(w, "Failed to update work hours", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(workHours); err != nil {
+			slog.ErrorContext(r.Context(), "JSON encoding error", "error", err)
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
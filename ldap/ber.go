@@ -0,0 +1,223 @@
+package ldap
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Encoding BER (subset X.690 yang dipakai LDAP): tag satu byte dengan nomor
+// tag < 31, panjang bentuk pendek atau panjang.
+
+// Kelas dan flag pada byte tag
+const (
+	ClassUniversal   byte = 0x00
+	ClassApplication byte = 0x40
+	ClassContext     byte = 0x80
+	Constructed      byte = 0x20
+)
+
+// Tag universal
+const (
+	TagBoolean     byte = 0x01
+	TagInteger     byte = 0x02
+	TagOctetString byte = 0x04
+	TagNull        byte = 0x05
+	TagEnumerated  byte = 0x0a
+	TagSequence    byte = 0x10 | Constructed
+	TagSet         byte = 0x11 | Constructed
+)
+
+// Batas ukuran satu pesan agar server/klien nakal tidak bisa menghabiskan memori
+const maxPacketSize = 4 << 20
+
+var ErrPacketTooLarge = errors.New("ldap: pesan BER terlalu besar")
+
+// Packet adalah satu elemen BER. Elemen constructed berisi Children,
+// elemen primitive berisi Value.
+type Packet struct {
+	Tag      byte
+	Value    []byte
+	Children []*Packet
+}
+
+func (p *Packet) IsConstructed() bool {
+	return p.Tag&Constructed != 0
+}
+
+func NewConstructed(tag byte, children ...*Packet) *Packet {
+	return &Packet{Tag: tag | Constructed, Children: children}
+}
+
+func NewSequence(children ...*Packet) *Packet {
+	return &Packet{Tag: TagSequence, Children: children}
+}
+
+func NewOctetString(tag byte, value string) *Packet {
+	return &Packet{Tag: tag, Value: []byte(value)}
+}
+
+func NewBoolean(tag byte, value bool) *Packet {
+	if value {
+		return &Packet{Tag: tag, Value: []byte{0xff}}
+	}
+	return &Packet{Tag: tag, Value: []byte{0x00}}
+}
+
+// NewInteger membuat INTEGER/ENUMERATED dengan encoding two's complement minimal
+func NewInteger(tag byte, value int64) *Packet {
+	var b []byte
+	for {
+		b = append([]byte{byte(value)}, b...)
+		value >>= 8
+		if (value == 0 && b[0]&0x80 == 0) || (value == -1 && b[0]&0x80 != 0) {
+			break
+		}
+	}
+	return &Packet{Tag: tag, Value: b}
+}
+
+func (p *Packet) Append(children ...*Packet) *Packet {
+	p.Children = append(p.Children, children...)
+	return p
+}
+
+// Int membaca nilai INTEGER/ENUMERATED
+func (p *Packet) Int() int64 {
+	if len(p.Value) == 0 {
+		return 0
+	}
+	value := int64(int8(p.Value[0]))
+	for _, b := range p.Value[1:] {
+		value = value<<8 | int64(b)
+	}
+	return value
+}
+
+func (p *Packet) String() string {
+	return string(p.Value)
+}
+
+func (p *Packet) Bool() bool {
+	return len(p.Value) > 0 && p.Value[0] != 0
+}
+
+// Child mengembalikan anak ke-i, atau nil jika tidak ada
+func (p *Packet) Child(i int) *Packet {
+	if i < 0 || i >= len(p.Children) {
+		return nil
+	}
+	return p.Children[i]
+}
+
+// Bytes meng-encode packet ke BER
+func (p *Packet) Bytes() []byte {
+	content := p.Value
+	if p.IsConstructed() {
+		content = nil
+		for _, child := range p.Children {
+			content = append(content, child.Bytes()...)
+		}
+	}
+
+	out := []byte{p.Tag}
+	out = append(out, encodeLength(len(content))...)
+	return append(out, content...)
+}
+
+func encodeLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	var b []byte
+	for n > 0 {
+		b = append([]byte{byte(n)}, b...)
+		n >>= 8
+	}
+	return append([]byte{0x80 | byte(len(b))}, b...)
+}
+
+// ReadPacket membaca satu elemen BER lengkap dari stream
+func ReadPacket(r io.Reader) (*Packet, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	length := int(header[1])
+	raw := header
+	if length&0x80 != 0 {
+		n := length & 0x7f
+		if n == 0 || n > 4 {
+			return nil, fmt.Errorf("ldap: panjang BER tidak didukung")
+		}
+		lengthBytes := make([]byte, n)
+		if _, err := io.ReadFull(r, lengthBytes); err != nil {
+			return nil, err
+		}
+		length = 0
+		for _, b := range lengthBytes {
+			length = length<<8 | int(b)
+		}
+		raw = append(raw, lengthBytes...)
+	}
+	if length > maxPacketSize {
+		return nil, ErrPacketTooLarge
+	}
+
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return nil, err
+	}
+
+	packet, _, err := parsePacket(append(raw, content...))
+	return packet, err
+}
+
+// parsePacket mem-parse satu elemen dari awal data dan mengembalikan jumlah
+// byte yang dipakai
+func parsePacket(data []byte) (*Packet, int, error) {
+	if len(data) < 2 {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+
+	tag := data[0]
+	if tag&0x1f == 0x1f {
+		return nil, 0, fmt.Errorf("ldap: tag BER multi-byte tidak didukung")
+	}
+
+	offset := 2
+	length := int(data[1])
+	if length&0x80 != 0 {
+		n := length & 0x7f
+		if n == 0 || n > 4 || len(data) < 2+n {
+			return nil, 0, fmt.Errorf("ldap: panjang BER tidak valid")
+		}
+		length = 0
+		for _, b := range data[2 : 2+n] {
+			length = length<<8 | int(b)
+		}
+		offset += n
+	}
+	if length < 0 || length > len(data)-offset {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+
+	content := data[offset : offset+length]
+	packet := &Packet{Tag: tag}
+
+	if packet.IsConstructed() {
+		for len(content) > 0 {
+			child, used, err := parsePacket(content)
+			if err != nil {
+				return nil, 0, err
+			}
+			packet.Children = append(packet.Children, child)
+			content = content[used:]
+		}
+	} else {
+		packet.Value = append([]byte(nil), content...)
+	}
+
+	return packet, offset + length, nil
+}
@@ -0,0 +1,299 @@
+// Package ldap adalah klien LDAPv3 minimal (bind sederhana dan pencarian) untuk
+// autentikasi dan sinkronisasi user dari LDAP / Active Directory, beserta
+// server tiruan untuk development dan pengujian.
+package ldap
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Tag operasi LDAP (RFC 4511)
+const (
+	appBindRequest      = ClassApplication | Constructed | 0
+	appBindResponse     = ClassApplication | Constructed | 1
+	appUnbindRequest    = ClassApplication | 2
+	appSearchRequest    = ClassApplication | Constructed | 3
+	appSearchResultItem = ClassApplication | Constructed | 4
+	appSearchResultDone = ClassApplication | Constructed | 5
+	appSearchResultRef  = ClassApplication | Constructed | 19
+	authSimple          = ClassContext | 0
+)
+
+// Result code LDAP yang dipakai
+const (
+	ResultSuccess            = 0
+	ResultProtocolError      = 2
+	ResultSizeLimitExceeded  = 4
+	ResultInvalidCredentials = 49
+	ResultInsufficientAccess = 50
+)
+
+// Scope pencarian
+const (
+	ScopeBaseObject   = 0
+	ScopeSingleLevel  = 1
+	ScopeWholeSubtree = 2
+)
+
+const (
+	dialTimeout      = 10 * time.Second
+	operationTimeout = 30 * time.Second
+)
+
+var ErrInvalidCredentials = errors.New("ldap: DN atau password salah")
+
+// ResultError adalah hasil operasi LDAP yang bukan success
+type ResultError struct {
+	Code    int
+	Message string
+}
+
+func (e *ResultError) Error() string {
+	return fmt.Sprintf("ldap: result code %d: %s", e.Code, e.Message)
+}
+
+// Entry adalah satu objek hasil pencarian. Nama atribut disimpan dalam huruf kecil.
+type Entry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// Get mengembalikan nilai pertama atribut (kosong jika tidak ada)
+func (e Entry) Get(attr string) string {
+	if values := e.GetAll(attr); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (e Entry) GetAll(attr string) []string {
+	return e.Attributes[strings.ToLower(attr)]
+}
+
+type SearchRequest struct {
+	BaseDN     string
+	Scope      int
+	Filter     string
+	Attributes []string
+	SizeLimit  int
+}
+
+// Conn adalah satu koneksi ke server LDAP. Tidak aman dipakai bersamaan dari
+// beberapa goroutine.
+type Conn struct {
+	conn      net.Conn
+	messageID int64
+}
+
+// Dial membuka koneksi ke ldap://host:port atau ldaps://host:port
+func Dial(rawURL string, tlsConfig *tls.Config) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: URL tidak valid: %w", err)
+	}
+
+	host := u.Host
+	dialer := &net.Dialer{Timeout: dialTimeout}
+
+	var conn net.Conn
+	switch u.Scheme {
+	case "ldap":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "389")
+		}
+		conn, err = dialer.Dial("tcp", host)
+	case "ldaps":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "636")
+		}
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig = tlsConfig.Clone()
+			tlsConfig.ServerName = u.Hostname()
+		}
+		conn, err = tls.DialWithDialer(dialer, "tcp", host, tlsConfig)
+	default:
+		return nil, fmt.Errorf("ldap: skema URL %q tidak didukung", u.Scheme)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ldap: gagal terhubung ke %s: %w", host, err)
+	}
+
+	return &Conn{conn: conn}, nil
+}
+
+// Bind melakukan simple bind. Password kosong ditolak karena server LDAP
+// memperlakukannya sebagai bind anonim yang selalu berhasil.
+func (c *Conn) Bind(dn string, password string) error {
+	if dn == "" || password == "" {
+		return ErrInvalidCredentials
+	}
+
+	request := NewConstructed(appBindRequest,
+		NewInteger(TagInteger, 3),
+		NewOctetString(TagOctetString, dn),
+		NewOctetString(authSimple, password),
+	)
+
+	response, err := c.roundTrip(request)
+	if err != nil {
+		return err
+	}
+	if response.Tag != appBindResponse {
+		return fmt.Errorf("ldap: respons bind tidak terduga")
+	}
+
+	if err := resultError(response); err != nil {
+		var resultErr *ResultError
+		if errors.As(err, &resultErr) && resultErr.Code == ResultInvalidCredentials {
+			return ErrInvalidCredentials
+		}
+		return err
+	}
+	return nil
+}
+
+// Search menjalankan pencarian dan mengumpulkan semua entry hasilnya
+func (c *Conn) Search(req SearchRequest) ([]Entry, error) {
+	filter, err := ParseFilter(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := NewSequence()
+	for _, attr := range req.Attributes {
+		attributes.Append(NewOctetString(TagOctetString, attr))
+	}
+
+	request := NewConstructed(appSearchRequest,
+		NewOctetString(TagOctetString, req.BaseDN),
+		NewInteger(TagEnumerated, int64(req.Scope)),
+		NewInteger(TagEnumerated, 0), // neverDerefAliases
+		NewInteger(TagInteger, int64(req.SizeLimit)),
+		NewInteger(TagInteger, int64(operationTimeout.Seconds())),
+		NewBoolean(TagBoolean, false),
+		filter.packet(),
+		attributes,
+	)
+
+	messageID, err := c.send(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for {
+		response, err := c.receive(messageID)
+		if err != nil {
+			return nil, err
+		}
+
+		switch response.Tag {
+		case appSearchResultItem:
+			entries = append(entries, entryFromPacket(response))
+		case appSearchResultRef:
+			// referral ke server lain tidak diikuti
+		case appSearchResultDone:
+			if err := resultError(response); err != nil {
+				var resultErr *ResultError
+				if errors.As(err, &resultErr) && resultErr.Code == ResultSizeLimitExceeded {
+					return entries, nil
+				}
+				return nil, err
+			}
+			return entries, nil
+		default:
+			return nil, fmt.Errorf("ldap: respons search tidak terduga (0x%02x)", response.Tag)
+		}
+	}
+}
+
+// Close mengirim unbind lalu menutup koneksi
+func (c *Conn) Close() error {
+	c.send(&Packet{Tag: appUnbindRequest})
+	return c.conn.Close()
+}
+
+func (c *Conn) roundTrip(request *Packet) (*Packet, error) {
+	messageID, err := c.send(request)
+	if err != nil {
+		return nil, err
+	}
+	return c.receive(messageID)
+}
+
+func (c *Conn) send(request *Packet) (int64, error) {
+	c.messageID++
+	message := NewSequence(NewInteger(TagInteger, c.messageID), request)
+
+	c.conn.SetDeadline(time.Now().Add(operationTimeout))
+	if _, err := c.conn.Write(message.Bytes()); err != nil {
+		return 0, fmt.Errorf("ldap: gagal mengirim request: %w", err)
+	}
+	return c.messageID, nil
+}
+
+func (c *Conn) receive(messageID int64) (*Packet, error) {
+	for {
+		message, err := ReadPacket(c.conn)
+		if err != nil {
+			return nil, fmt.Errorf("ldap: gagal membaca respons: %w", err)
+		}
+		if message.Tag != TagSequence || len(message.Children) < 2 {
+			return nil, fmt.Errorf("ldap: pesan LDAP tidak valid")
+		}
+		// Pesan dengan message ID lain (misalnya notice of disconnection) diabaikan
+		if message.Children[0].Int() != messageID {
+			if message.Children[0].Int() == 0 {
+				return nil, fmt.Errorf("ldap: koneksi ditutup server")
+			}
+			continue
+		}
+		return message.Children[1], nil
+	}
+}
+
+// resultError membaca LDAPResult (resultCode, matchedDN, diagnosticMessage)
+func resultError(response *Packet) error {
+	code := response.Child(0)
+	if code == nil {
+		return fmt.Errorf("ldap: LDAPResult tidak valid")
+	}
+	if code.Int() == ResultSuccess {
+		return nil
+	}
+
+	message := ""
+	if diagnostic := response.Child(2); diagnostic != nil {
+		message = diagnostic.String()
+	}
+	return &ResultError{Code: int(code.Int()), Message: message}
+}
+
+func entryFromPacket(p *Packet) Entry {
+	entry := Entry{Attributes: make(map[string][]string)}
+	if dn := p.Child(0); dn != nil {
+		entry.DN = dn.String()
+	}
+	if attributes := p.Child(1); attributes != nil {
+		for _, attr := range attributes.Children {
+			name, values := attr.Child(0), attr.Child(1)
+			if name == nil || values == nil {
+				continue
+			}
+			key := strings.ToLower(name.String())
+			for _, value := range values.Children {
+				entry.Attributes[key] = append(entry.Attributes[key], value.String())
+			}
+		}
+	}
+	return entry
+}
@@ -0,0 +1,209 @@
+package ldap
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Tag filter pada SearchRequest (RFC 4511 bagian 4.5.1)
+const (
+	FilterAnd      byte = ClassContext | Constructed | 0
+	FilterOr       byte = ClassContext | Constructed | 1
+	FilterNot      byte = ClassContext | Constructed | 2
+	FilterEquality byte = ClassContext | Constructed | 3
+	FilterPresent  byte = ClassContext | 7
+)
+
+// Filter adalah filter pencarian LDAP. Yang didukung: &, |, !, kesamaan
+// (attr=nilai) dan keberadaan atribut (attr=*).
+type Filter struct {
+	Op       byte
+	Attr     string
+	Value    string
+	Children []*Filter
+}
+
+// ParseFilter mem-parse filter berformat string RFC 4515, contoh
+// (&(objectClass=person)(mail=budi@example.com))
+func ParseFilter(s string) (*Filter, error) {
+	s = strings.TrimSpace(s)
+	filter, rest, err := parseFilter(s)
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("ldap: sisa filter tidak terbaca: %q", rest)
+	}
+	return filter, nil
+}
+
+func parseFilter(s string) (*Filter, string, error) {
+	if !strings.HasPrefix(s, "(") {
+		return nil, "", fmt.Errorf("ldap: filter harus diawali '(': %q", s)
+	}
+	s = s[1:]
+	if s == "" {
+		return nil, "", fmt.Errorf("ldap: filter tidak lengkap")
+	}
+
+	switch s[0] {
+	case '&', '|', '!':
+		op := map[byte]byte{'&': FilterAnd, '|': FilterOr, '!': FilterNot}[s[0]]
+		filter := &Filter{Op: op}
+		s = s[1:]
+		for strings.HasPrefix(s, "(") {
+			child, rest, err := parseFilter(s)
+			if err != nil {
+				return nil, "", err
+			}
+			filter.Children = append(filter.Children, child)
+			s = rest
+		}
+		if !strings.HasPrefix(s, ")") {
+			return nil, "", fmt.Errorf("ldap: filter tidak ditutup")
+		}
+		if op == FilterNot && len(filter.Children) != 1 {
+			return nil, "", fmt.Errorf("ldap: filter '!' harus berisi tepat satu filter")
+		}
+		return filter, s[1:], nil
+	}
+
+	end := strings.IndexByte(s, ')')
+	if end < 0 {
+		return nil, "", fmt.Errorf("ldap: filter tidak ditutup")
+	}
+	item, rest := s[:end], s[end+1:]
+
+	eq := strings.IndexByte(item, '=')
+	if eq <= 0 {
+		return nil, "", fmt.Errorf("ldap: filter tidak valid: %q", item)
+	}
+	attr, rawValue := item[:eq], item[eq+1:]
+	if strings.ContainsAny(attr, "~<>:") {
+		return nil, "", fmt.Errorf("ldap: jenis filter %q tidak didukung", item)
+	}
+
+	if rawValue == "*" {
+		return &Filter{Op: FilterPresent, Attr: attr}, rest, nil
+	}
+	if strings.Contains(rawValue, "*") {
+		return nil, "", fmt.Errorf("ldap: filter substring %q tidak didukung", item)
+	}
+
+	value, err := unescapeFilterValue(rawValue)
+	if err != nil {
+		return nil, "", err
+	}
+	return &Filter{Op: FilterEquality, Attr: attr, Value: value}, rest, nil
+}
+
+// EscapeFilter meng-escape nilai yang akan disisipkan ke filter (RFC 4515),
+// mencegah LDAP injection dari input user seperti email
+func EscapeFilter(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch c {
+		case '*', '(', ')', '\\', 0:
+			fmt.Fprintf(&b, "\\%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func unescapeFilterValue(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+3 > len(s) {
+			return "", fmt.Errorf("ldap: escape filter tidak lengkap")
+		}
+		decoded, err := hex.DecodeString(s[i+1 : i+3])
+		if err != nil {
+			return "", fmt.Errorf("ldap: escape filter tidak valid")
+		}
+		b.Write(decoded)
+		i += 2
+	}
+	return b.String(), nil
+}
+
+func (f *Filter) packet() *Packet {
+	switch f.Op {
+	case FilterAnd, FilterOr, FilterNot:
+		packet := &Packet{Tag: f.Op}
+		for _, child := range f.Children {
+			packet.Append(child.packet())
+		}
+		return packet
+	case FilterPresent:
+		return NewOctetString(FilterPresent, f.Attr)
+	default:
+		return &Packet{Tag: FilterEquality, Children: []*Packet{
+			NewOctetString(TagOctetString, f.Attr),
+			NewOctetString(TagOctetString, f.Value),
+		}}
+	}
+}
+
+// filterFromPacket adalah kebalikan packet(), dipakai oleh Server
+func filterFromPacket(p *Packet) (*Filter, error) {
+	switch p.Tag {
+	case FilterAnd, FilterOr, FilterNot:
+		filter := &Filter{Op: p.Tag}
+		for _, child := range p.Children {
+			f, err := filterFromPacket(child)
+			if err != nil {
+				return nil, err
+			}
+			filter.Children = append(filter.Children, f)
+		}
+		return filter, nil
+	case FilterPresent:
+		return &Filter{Op: FilterPresent, Attr: p.String()}, nil
+	case FilterEquality:
+		if len(p.Children) != 2 {
+			return nil, fmt.Errorf("ldap: filter equality tidak valid")
+		}
+		return &Filter{Op: FilterEquality, Attr: p.Children[0].String(), Value: p.Children[1].String()}, nil
+	}
+	return nil, fmt.Errorf("ldap: jenis filter 0x%02x tidak didukung", p.Tag)
+}
+
+// Match mengevaluasi filter terhadap entry. Perbandingan nilai tidak
+// membedakan huruf besar/kecil (sesuai matching rule caseIgnoreMatch).
+func (f *Filter) Match(entry Entry) bool {
+	switch f.Op {
+	case FilterAnd:
+		for _, child := range f.Children {
+			if !child.Match(entry) {
+				return false
+			}
+		}
+		return true
+	case FilterOr:
+		for _, child := range f.Children {
+			if child.Match(entry) {
+				return true
+			}
+		}
+		return false
+	case FilterNot:
+		return !f.Children[0].Match(entry)
+	case FilterPresent:
+		return len(entry.GetAll(f.Attr)) > 0
+	default:
+		for _, value := range entry.GetAll(f.Attr) {
+			if strings.EqualFold(value, f.Value) {
+				return true
+			}
+		}
+		return false
+	}
+}
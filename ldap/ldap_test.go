@@ -0,0 +1,180 @@
+package ldap
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+const (
+	testBaseDN    = "dc=company,dc=com"
+	testServiceDN = "cn=service,dc=company,dc=com"
+)
+
+// startTestServer menjalankan Server in-process dengan service account dan
+// entry yang diberikan (password semuanya "password123"), mengembalikan URL
+// ldap:// server tersebut
+func startTestServer(t *testing.T, entries ...Entry) string {
+	t.Helper()
+
+	server := NewServer()
+	server.AddEntry(Entry{DN: testServiceDN, Attributes: map[string][]string{"cn": {"service"}}}, "service123")
+	for _, entry := range entries {
+		server.AddEntry(entry, "password123")
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(listener)
+	t.Cleanup(func() { listener.Close() })
+
+	return "ldap://" + listener.Addr().String()
+}
+
+func dialTestServer(t *testing.T, url string) *Conn {
+	t.Helper()
+
+	conn, err := Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func person(dn string, mail string) Entry {
+	return Entry{DN: dn, Attributes: map[string][]string{
+		"objectClass": {"person"},
+		"mail":        {mail},
+	}}
+}
+
+func TestBind(t *testing.T) {
+	url := startTestServer(t, person("uid=ahmad,ou=People,dc=company,dc=com", "ahmad@company.com"))
+
+	tests := []struct {
+		name     string
+		dn       string
+		password string
+		wantErr  error
+	}{
+		{"password benar", "uid=ahmad,ou=People,dc=company,dc=com", "password123", nil},
+		{"DN beda penulisan", "UID=ahmad, OU=People, DC=company, DC=com", "password123", nil},
+		{"password salah", "uid=ahmad,ou=People,dc=company,dc=com", "salah", ErrInvalidCredentials},
+		{"DN tidak ada", "uid=tidak-ada,ou=People,dc=company,dc=com", "password123", ErrInvalidCredentials},
+		// Password kosong adalah bind anonim/unauthenticated yang di server
+		// sungguhan bisa berhasil, jadi harus ditolak oleh klien
+		{"password kosong", "uid=ahmad,ou=People,dc=company,dc=com", "", ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := dialTestServer(t, url)
+			if err := conn.Bind(tt.dn, tt.password); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Bind err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSearchRequiresBind(t *testing.T) {
+	url := startTestServer(t)
+	conn := dialTestServer(t, url)
+
+	_, err := conn.Search(SearchRequest{BaseDN: testBaseDN, Scope: ScopeWholeSubtree, Filter: "(objectClass=*)"})
+	var resultErr *ResultError
+	if !errors.As(err, &resultErr) || resultErr.Code != ResultInsufficientAccess {
+		t.Fatalf("Search tanpa bind err = %v, want insufficientAccessRights", err)
+	}
+}
+
+func TestSearchEmailMatchingTwoEntries(t *testing.T) {
+	url := startTestServer(t,
+		person("uid=budi,ou=People,dc=company,dc=com", "budi@company.com"),
+		person("uid=budi2,ou=Contractors,dc=company,dc=com", "BUDI@company.com"),
+		person("uid=dewi,ou=People,dc=company,dc=com", "dewi@company.com"),
+	)
+	conn := dialTestServer(t, url)
+	if err := conn.Bind(testServiceDN, "service123"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sama seperti AuthenticateLDAP: SizeLimit 2 cukup untuk mendeteksi email ganda
+	entries, err := conn.Search(SearchRequest{
+		BaseDN:    testBaseDN,
+		Scope:     ScopeWholeSubtree,
+		Filter:    "(&(objectClass=person)(mail=" + EscapeFilter("budi@company.com") + "))",
+		SizeLimit: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("jumlah entry = %d, want 2", len(entries))
+	}
+
+	// Hasil yang terpotong size limit bukan error
+	entries, err = conn.Search(SearchRequest{
+		BaseDN:    testBaseDN,
+		Scope:     ScopeWholeSubtree,
+		Filter:    "(mail=budi@company.com)",
+		SizeLimit: 1,
+	})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("search dengan SizeLimit 1 = (%d entry, %v), want (1, nil)", len(entries), err)
+	}
+}
+
+func TestEscapeFilter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"budi@company.com", "budi@company.com"},
+		{"*", `\2a`},
+		{"budi)(uid=*", `budi\29\28uid=\2a`},
+		{"*)(|(objectClass=*", `\2a\29\28|\28objectClass=\2a`},
+		{`back\slash`, `back\5cslash`},
+		{"nul\x00byte", `nul\00byte`},
+	}
+
+	for _, tt := range tests {
+		if got := EscapeFilter(tt.value); got != tt.want {
+			t.Errorf("EscapeFilter(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+// Nilai hasil EscapeFilter harus tetap menjadi satu filter kesamaan dengan
+// nilai literal, bukan menambah atau mengubah struktur filter
+func TestEscapeFilterPreventsInjection(t *testing.T) {
+	entries := []Entry{
+		person("uid=ahmad,ou=People,dc=company,dc=com", "ahmad@company.com"),
+		person("uid=dewi,ou=People,dc=company,dc=com", "dewi@company.com"),
+	}
+
+	for _, value := range []string{"*", "ahmad@company.com)(mail=*", "*)(|(objectClass=*", "x)(!(mail=nobody)"} {
+		filter, err := ParseFilter("(&(objectClass=person)(mail=" + EscapeFilter(value) + "))")
+		if err != nil {
+			t.Fatalf("filter untuk %q tidak valid: %v", value, err)
+		}
+		if len(filter.Children) != 2 || filter.Children[1].Op != FilterEquality || filter.Children[1].Value != value {
+			t.Fatalf("filter untuk %q berubah struktur: %+v", value, filter.Children)
+		}
+		for _, entry := range entries {
+			if filter.Match(entry) {
+				t.Errorf("filter untuk %q cocok dengan %s", value, entry.DN)
+			}
+		}
+	}
+}
+
+func TestParseFilterRejectsUnsupported(t *testing.T) {
+	for _, s := range []string{"", "mail=x", "(mail=x", "(mail=a*b)", "(mail~=x)", "(!(a=b)(c=d))", "(mail=x))", `(mail=\2)`} {
+		if _, err := ParseFilter(s); err == nil {
+			t.Errorf("ParseFilter(%q) diterima", s)
+		}
+	}
+}
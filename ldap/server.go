@@ -0,0 +1,197 @@
+package ldap
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Server adalah server LDAP tiruan in-memory untuk development dan pengujian
+// (lihat mockldap). Mendukung simple bind, search dengan filter yang didukung
+// Filter, dan unbind. Search hanya boleh dilakukan setelah bind berhasil.
+type Server struct {
+	mu        sync.RWMutex
+	entries   []Entry
+	passwords map[string]string
+}
+
+func NewServer() *Server {
+	return &Server{passwords: make(map[string]string)}
+}
+
+// AddEntry menambahkan entry; password kosong berarti entry tidak bisa dipakai bind
+func (s *Server) AddEntry(entry Entry, password string) {
+	normalized := Entry{DN: entry.DN, Attributes: make(map[string][]string)}
+	for attr, values := range entry.Attributes {
+		key := strings.ToLower(attr)
+		normalized.Attributes[key] = append(normalized.Attributes[key], values...)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, normalized)
+	if password != "" {
+		s.passwords[NormalizeDN(entry.DN)] = password
+	}
+}
+
+// Serve menerima koneksi sampai listener ditutup
+func (s *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	bound := false
+	for {
+		message, err := ReadPacket(conn)
+		if err != nil || message.Tag != TagSequence || len(message.Children) < 2 {
+			return
+		}
+
+		messageID := message.Children[0].Int()
+		request := message.Children[1]
+
+		switch request.Tag {
+		case appBindRequest:
+			code := ResultInvalidCredentials
+			dn, credentials := request.Child(1), request.Child(2)
+			if dn != nil && credentials != nil && credentials.Tag == authSimple && credentials.String() != "" {
+				s.mu.RLock()
+				password, ok := s.passwords[NormalizeDN(dn.String())]
+				s.mu.RUnlock()
+				if ok && password == credentials.String() {
+					code = ResultSuccess
+				}
+			}
+			bound = code == ResultSuccess
+			s.reply(conn, messageID, resultPacket(appBindResponse, code, ""))
+
+		case appSearchRequest:
+			if !bound {
+				s.reply(conn, messageID, resultPacket(appSearchResultDone, ResultInsufficientAccess, "bind diperlukan"))
+				continue
+			}
+			s.search(conn, messageID, request)
+
+		case appUnbindRequest:
+			return
+
+		default:
+			s.reply(conn, messageID, resultPacket(appSearchResultDone, ResultProtocolError, "operasi tidak didukung"))
+			return
+		}
+	}
+}
+
+func (s *Server) search(conn net.Conn, messageID int64, request *Packet) {
+	if len(request.Children) < 8 {
+		s.reply(conn, messageID, resultPacket(appSearchResultDone, ResultProtocolError, "search request tidak valid"))
+		return
+	}
+
+	baseDN := NormalizeDN(request.Children[0].String())
+	scope := int(request.Children[1].Int())
+	sizeLimit := int(request.Children[3].Int())
+
+	filter, err := filterFromPacket(request.Children[6])
+	if err != nil {
+		s.reply(conn, messageID, resultPacket(appSearchResultDone, ResultProtocolError, err.Error()))
+		return
+	}
+
+	var attributes []string
+	for _, attr := range request.Children[7].Children {
+		attributes = append(attributes, strings.ToLower(attr.String()))
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sent := 0
+	for _, entry := range s.entries {
+		if !inScope(NormalizeDN(entry.DN), baseDN, scope) || !filter.Match(entry) {
+			continue
+		}
+		if sizeLimit > 0 && sent == sizeLimit {
+			s.reply(conn, messageID, resultPacket(appSearchResultDone, ResultSizeLimitExceeded, ""))
+			return
+		}
+		s.reply(conn, messageID, entryPacket(entry, attributes))
+		sent++
+	}
+
+	s.reply(conn, messageID, resultPacket(appSearchResultDone, ResultSuccess, ""))
+}
+
+func (s *Server) reply(conn net.Conn, messageID int64, response *Packet) {
+	conn.Write(NewSequence(NewInteger(TagInteger, messageID), response).Bytes())
+}
+
+func resultPacket(tag byte, code int, message string) *Packet {
+	return NewConstructed(tag,
+		NewInteger(TagEnumerated, int64(code)),
+		NewOctetString(TagOctetString, ""),
+		NewOctetString(TagOctetString, message),
+	)
+}
+
+func entryPacket(entry Entry, attributes []string) *Packet {
+	names := make([]string, 0, len(entry.Attributes))
+	for name := range entry.Attributes {
+		if len(attributes) == 0 || contains(attributes, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	list := NewSequence()
+	for _, name := range names {
+		values := &Packet{Tag: TagSet}
+		for _, value := range entry.Attributes[name] {
+			values.Append(NewOctetString(TagOctetString, value))
+		}
+		list.Append(NewSequence(NewOctetString(TagOctetString, name), values))
+	}
+
+	return NewConstructed(appSearchResultItem, NewOctetString(TagOctetString, entry.DN), list)
+}
+
+func inScope(dn string, baseDN string, scope int) bool {
+	switch scope {
+	case ScopeBaseObject:
+		return dn == baseDN
+	case ScopeSingleLevel:
+		parent := dn[strings.IndexByte(dn, ',')+1:]
+		return strings.Contains(dn, ",") && parent == baseDN
+	default:
+		return baseDN == "" || dn == baseDN || strings.HasSuffix(dn, ","+baseDN)
+	}
+}
+
+// NormalizeDN menyamakan penulisan DN untuk perbandingan sederhana
+// (huruf kecil, tanpa spasi di sekitar koma)
+func NormalizeDN(dn string) string {
+	parts := strings.Split(dn, ",")
+	for i, part := range parts {
+		parts[i] = strings.ToLower(strings.TrimSpace(part))
+	}
+	return strings.Join(parts, ",")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
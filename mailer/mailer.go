@@ -0,0 +1,125 @@
+package mailer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"time"
+)
+
+// Attachment adalah file lampiran email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Konfigurasi SMTP dibaca dari environment:
+//   - SMTP_HOST, SMTP_PORT (default 25)
+//   - SMTP_USERNAME, SMTP_PASSWORD (opsional, kosongkan untuk SMTP sink lokal seperti MailHog)
+//   - SMTP_FROM (alamat pengirim)
+func config() (addr, from string, auth smtp.Auth, err error) {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return "", "", nil, errors.New("SMTP_HOST tidak diset")
+	}
+
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "25"
+	}
+
+	from = os.Getenv("SMTP_FROM")
+	if from == "" {
+		return "", "", nil, errors.New("SMTP_FROM tidak diset")
+	}
+
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+	}
+
+	return net.JoinHostPort(host, port), from, auth, nil
+}
+
+// Send mengirim email teks dengan lampiran opsional melalui SMTP
+func Send(to []string, subject, body string, attachments ...Attachment) error {
+	if len(to) == 0 {
+		return errors.New("penerima email kosong")
+	}
+
+	addr, from, auth, err := config()
+	if err != nil {
+		return err
+	}
+
+	message, err := buildMessage(from, to, subject, body, attachments)
+	if err != nil {
+		return fmt.Errorf("gagal menyusun email: %w", err)
+	}
+
+	if err := smtp.SendMail(addr, auth, from, to, message); err != nil {
+		return fmt.Errorf("gagal mengirim email: %w", err)
+	}
+
+	return nil
+}
+
+func buildMessage(from string, to []string, subject, body string, attachments []Attachment) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", writer.Boundary())
+
+	textPart, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"8bit"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := textPart.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range attachments {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", attachment.Filename)},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+		// RFC 2045: baris base64 maksimal 76 karakter
+		for len(encoded) > 76 {
+			if _, err := part.Write([]byte(encoded[:76] + "\r\n")); err != nil {
+				return nil, err
+			}
+			encoded = encoded[76:]
+		}
+		if _, err := part.Write([]byte(encoded + "\r\n")); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,178 @@
+package mailer
+
+import (
+	"bufio"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+// smtpSink adalah SMTP server minimal untuk test: menerima satu pesan per
+// koneksi dan mengirim hasilnya ke channel messages
+type smtpSink struct {
+	addr     string
+	messages chan sinkMessage
+}
+
+type sinkMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+func startSMTPSink(t *testing.T) *smtpSink {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	sink := &smtpSink{addr: listener.Addr().String(), messages: make(chan sinkMessage, 1)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go sink.serve(conn)
+		}
+	}()
+	return sink
+}
+
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	var msg sinkMessage
+	reply("220 sink ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(line)
+
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 sink")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			msg.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			msg.to = append(msg.to, strings.Trim(line[len("RCPT TO:"):], "<>"))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			msg.data = data.String()
+			reply("250 OK")
+			s.messages <- msg
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSendDeliversMessageWithAttachment(t *testing.T) {
+	sink := startSMTPSink(t)
+	host, port, _ := net.SplitHostPort(sink.addr)
+	t.Setenv("SMTP_HOST", host)
+	t.Setenv("SMTP_PORT", port)
+	t.Setenv("SMTP_USERNAME", "")
+	t.Setenv("SMTP_FROM", "attendance@company.com")
+
+	attachment := Attachment{
+		Filename:    "attendance-2026-03-02.csv",
+		ContentType: "text/csv",
+		Data:        []byte(strings.Repeat("user_id,name,status\n1,Ahmad,on-time\n", 10)),
+	}
+	to := []string{"hr@company.com", "manager@company.com"}
+	if err := Send(to, "Rekap Absensi Harian 2026-03-02", "Hadir: 1\n", attachment); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	msg := <-sink.messages
+	if msg.from != "attendance@company.com" {
+		t.Errorf("MAIL FROM = %q", msg.from)
+	}
+	if strings.Join(msg.to, ",") != strings.Join(to, ",") {
+		t.Errorf("RCPT TO = %v, want %v", msg.to, to)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(msg.data))
+	if err != nil {
+		t.Fatalf("email tidak valid: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != "Rekap Absensi Harian 2026-03-02" {
+		t.Errorf("Subject = %q (%v)", subject, err)
+	}
+
+	_, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+
+	text, err := reader.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Baris dikirim dengan CRLF lewat SMTP
+	if body, _ := io.ReadAll(text); string(body) != "Hadir: 1\r\n" {
+		t.Errorf("body = %q", body)
+	}
+
+	file, err := reader.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.FileName() != attachment.Filename {
+		t.Errorf("filename lampiran = %q, want %q", file.FileName(), attachment.Filename)
+	}
+	data, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, file))
+	if err != nil || string(data) != string(attachment.Data) {
+		t.Errorf("isi lampiran = %q (%v), want %q", data, err, attachment.Data)
+	}
+}
+
+func TestSendRequiresConfiguration(t *testing.T) {
+	t.Setenv("SMTP_HOST", "")
+	if err := Send([]string{"hr@company.com"}, "subject", "body"); err == nil {
+		t.Error("Send tanpa SMTP_HOST tidak mengembalikan error")
+	}
+
+	t.Setenv("SMTP_HOST", "localhost")
+	t.Setenv("SMTP_FROM", "")
+	if err := Send([]string{"hr@company.com"}, "subject", "body"); err == nil {
+		t.Error("Send tanpa SMTP_FROM tidak mengembalikan error")
+	}
+
+	if err := Send(nil, "subject", "body"); err == nil {
+		t.Error("Send tanpa penerima tidak mengembalikan error")
+	}
+}
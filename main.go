@@ -2,13 +2,20 @@
 package main
 
 import (
+	"backend/controllers"
 	"backend/database"
 	"backend/handlers"
 	"backend/middleware"
-	"log"
+	"backend/ratelimit"
+	"backend/scheduler"
+	"backend/utils"
+	"crypto/tls"
+	"log/slog"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
@@ -16,84 +23,270 @@ import (
 
 func init() {
 	// Muat .env terlebih dahulu
-	if err := godotenv.Load(); err != nil {
-		log.Println("Warning: No .env file found")
+	envErr := godotenv.Load()
+
+	// Logger JSON dipasang setelah .env dimuat agar LOG_LEVEL terbaca
+	utils.InitLogger()
+	if envErr != nil {
+		slog.Warn("No .env file found")
 	}
 
 	// Inisialisasi database
 	database.Init()
 
-	// Pastikan SESSION_SECRET ada
-	if os.Getenv("SESSION_SECRET") == "" {
-		log.Println("Warning: SESSION_SECRET tidak diset di .env")
+	// Session store dibuat setelah .env dimuat agar SESSION_SECRET terbaca
+	err := handlers.InitSessionStore(handlers.SessionConfig{
+		Secret:       os.Getenv("SESSION_SECRET"),
+		CookieSecure: envBool("SESSION_COOKIE_SECURE", !isDevelopment()),
+		CookieDomain: os.Getenv("SESSION_COOKIE_DOMAIN"),
+		Development:  isDevelopment(),
+	})
+	if err != nil {
+		slog.Error("Konfigurasi session tidak valid", "error", err)
+		os.Exit(1)
 	}
 }
 
 func main() {
 	r := mux.NewRouter()
-	r.Use(middleware.CORSMiddleware)
+	r.Use(middleware.RouteLogField)
 
 	// Route publik (tidak perlu login)
 	r.HandleFunc("/api/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("OK"))
 	}).Methods("GET")
 
-	// Route autentikasi
-	r.HandleFunc("/api/login", handlers.LoginHandler).Methods("POST", "OPTIONS")
-	r.HandleFunc("/api/logout", handlers.LogoutHandler).Methods("POST", "OPTIONS")
+	// Rate limit per route (token bucket, backend in-memory)
+	limitIP := handlers.RateLimitIP
+	limitUser := handlers.RateLimitUser
+	loginLimit := limitIP(ratelimit.PerMinute("login", 10))
+	passwordLimit := limitIP(ratelimit.PerMinute("password", 5))
+
+	// Route autentikasi. Login dan langkah 2FA memakai session cookie sehingga
+	// juga wajib mengirim X-CSRF-Token (token pra-login dari GET /api/csrf)
+	csrf := handlers.RequireCSRF
+	r.Handle("/api/csrf", limitIP(ratelimit.PerMinute("csrf", 30))(handlers.GetCSRFToken())).Methods("GET")
+	r.Handle("/api/login", loginLimit(csrf(http.HandlerFunc(handlers.LoginHandler)))).Methods("POST", "OPTIONS")
+	r.Handle("/api/logout", csrf(http.HandlerFunc(handlers.LogoutHandler))).Methods("POST", "OPTIONS")
+	r.Handle("/api/login/oidc", loginLimit(handlers.OIDCLogin())).Methods("GET")
+	r.HandleFunc("/api/login/oidc/callback", handlers.OIDCCallback()).Methods("GET")
+	r.Handle("/api/login/2fa", loginLimit(csrf(handlers.VerifyTwoFactorLogin()))).Methods("POST", "OPTIONS")
+	r.Handle("/api/login/2fa/setup", loginLimit(csrf(handlers.SetupTwoFactor()))).Methods("POST", "OPTIONS")
+	r.Handle("/api/login/2fa/enable", loginLimit(csrf(handlers.EnableTwoFactor()))).Methods("POST", "OPTIONS")
+	r.Handle("/api/password/forgot", passwordLimit(handlers.ForgotPassword())).Methods("POST", "OPTIONS")
+	r.Handle("/api/password/reset", passwordLimit(handlers.ResetPassword())).Methods("POST", "OPTIONS")
+	r.Handle("/api/invitations/accept", passwordLimit(handlers.AcceptInvitation())).Methods("POST", "OPTIONS")
 
 	// Route yang memerlukan autentikasi
 	protected := r.PathPrefix("/api").Subrouter()
 	protected.Use(handlers.RequireAuth) // Middleware untuk cek login
+	// Semua route POST/PUT/DELETE dengan session cookie wajib mengirim header
+	// X-CSRF-Token (token diambil dari GET /api/auth/check)
+	protected.Use(handlers.RequireCSRF)
 
 	// route untuk check session
 	protected.HandleFunc("/auth/check", handlers.CheckAuthentication()).Methods("GET")
+
+	// Route yang hanya boleh diakses lewat session login (bukan API key)
+	sessionOnly := handlers.RequireSession
+
 	// route untuk generate attendance token
-	protected.HandleFunc("/attendance/token", handlers.GenerateToken()).Methods("GET")
-	protected.HandleFunc("/attendance/token/check", handlers.CheckAttendanceToken()).Methods("POST", "OPTIONS")
+	protected.Handle("/attendance/token", limitUser(ratelimit.PerMinute("attendance-token", 20))(sessionOnly(handlers.GenerateToken()))).Methods("GET")
+	// API key (scanner/kiosk) wajib memiliki scope attendance.scan; user dengan
+	// session login diotorisasi di handler (pemilik token atau permission scanner)
+	scanScope := handlers.RequireAPIKeyScope("attendance.scan")
+	protected.Handle("/attendance/token/check", scanScope(limitUser(ratelimit.PerMinute("attendance-token-check", 30))(handlers.CheckAttendanceToken()))).Methods("POST", "OPTIONS")
 
 	// route untuk proses absensi
-	protected.HandleFunc("/attendance/submit", handlers.SubmitAttendance()).Methods("POST", "OPTIONS")
+	protected.Handle("/attendance/submit", scanScope(limitUser(ratelimit.PerMinute("attendance-submit", 10))(handlers.SubmitAttendance()))).Methods("POST", "OPTIONS")
 
 	// route untuk work hours
 	protected.HandleFunc("/work-hours", handlers.GetWorkHours()).Methods("GET")
+	protected.Handle("/work-hours", handlers.RequirePermission("settings.write")(handlers.UpdateWorkHours())).Methods("PUT")
+
+	// route self-service (data milik user yang sedang login)
+	protected.HandleFunc("/me/attendance", handlers.GetMyAttendance()).Methods("GET")
+	protected.Handle("/me/password", limitUser(ratelimit.PerMinute("password-change", 5))(sessionOnly(handlers.ChangeMyPassword()))).Methods("POST", "OPTIONS")
+	protected.Handle("/me/2fa/setup", sessionOnly(handlers.SetupTwoFactor())).Methods("POST", "OPTIONS")
+	protected.Handle("/me/2fa/enable", sessionOnly(handlers.EnableTwoFactor())).Methods("POST", "OPTIONS")
+	protected.Handle("/me/2fa/disable", sessionOnly(handlers.DisableTwoFactor())).Methods("POST", "OPTIONS")
+	protected.Handle("/me/2fa/recovery-codes", sessionOnly(handlers.RegenerateRecoveryCodes())).Methods("POST", "OPTIONS")
+	protected.Handle("/me/sessions", sessionOnly(handlers.GetMySessions())).Methods("GET")
+	protected.Handle("/me/sessions", sessionOnly(handlers.RevokeMySessions())).Methods("DELETE")
+	protected.Handle("/me/sessions/{id}", sessionOnly(withID(handlers.RevokeMySession))).Methods("DELETE")
+	protected.Handle("/me/leave-requests", sessionOnly(handlers.CreateMyLeaveRequest())).Methods("POST")
+	protected.Handle("/me/attendance-corrections", sessionOnly(handlers.CreateMyAttendanceCorrection())).Methods("POST")
+
+	// Route dengan pengecekan permission per route (lihat tabel role_permissions)
+	can := handlers.RequirePermission
+
+	// User routes
+	protected.Handle("/users/search", can("users.read")(handlers.SearchUsers())).Methods("GET")
+	protected.Handle("/users", can("users.read")(handlers.GetUsers())).Methods("GET")
+	protected.Handle("/users", can("users.write")(handlers.CreateUser())).Methods("POST")
+	protected.Handle("/users/{id}", can("users.read")(withID(handlers.GetUser))).Methods("GET")
+	protected.Handle("/users/{id}", can("users.write")(withID(handlers.EditUser))).Methods("PUT")
+	protected.Handle("/users/{id}/invitation", can("users.write")(withID(handlers.ResendInvitation))).Methods("POST")
+	protected.Handle("/users/{id}/invitation", can("users.write")(withID(handlers.RevokeInvitation))).Methods("DELETE")
+	protected.Handle("/users/{id}/unlock", can("users.write")(withID(handlers.UnlockUser))).Methods("POST")
+	protected.Handle("/users/{id}/sessions", can("users.write")(withID(handlers.RevokeUserSessions))).Methods("DELETE")
+	protected.Handle("/users/{id}/roles", can("roles.manage")(withID(handlers.UpdateUserRoles))).Methods("PUT")
+
+	// Attendance & Department routes
+	protected.Handle("/departments", can("departments.read")(handlers.GetDepartments())).Methods("GET")
+	protected.Handle("/departments", can("departments.write")(handlers.CreateDepartment())).Methods("POST")
+	protected.Handle("/departments/{id}", can("departments.write")(withID(handlers.UpdateDepartment))).Methods("PUT")
+	protected.Handle("/departments/{id}", can("departments.write")(withID(handlers.DeleteDepartment))).Methods("DELETE")
+	protected.Handle("/attendance/today", can("attendance.report.read")(handlers.GetTodayAttendance())).Methods("GET")
+	protected.Handle("/attendance/monthly", can("attendance.report.read")(handlers.GetMonthlyAttendance())).Methods("GET")
+	protected.Handle("/attendance/monthly/export", can("attendance.report.read")(handlers.ExportMonthlyAttendance())).Methods("GET")
+	protected.Handle("/attendance/employee/monthly", can("attendance.report.read")(handlers.GetEmployeeMonthlyAttendance())).Methods("GET")
+
+	// Team routes (manager hanya melihat dan menyetujui data timnya sendiri)
+	protected.Handle("/team/attendance/today", can("team.attendance.read")(handlers.GetTeamTodayAttendance())).Methods("GET")
+	protected.Handle("/team/attendance/monthly", can("team.attendance.read")(handlers.GetTeamMonthlyAttendance())).Methods("GET")
+	protected.Handle("/team/attendance/employee/monthly", can("team.attendance.read")(handlers.GetTeamEmployeeMonthlyAttendance())).Methods("GET")
+	protected.Handle("/team/leave-requests", can("team.requests.approve")(handlers.GetTeamLeaveRequests())).Methods("GET")
+	protected.Handle("/team/leave-requests/{id}", can("team.requests.approve")(withID(handlers.ReviewLeaveRequest))).Methods("PUT")
+	protected.Handle("/team/attendance-corrections", can("team.requests.approve")(handlers.GetTeamAttendanceCorrections())).Methods("GET")
+	protected.Handle("/team/attendance-corrections/{id}", can("team.requests.approve")(withID(handlers.ReviewAttendanceCorrection))).Methods("PUT")
+
+	// Anomali absensi (review HR)
+	protected.Handle("/attendance/anomalies", can("attendance.anomaly.review")(handlers.GetAttendanceAnomalies())).Methods("GET")
+	protected.Handle("/attendance/anomalies/scan", can("attendance.anomaly.review")(handlers.ScanAttendanceAnomalies())).Methods("POST")
+	protected.Handle("/attendance/anomalies/{id}", can("attendance.anomaly.review")(withID(handlers.ReviewAttendanceAnomaly))).Methods("PUT")
+
+	// Kebijakan keterlambatan
+	protected.Handle("/late-policy", can("attendance.report.read")(handlers.GetLatePolicy())).Methods("GET")
+	protected.Handle("/late-policy", can("late_policy.write")(handlers.UpdateLatePolicy())).Methods("PUT")
+
+	// Laporan terjadwal via email
+	protected.Handle("/report-subscriptions", can("reports.manage")(handlers.GetReportSubscriptions())).Methods("GET")
+	protected.Handle("/report-subscriptions", can("reports.manage")(handlers.CreateReportSubscription())).Methods("POST")
+	protected.Handle("/report-subscriptions/{id}", can("reports.manage")(withID(handlers.DeleteReportSubscription))).Methods("DELETE")
+	protected.Handle("/report-subscriptions/{id}/send", can("reports.manage")(withID(handlers.SendReportSubscription))).Methods("POST")
+
+	// Audit log perubahan data
+	protected.Handle("/audit-logs", can("audit.read")(handlers.GetAuditLogs())).Methods("GET")
+	protected.Handle("/audit-logs/verify", can("audit.read")(handlers.VerifyAuditChain())).Methods("GET")
+	protected.Handle("/audit-logs/digest", can("audit.read")(handlers.GetAuditDigest())).Methods("GET")
+
+	// Route khusus Admin (role Admin, termasuk pewarisan)
+	admin := protected.PathPrefix("/admin").Subrouter()
+	admin.Use(handlers.RequireSession, handlers.RequireRole("Admin"))
+	admin.HandleFunc("/settings", handlers.GetSystemSettings()).Methods("GET")
+	admin.HandleFunc("/settings", handlers.UpdateSystemSettings()).Methods("PUT")
+	admin.HandleFunc("/roles", handlers.GetRoles()).Methods("GET")
+	admin.HandleFunc("/permissions", handlers.GetPermissions()).Methods("GET")
+	admin.Handle("/roles/{id}/permissions", withID(handlers.UpdateRolePermissions)).Methods("PUT")
+	admin.Handle("/roles/{id}/require-2fa", withID(handlers.UpdateRoleTwoFA)).Methods("PUT")
+	admin.HandleFunc("/ldap/sync", handlers.SyncLDAPUsers()).Methods("POST")
+	admin.HandleFunc("/api-keys", handlers.GetAPIKeys()).Methods("GET")
+	admin.HandleFunc("/api-keys", handlers.CreateAPIKey()).Methods("POST")
+	admin.Handle("/api-keys/{id}", withID(handlers.RevokeAPIKey)).Methods("DELETE")
+
+	// Job terjadwal
+	scheduleAnomalyScan()
+	scheduleLDAPSync()
+	scheduler.EveryMinute("report-subscriptions", controllers.RunDueReportSubscriptions)
+	scheduler.Every("login-attempts-cleanup", 24*time.Hour, controllers.PruneLoginAttempts)
+	scheduler.Every("session-cleanup", time.Hour, controllers.PruneSessions)
+	scheduler.EveryMinute("audit-digest-export", controllers.ExportDailyAuditDigest)
+
+	// CORS membungkus seluruh router agar preflight ke route mana pun ditangani
+	cors := middleware.NewCORSMiddleware(middleware.LoadCORSConfig())
+	securityHeaders := middleware.NewSecurityHeadersMiddleware(middleware.LoadSecurityHeadersConfig(!isDevelopment()))
+
+	// Request logger paling luar agar request yang ditolak CORS pun tercatat
+	server := &http.Server{
+		Addr:    ":8080",
+		Handler: middleware.RequestLogger(securityHeaders(cors(r))),
+	}
+
+	// TLS langsung dari aplikasi jika TLS_CERT_FILE dan TLS_KEY_FILE diisi,
+	// kosongkan keduanya jika TLS ditangani reverse proxy
+	certFile, keyFile := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+	if (certFile == "") != (keyFile == "") {
+		slog.Error("TLS_CERT_FILE dan TLS_KEY_FILE harus diisi keduanya")
+		os.Exit(1)
+	}
+
+	var err error
+	if certFile != "" {
+		server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		slog.Info("Server running", "addr", "https://localhost:8080")
+		err = server.ListenAndServeTLS(certFile, keyFile)
+	} else {
+		slog.Info("Server running", "addr", "http://localhost:8080")
+		err = server.ListenAndServe()
+	}
 
-	// buat route khusus HR
-	hrOnly := r.PathPrefix("/api").Subrouter()
-	hrOnly.Use(handlers.RequireAuth)
-	hrOnly.Use(handlers.RequireHR)
-
-	// User routes (butuh login)
-	hrOnly.HandleFunc("/users/search", handlers.SearchUsers()).Methods("GET")
-	hrOnly.HandleFunc("/users", handlers.GetUsers()).Methods("GET")
-	hrOnly.HandleFunc("/users", handlers.CreateUser()).Methods("POST")
-	hrOnly.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
-		vars := mux.Vars(r)
-		userID, err := strconv.Atoi(vars["id"])
+	slog.Error("Server stopped", "error", err)
+	os.Exit(1)
+}
+
+// isDevelopment bernilai true jika APP_ENV=development. Default-nya production
+// agar server yang lupa dikonfigurasi tidak berjalan dengan setelan yang longgar.
+func isDevelopment() bool {
+	return strings.EqualFold(os.Getenv("APP_ENV"), "development")
+}
+
+// envBool membaca environment variable boolean dengan nilai default
+func envBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// withID membaca path parameter {id} lalu meneruskannya ke handler
+func withID(handler func(int) http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
 		if err != nil {
-			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			http.Error(w, "Invalid ID", http.StatusBadRequest)
 			return
 		}
-		handlers.GetUser(userID)(w, r)
-	}).Methods("GET")
-	hrOnly.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
-		vars := mux.Vars(r)
-		userID, err := strconv.Atoi(vars["id"])
-		if err != nil {
-			http.Error(w, "Invalid user ID", http.StatusBadRequest)
-			return
+		handler(id)(w, r)
+	}
+}
+
+// scheduleLDAPSync menjadwalkan sinkronisasi user dari LDAP jika LDAP dikonfigurasi
+// dan LDAP_SYNC_INTERVAL diisi (contoh: "6h")
+func scheduleLDAPSync() {
+	value := os.Getenv("LDAP_SYNC_INTERVAL")
+	if !controllers.LDAPEnabled() || value == "" {
+		return
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		slog.Warn("LDAP_SYNC_INTERVAL tidak valid, sinkronisasi LDAP terjadwal tidak dijalankan", "value", value)
+		return
+	}
+
+	scheduler.Every("ldap-user-sync", interval, func() error {
+		_, err := controllers.SyncLDAPUsers(false)
+		return err
+	})
+}
+
+// scheduleAnomalyScan menjadwalkan deteksi anomali absensi.
+// Interval bisa diatur lewat ANOMALY_SCAN_INTERVAL (contoh: "30m"), default 1 jam.
+func scheduleAnomalyScan() {
+	interval := time.Hour
+	if value := os.Getenv("ANOMALY_SCAN_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			slog.Warn("ANOMALY_SCAN_INTERVAL tidak valid, menggunakan default 1 jam", "value", value)
+		} else {
+			interval = parsed
 		}
-		handlers.EditUser(userID)(w, r)
-	}).Methods("PUT")
-
-	// Attendance & Department routes (butuh login)
-	// hrOnly.HandleFunc("/attendance/token", handlers.GenerateToken()).Methods("GET")
-	hrOnly.HandleFunc("/departments", handlers.GetDepartments()).Methods("GET")
-	hrOnly.HandleFunc("/attendance/today", handlers.GetTodayAttendance()).Methods("GET")
-	hrOnly.HandleFunc("/attendance/monthly", handlers.GetMonthlyAttendance()).Methods("GET")
-	hrOnly.HandleFunc("/attendance/employee/monthly", handlers.GetEmployeeMonthlyAttendance()).Methods("GET")
-	hrOnly.HandleFunc("/attendance/employee/monthly", handlers.GetEmployeeMonthlyAttendance()).Methods("GET")
-
-	log.Println("Server running on http://localhost:8080")
-	log.Fatal(http.ListenAndServe(":8080", r))
+	}
+
+	scheduler.Every("attendance-anomaly-scan", interval, func() error {
+		_, err := controllers.DetectAttendanceAnomalies(time.Now().Add(-24 * time.Hour))
+		return err
+	})
 }
@@ -1,21 +1,200 @@
 package middleware
 
-import "net/http"
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
 
-func CORSMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Izinkan origin frontend Anda
-		w.Header().Set("Access-Control-Allow-Origin", "http://localhost:3000") // Sesuaikan dengan URL frontend
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
+// CORSConfig berisi konfigurasi CORS yang dimuat dari environment
+type CORSConfig struct {
+	// AllowedOrigins berisi origin yang diizinkan, misalnya "https://app.example.com".
+	// Wildcard subdomain didukung: "https://*.example.com" cocok dengan
+	// "https://staging.example.com" tetapi tidak dengan "https://example.com".
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	MaxAge         time.Duration
+}
+
+// LoadCORSConfig membaca konfigurasi CORS dari environment:
+//   - CORS_ALLOWED_ORIGINS: daftar origin dipisah koma (default http://localhost:3000)
+//   - CORS_ALLOWED_METHODS: daftar method dipisah koma
+//   - CORS_ALLOWED_HEADERS: daftar header request dipisah koma
+//   - CORS_MAX_AGE: lama cache preflight di browser (contoh: "10m")
+func LoadCORSConfig() CORSConfig {
+	cfg := CORSConfig{
+		AllowedOrigins: splitList(envOrDefault("CORS_ALLOWED_ORIGINS", "http://localhost:3000")),
+		AllowedMethods: splitList(envOrDefault("CORS_ALLOWED_METHODS", "GET, POST, PUT, DELETE, OPTIONS")),
+		AllowedHeaders: splitList(envOrDefault("CORS_ALLOWED_HEADERS", "Content-Type, Authorization, X-CSRF-Token")),
+		MaxAge:         10 * time.Minute,
+	}
+
+	if value := os.Getenv("CORS_MAX_AGE"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			slog.Warn("CORS_MAX_AGE tidak valid, menggunakan default 10 menit", "value", value)
+		} else {
+			cfg.MaxAge = parsed
+		}
+	}
+
+	// Cookie session ikut dikirim (Allow-Credentials), jadi origin "*" tidak boleh dipakai
+	origins := cfg.AllowedOrigins[:0]
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			slog.Warn("origin \"*\" tidak didukung pada CORS_ALLOWED_ORIGINS karena credentials diizinkan, diabaikan")
+			continue
+		}
+		origins = append(origins, strings.TrimSuffix(origin, "/"))
+	}
+	cfg.AllowedOrigins = origins
+
+	return cfg
+}
+
+// NewCORSMiddleware membuat middleware CORS berdasarkan konfigurasi.
+// Origin yang diizinkan dipantulkan apa adanya di Access-Control-Allow-Origin,
+// origin lain tidak mendapat header CORS sama sekali. Middleware ini dipasang
+// membungkus seluruh router agar preflight ke route mana pun ditangani di sini.
+func NewCORSMiddleware(cfg CORSConfig) func(http.Handler) http.Handler {
+	allowedMethods := make(map[string]bool, len(cfg.AllowedMethods))
+	for _, method := range cfg.AllowedMethods {
+		allowedMethods[strings.ToUpper(method)] = true
+	}
+	allowedHeaders := make(map[string]bool, len(cfg.AllowedHeaders))
+	for _, header := range cfg.AllowedHeaders {
+		allowedHeaders[http.CanonicalHeaderKey(header)] = true
+	}
+
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			// Response berbeda tergantung Origin, jadi cache (CDN/proxy) harus membedakannya
+			w.Header().Add("Vary", "Origin")
+			if preflight {
+				w.Header().Add("Vary", "Access-Control-Request-Method")
+				w.Header().Add("Vary", "Access-Control-Request-Headers")
+			}
+
+			if origin == "" {
+				// Bukan request cross-origin
+				if r.Method == http.MethodOptions {
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !originAllowed(cfg.AllowedOrigins, origin) {
+				if preflight || r.Method == http.MethodOptions {
+					http.Error(w, "Origin not allowed", http.StatusForbidden)
+					return
+				}
+				// Request tetap diproses tanpa header CORS, browser yang akan memblokir respons
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.Method == http.MethodOptions {
+				if !preflight || !allowedMethods[strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))] || !headersAllowed(allowedHeaders, r.Header.Get("Access-Control-Request-Headers")) {
+					http.Error(w, "CORS request not allowed", http.StatusForbidden)
+					return
+				}
+
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+				w.Header().Set("Access-Control-Allow-Methods", methods)
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+				w.Header().Set("Access-Control-Max-Age", maxAge)
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
 
-		// Handle preflight request
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+// originAllowed mengecek origin terhadap daftar origin, termasuk pola wildcard subdomain
+func originAllowed(allowed []string, origin string) bool {
+	for _, pattern := range allowed {
+		if strings.EqualFold(pattern, origin) {
+			return true
 		}
 
-		next.ServeHTTP(w, r)
-	})
+		star := strings.Index(pattern, "*.")
+		if star < 0 {
+			continue
+		}
+
+		prefix := pattern[:star]   // contoh: "https://"
+		suffix := pattern[star+1:] // contoh: ".example.com"
+		if len(origin) <= len(prefix)+len(suffix) {
+			continue
+		}
+		if !strings.EqualFold(origin[:len(prefix)], prefix) || !strings.EqualFold(origin[len(origin)-len(suffix):], suffix) {
+			continue
+		}
+
+		// Bagian wildcard hanya boleh berisi label hostname, bukan path/port/userinfo
+		if validSubdomain(origin[len(prefix) : len(origin)-len(suffix)]) {
+			return true
+		}
+	}
+	return false
+}
+
+func validSubdomain(s string) bool {
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// headersAllowed mengecek semua header pada Access-Control-Request-Headers
+func headersAllowed(allowed map[string]bool, requested string) bool {
+	for _, header := range splitList(requested) {
+		if !allowed[http.CanonicalHeaderKey(header)] {
+			return false
+		}
+	}
+	return true
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func envOrDefault(key, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return fallback
 }
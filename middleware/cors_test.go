@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testCORSHandler() http.Handler {
+	cfg := CORSConfig{
+		AllowedOrigins: []string{"https://app.company.com", "https://*.example.com"},
+		AllowedMethods: []string{"GET", "POST", "OPTIONS"},
+		AllowedHeaders: []string{"Content-Type", "X-CSRF-Token"},
+		MaxAge:         10 * time.Minute,
+	}
+	return NewCORSMiddleware(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func TestOriginAllowed(t *testing.T) {
+	allowed := []string{"https://app.company.com", "https://*.example.com"}
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.company.com", true},
+		{"HTTPS://APP.COMPANY.COM", true},
+		{"http://app.company.com", false},
+		{"https://app.company.com:8443", false},
+		{"https://evil.app.company.com", false},
+
+		{"https://staging.example.com", true},
+		{"https://a.b.example.com", true},
+		{"https://example.com", false}, // apex tidak cocok dengan *.example.com
+		{"https://.example.com", false},
+		{"https://notexample.com", false},
+		{"https://evil-example.com", false},
+		{"http://staging.example.com", false},
+		{"https://staging.example.com.evil.com", false},
+
+		// Port, path atau userinfo yang diselipkan di bagian wildcard
+		{"https://evil.com:443.example.com", false},
+		{"https://evil.com/.example.com", false},
+		{"https://user@evil.com#.example.com", false},
+		{"https://evil.com?.example.com", false},
+		{"https://-bad.example.com", false},
+		{"https://a..example.com", false},
+	}
+
+	for _, tt := range tests {
+		if got := originAllowed(allowed, tt.origin); got != tt.want {
+			t.Errorf("originAllowed(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	handler := testCORSHandler()
+
+	tests := []struct {
+		name          string
+		method        string
+		origin        string
+		requestMethod string // Access-Control-Request-Method (preflight)
+		requestHeader string // Access-Control-Request-Headers
+		wantStatus    int
+		wantACAO      string
+	}{
+		{"tanpa origin", http.MethodGet, "", "", "", http.StatusOK, ""},
+		{"origin persis", http.MethodGet, "https://app.company.com", "", "", http.StatusOK, "https://app.company.com"},
+		{"subdomain wildcard", http.MethodPost, "https://staging.example.com", "", "", http.StatusOK, "https://staging.example.com"},
+		{"apex wildcard", http.MethodGet, "https://example.com", "", "", http.StatusOK, ""},
+		{"origin lain tetap diproses tanpa header", http.MethodPost, "https://evil.com", "", "", http.StatusOK, ""},
+
+		{"preflight diizinkan", http.MethodOptions, "https://app.company.com", "POST", "content-type, x-csrf-token", http.StatusOK, "https://app.company.com"},
+		{"preflight origin ditolak", http.MethodOptions, "https://evil.com", "POST", "", http.StatusForbidden, ""},
+		{"preflight port di wildcard ditolak", http.MethodOptions, "https://evil.com:443.example.com", "POST", "", http.StatusForbidden, ""},
+		{"preflight method ditolak", http.MethodOptions, "https://app.company.com", "DELETE", "", http.StatusForbidden, ""},
+		{"preflight header ditolak", http.MethodOptions, "https://app.company.com", "POST", "X-Custom", http.StatusForbidden, ""},
+		{"OPTIONS tanpa preflight", http.MethodOptions, "https://app.company.com", "", "", http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/users", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.requestMethod != "" {
+				req.Header.Set("Access-Control-Request-Method", tt.requestMethod)
+			}
+			if tt.requestHeader != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.requestHeader)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantACAO {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantACAO)
+			}
+			if tt.wantACAO == "" && rec.Header().Get("Access-Control-Allow-Credentials") != "" {
+				t.Error("Access-Control-Allow-Credentials dikirim untuk origin yang tidak diizinkan")
+			}
+		})
+	}
+}
+
+func TestCORSPreflightHeaders(t *testing.T) {
+	req := httptest.NewRequest(http.MethodOptions, "/api/login", nil)
+	req.Header.Set("Origin", "https://app.company.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+
+	rec := httptest.NewRecorder()
+	testCORSHandler().ServeHTTP(rec, req)
+
+	want := map[string]string{
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Allow-Methods":     "GET, POST, OPTIONS",
+		"Access-Control-Allow-Headers":     "Content-Type, X-CSRF-Token",
+		"Access-Control-Max-Age":           "600",
+	}
+	for header, value := range want {
+		if got := rec.Header().Get(header); got != value {
+			t.Errorf("%s = %q, want %q", header, got, value)
+		}
+	}
+}
+
+func TestCORSVary(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		origin    string
+		preflight bool
+		want      string
+	}{
+		{"request biasa", http.MethodGet, "https://app.company.com", false, "Origin"},
+		{"tanpa origin", http.MethodGet, "", false, "Origin"},
+		{"origin ditolak", http.MethodGet, "https://evil.com", false, "Origin"},
+		{"preflight", http.MethodOptions, "https://app.company.com", true, "Origin, Access-Control-Request-Method, Access-Control-Request-Headers"},
+		{"preflight ditolak", http.MethodOptions, "https://evil.com", true, "Origin, Access-Control-Request-Method, Access-Control-Request-Headers"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/users", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", "GET")
+			}
+
+			rec := httptest.NewRecorder()
+			testCORSHandler().ServeHTTP(rec, req)
+
+			if got := strings.Join(rec.Header().Values("Vary"), ", "); got != tt.want {
+				t.Errorf("Vary = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
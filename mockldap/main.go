@@ -0,0 +1,103 @@
+// mockldap adalah directory LDAP tiruan (in-memory) untuk development dan
+// pengujian login serta sinkronisasi LDAP secara lokal. Jangan dipakai di production.
+//
+//	go run ./mockldap                      # data contoh bawaan
+//	MOCK_LDAP_DATA=users.json go run ./mockldap
+//
+// Lalu set di .env server:
+//
+//	LDAP_URL=ldap://localhost:3890
+//	LDAP_BIND_DN=cn=service,dc=company,dc=com
+//	LDAP_BIND_PASSWORD=service123
+//	LDAP_BASE_DN=dc=company,dc=com
+//	LDAP_GROUP_DEPARTMENTS={"cn=IT,ou=Groups,dc=company,dc=com":"IT","cn=Design,ou=Groups,dc=company,dc=com":"Design"}
+package main
+
+import (
+	"backend/ldap"
+	"encoding/json"
+	"log"
+	"net"
+	"os"
+)
+
+// entry adalah format file MOCK_LDAP_DATA (array JSON)
+type entry struct {
+	DN         string              `json:"dn"`
+	Password   string              `json:"password"`
+	Attributes map[string][]string `json:"attributes"`
+}
+
+var sampleEntries = []entry{
+	{
+		DN:         "cn=service,dc=company,dc=com",
+		Password:   "service123",
+		Attributes: map[string][]string{"objectClass": {"applicationProcess"}, "cn": {"service"}},
+	},
+	{
+		DN:       "uid=ahmad.fauzi,ou=People,dc=company,dc=com",
+		Password: "password123",
+		Attributes: map[string][]string{
+			"objectClass":     {"person"},
+			"cn":              {"Ahmad Fauzi"},
+			"mail":            {"ahmad.fauzi@company.com"},
+			"telephoneNumber": {"+62 812-3456-7890"},
+			"title":           {"Senior Software Engineer"},
+			"memberOf":        {"cn=IT,ou=Groups,dc=company,dc=com"},
+		},
+	},
+	{
+		DN:       "uid=budi.santoso,ou=People,dc=company,dc=com",
+		Password: "password123",
+		Attributes: map[string][]string{
+			"objectClass":        {"person"},
+			"cn":                 {"Budi Santoso"},
+			"mail":               {"budi.santoso@company.com"},
+			"title":              {"UI/UX Designer"},
+			"memberOf":           {"cn=Design,ou=Groups,dc=company,dc=com"},
+			"userAccountControl": {"514"}, // akun dinonaktifkan (ACCOUNTDISABLE)
+		},
+	},
+	{
+		DN:       "uid=dewi.lestari,ou=People,dc=company,dc=com",
+		Password: "password123",
+		Attributes: map[string][]string{
+			"objectClass": {"person"},
+			"cn":          {"Dewi Lestari"},
+			"mail":        {"dewi.lestari@company.com"},
+			"title":       {"Backend Engineer"},
+			"memberOf":    {"cn=IT,ou=Groups,dc=company,dc=com"},
+		},
+	},
+}
+
+func main() {
+	addr := os.Getenv("MOCK_LDAP_ADDR")
+	if addr == "" {
+		addr = "127.0.0.1:3890"
+	}
+
+	entries := sampleEntries
+	if path := os.Getenv("MOCK_LDAP_DATA"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatal("Gagal membaca MOCK_LDAP_DATA:", err)
+		}
+		if err := json.Unmarshal(data, &entries); err != nil {
+			log.Fatal("MOCK_LDAP_DATA bukan JSON yang valid:", err)
+		}
+	}
+
+	server := ldap.NewServer()
+	for _, e := range entries {
+		server.AddEntry(ldap.Entry{DN: e.DN, Attributes: e.Attributes}, e.Password)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatal("Gagal listen:", err)
+	}
+
+	log.Printf("Mock LDAP directory with %d entries listening on %s", len(entries), addr)
+	log.Fatal(server.Serve(listener))
+}
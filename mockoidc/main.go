@@ -0,0 +1,35 @@
+// mockoidc adalah identity provider OpenID Connect tiruan untuk development dan
+// pengujian login SSO secara lokal. Jangan dipakai di production: provider ini
+// menerima email apa pun tanpa password.
+//
+//	go run ./mockoidc
+//
+// Lalu set OIDC_ISSUER_URL=http://localhost:9000 di .env server.
+package main
+
+import (
+	"backend/oidc"
+	"log"
+	"net/http"
+	"os"
+)
+
+func main() {
+	addr := envOr("MOCK_OIDC_ADDR", ":9000")
+	issuer := envOr("MOCK_OIDC_ISSUER", "http://localhost:9000")
+
+	provider, err := oidc.NewMockProvider(issuer)
+	if err != nil {
+		log.Fatal("Gagal membuat RSA key:", err)
+	}
+
+	log.Printf("Mock OIDC provider %s listening on %s", issuer, addr)
+	log.Fatal(http.ListenAndServe(addr, provider.Handler()))
+}
+
+func envOr(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,244 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testClientID    = "test-client"
+	testRedirectURL = "http://app.test/api/login/oidc/callback"
+)
+
+// startMockProvider menjalankan MockProvider di httptest dan melakukan
+// discovery terhadapnya
+func startMockProvider(t *testing.T) (*MockProvider, *Provider) {
+	t.Helper()
+
+	mock, err := NewMockProvider("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(mock.Handler())
+	mock.Issuer = "http://" + srv.Listener.Addr().String()
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	provider, err := Discover(mock.Issuer, testClientID, "", testRedirectURL)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	return mock, provider
+}
+
+// authorize mensimulasikan user mengisi form login mock dan mengembalikan
+// parameter redirect ke callback
+func authorize(t *testing.T, provider *Provider, state, nonce, challenge, email string) url.Values {
+	t.Helper()
+
+	authURL, err := url.Parse(provider.AuthCodeURL(state, nonce, challenge))
+	if err != nil {
+		t.Fatal(err)
+	}
+	form := authURL.Query()
+	form.Set("email", email)
+	form.Set("name", "Test User")
+	form.Set("email_verified", "true")
+	authURL.RawQuery = ""
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.PostForm(authURL.String(), form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize status = %d, want 302", resp.StatusCode)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := location.Scheme + "://" + location.Host + location.Path; got != testRedirectURL {
+		t.Fatalf("redirect ke %q, want %q", got, testRedirectURL)
+	}
+	return location.Query()
+}
+
+// signToken menandatangani JWT dengan header bebas agar test bisa membuat
+// token dengan kid atau alg yang tidak valid
+func signToken(t *testing.T, key *rsa.PrivateKey, header, claims map[string]interface{}) string {
+	t.Helper()
+
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func validClaims(mock *MockProvider, nonce string) map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"iss":            mock.Issuer,
+		"sub":            "mock|user@example.com",
+		"aud":            testClientID,
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          nonce,
+		"email":          "user@example.com",
+		"email_verified": true,
+	}
+}
+
+func TestAuthorizationCodeFlowRoundTrip(t *testing.T) {
+	_, provider := startMockProvider(t)
+
+	state, _ := RandomString()
+	nonce, _ := RandomString()
+	verifier, challenge, err := NewPKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	callback := authorize(t, provider, state, nonce, challenge, "user@example.com")
+	if callback.Get("state") != state {
+		t.Fatalf("state = %q, want %q", callback.Get("state"), state)
+	}
+
+	rawIDToken, err := provider.Exchange(callback.Get("code"), verifier)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	claims, err := provider.VerifyIDToken(rawIDToken, nonce)
+	if err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	if claims.Subject != "mock|user@example.com" || claims.Email != "user@example.com" || !claims.EmailVerified {
+		t.Errorf("claims tidak sesuai: %+v", claims)
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	_, provider := startMockProvider(t)
+
+	nonce, _ := RandomString()
+	_, challenge, _ := NewPKCE()
+	otherVerifier, _, _ := NewPKCE()
+
+	callback := authorize(t, provider, "state", nonce, challenge, "user@example.com")
+	if _, err := provider.Exchange(callback.Get("code"), otherVerifier); err == nil {
+		t.Fatal("Exchange dengan code_verifier lain diterima")
+	}
+}
+
+func TestExchangeRejectsReusedCode(t *testing.T) {
+	_, provider := startMockProvider(t)
+
+	nonce, _ := RandomString()
+	verifier, challenge, _ := NewPKCE()
+
+	callback := authorize(t, provider, "state", nonce, challenge, "user@example.com")
+	if _, err := provider.Exchange(callback.Get("code"), verifier); err != nil {
+		t.Fatalf("Exchange pertama: %v", err)
+	}
+	if _, err := provider.Exchange(callback.Get("code"), verifier); err == nil {
+		t.Fatal("authorization code dipakai dua kali")
+	}
+}
+
+func TestVerifyIDTokenRejections(t *testing.T) {
+	mock, provider := startMockProvider(t)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs256 := map[string]interface{}{"alg": "RS256", "typ": "JWT", "kid": mock.KeyID}
+
+	tests := []struct {
+		name  string
+		token func() string
+	}{
+		{"tanda tangan key lain", func() string {
+			return signToken(t, otherKey, rs256, validClaims(mock, "n"))
+		}},
+		{"payload diubah", func() string {
+			parts := strings.Split(signToken(t, mock.key, rs256, validClaims(mock, "n")), ".")
+			claims := validClaims(mock, "n")
+			claims["email"] = "attacker@example.com"
+			payload, _ := json.Marshal(claims)
+			return parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2]
+		}},
+		{"audience lain", func() string {
+			claims := validClaims(mock, "n")
+			claims["aud"] = "client-lain"
+			return signToken(t, mock.key, rs256, claims)
+		}},
+		{"issuer lain", func() string {
+			claims := validClaims(mock, "n")
+			claims["iss"] = "https://issuer-lain.example.com"
+			return signToken(t, mock.key, rs256, claims)
+		}},
+		{"kedaluwarsa", func() string {
+			claims := validClaims(mock, "n")
+			claims["iat"] = time.Now().Add(-time.Hour).Unix()
+			claims["exp"] = time.Now().Add(-10 * time.Minute).Unix()
+			return signToken(t, mock.key, rs256, claims)
+		}},
+		{"kid tidak ada di JWKS", func() string {
+			header := map[string]interface{}{"alg": "RS256", "typ": "JWT", "kid": "key-lain"}
+			return signToken(t, mock.key, header, validClaims(mock, "n"))
+		}},
+		{"alg none", func() string {
+			header, _ := json.Marshal(map[string]interface{}{"alg": "none", "typ": "JWT"})
+			payload, _ := json.Marshal(validClaims(mock, "n"))
+			return base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload) + "."
+		}},
+		{"alg HS256", func() string {
+			header := map[string]interface{}{"alg": "HS256", "typ": "JWT", "kid": mock.KeyID}
+			return signToken(t, mock.key, header, validClaims(mock, "n"))
+		}},
+		{"nonce lain", func() string {
+			return signToken(t, mock.key, rs256, validClaims(mock, "nonce-lain"))
+		}},
+		{"sub kosong", func() string {
+			claims := validClaims(mock, "n")
+			claims["sub"] = ""
+			return signToken(t, mock.key, rs256, claims)
+		}},
+	}
+
+	// Token valid harus diterima agar penolakan di bawah bukan karena setup test
+	if _, err := provider.VerifyIDToken(signToken(t, mock.key, rs256, validClaims(mock, "n")), "n"); err != nil {
+		t.Fatalf("token valid ditolak: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := provider.VerifyIDToken(tt.token(), "n")
+			if !errors.Is(err, ErrInvalidIDToken) {
+				t.Fatalf("err = %v, want ErrInvalidIDToken", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Package ratelimit menyediakan rate limiter token bucket dengan backend yang
+// bisa diganti (default in-memory, bisa diganti Redis dsb. untuk multi instance).
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Rule mendefinisikan batas request untuk satu kelompok route.
+// Bucket berisi maksimal Burst token (default Requests) dan diisi ulang
+// sebanyak Requests token setiap Per.
+type Rule struct {
+	Name     string
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+// PerMinute membuat rule n request per menit
+func PerMinute(name string, n int) Rule {
+	return Rule{Name: name, Requests: n, Per: time.Minute}
+}
+
+func (rule Rule) capacity() float64 {
+	if rule.Burst > 0 {
+		return float64(rule.Burst)
+	}
+	return float64(rule.Requests)
+}
+
+// ratePerSecond adalah jumlah token yang ditambahkan ke bucket per detik
+func (rule Rule) ratePerSecond() float64 {
+	return float64(rule.Requests) / rule.Per.Seconds()
+}
+
+// Limiter adalah backend rate limiter. Allow mengambil satu token dari bucket
+// milik key; jika bucket kosong, kembalikan false beserta waktu tunggu.
+type Limiter interface {
+	Allow(key string, rule Rule) (allowed bool, retryAfter time.Duration, err error)
+}
+
+type bucket struct {
+	tokens   float64
+	updated  time.Time
+	capacity float64
+	rate     float64
+}
+
+// MemoryLimiter menyimpan bucket di memori proses. Cocok untuk satu instance
+// server; data hilang saat restart.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryLimiter membuat limiter in-memory
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Allow mengimplementasikan Limiter
+func (l *MemoryLimiter) Allow(key string, rule Rule) (bool, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: rule.capacity(), updated: now}
+		l.buckets[key] = b
+	}
+	b.capacity = rule.capacity()
+	b.rate = rule.ratePerSecond()
+
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+
+	// Dibulatkan ke atas per detik setelah dikonversi ke Duration (presisi
+	// nanodetik), agar sisa pembulatan float tidak menambah satu detik
+	wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	return false, (wait + time.Second - 1).Truncate(time.Second), nil
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(b.capacity, b.tokens+elapsed*b.rate)
+		b.updated = now
+	}
+}
+
+// sweep menghapus bucket yang sudah penuh kembali (sama dengan bucket baru)
+// agar map tidak tumbuh tanpa batas. Dijalankan paling sering sekali per menit.
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= b.capacity {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock adalah jam yang hanya maju saat dipanggil advance
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestLimiter() (*MemoryLimiter, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)}
+	l := NewMemoryLimiter()
+	l.now = clock.now
+	l.lastSweep = clock.t
+	return l, clock
+}
+
+func allow(t *testing.T, l *MemoryLimiter, key string, rule Rule) (bool, time.Duration) {
+	t.Helper()
+
+	allowed, retryAfter, err := l.Allow(key, rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return allowed, retryAfter
+}
+
+func TestMemoryLimiterBurstAndRefill(t *testing.T) {
+	l, clock := newTestLimiter()
+	rule := PerMinute("test", 6) // satu token setiap 10 detik
+
+	for i := 0; i < 6; i++ {
+		if ok, _ := allow(t, l, "a", rule); !ok {
+			t.Fatalf("request ke-%d ditolak, want diizinkan (burst penuh)", i+1)
+		}
+	}
+	if ok, _ := allow(t, l, "a", rule); ok {
+		t.Fatal("request ke-7 diizinkan, want ditolak")
+	}
+
+	// Key lain punya bucket sendiri
+	if ok, _ := allow(t, l, "b", rule); !ok {
+		t.Fatal("key lain ikut dibatasi")
+	}
+
+	clock.advance(9 * time.Second)
+	if ok, _ := allow(t, l, "a", rule); ok {
+		t.Fatal("diizinkan sebelum satu token terisi")
+	}
+
+	clock.advance(time.Second)
+	if ok, _ := allow(t, l, "a", rule); !ok {
+		t.Fatal("ditolak setelah satu token terisi")
+	}
+	if ok, _ := allow(t, l, "a", rule); ok {
+		t.Fatal("token yang terisi dipakai dua kali")
+	}
+
+	// Bucket tidak terisi melebihi kapasitas walau lama tidak dipakai
+	clock.advance(time.Hour)
+	for i := 0; i < 6; i++ {
+		if ok, _ := allow(t, l, "a", rule); !ok {
+			t.Fatalf("request ke-%d setelah refill ditolak", i+1)
+		}
+	}
+	if ok, _ := allow(t, l, "a", rule); ok {
+		t.Fatal("bucket terisi melebihi kapasitas")
+	}
+}
+
+func TestMemoryLimiterBurst(t *testing.T) {
+	l, _ := newTestLimiter()
+	rule := Rule{Name: "test", Requests: 60, Per: time.Minute, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := allow(t, l, "a", rule); !ok {
+			t.Fatalf("request ke-%d ditolak", i+1)
+		}
+	}
+	if ok, _ := allow(t, l, "a", rule); ok {
+		t.Fatal("request melebihi burst diizinkan")
+	}
+}
+
+func TestMemoryLimiterRetryAfter(t *testing.T) {
+	l, clock := newTestLimiter()
+	rule := PerMinute("test", 2) // satu token setiap 30 detik
+
+	allow(t, l, "a", rule)
+	allow(t, l, "a", rule)
+
+	tests := []struct {
+		advance time.Duration
+		want    time.Duration
+	}{
+		{0, 30 * time.Second},
+		{10 * time.Second, 20 * time.Second},
+		{19*time.Second + 500*time.Millisecond, time.Second}, // dibulatkan ke atas
+	}
+
+	for _, tt := range tests {
+		clock.advance(tt.advance)
+		ok, retryAfter := allow(t, l, "a", rule)
+		if ok {
+			t.Fatalf("diizinkan setelah %s, want ditolak", tt.advance)
+		}
+		if retryAfter != tt.want {
+			t.Errorf("retryAfter = %s, want %s", retryAfter, tt.want)
+		}
+	}
+
+	clock.advance(time.Second)
+	if ok, retryAfter := allow(t, l, "a", rule); !ok || retryAfter != 0 {
+		t.Fatalf("Allow = %v, %s setelah Retry-After, want diizinkan", ok, retryAfter)
+	}
+}
+
+func TestMemoryLimiterSweep(t *testing.T) {
+	l, clock := newTestLimiter()
+	rule := PerMinute("test", 6)
+
+	allow(t, l, "idle", rule)
+	for i := 0; i < 6; i++ {
+		allow(t, l, "busy", rule)
+	}
+
+	// Sweep paling sering sekali per menit
+	clock.advance(59 * time.Second)
+	allow(t, l, "other", rule)
+	if len(l.buckets) != 3 {
+		t.Fatalf("bucket = %d sebelum sweep, want 3", len(l.buckets))
+	}
+
+	// Setelah satu menit bucket "idle" dan "other" sudah penuh kembali dan
+	// dihapus; "busy" dipakai lagi sehingga bucket-nya tetap ada
+	clock.advance(time.Second)
+	allow(t, l, "busy", rule)
+	if _, ok := l.buckets["idle"]; ok {
+		t.Error("bucket yang sudah penuh tidak dihapus")
+	}
+	if _, ok := l.buckets["busy"]; !ok {
+		t.Error("bucket yang sedang dipakai ikut dihapus")
+	}
+}
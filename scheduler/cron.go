@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule adalah ekspresi cron 5 field: menit jam hari-bulan bulan hari-minggu.
+// Mendukung "*", daftar ("1,15"), rentang ("1-5") dan step ("*/10", "0-30/5").
+type CronSchedule struct {
+	minutes     map[int]bool
+	hours       map[int]bool
+	daysOfMonth map[int]bool
+	months      map[int]bool
+	daysOfWeek  map[int]bool
+	anyDOM      bool
+	anyDOW      bool
+}
+
+// ParseCron mem-parsing ekspresi cron standar, contoh "0 7 * * *" (setiap hari 07:00)
+// atau "0 7 1 * *" (setiap tanggal 1 pukul 07:00)
+func ParseCron(expr string) (*CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("ekspresi cron harus 5 field, didapat %d", len(fields))
+	}
+
+	bounds := []struct {
+		name     string
+		min, max int
+	}{
+		{"menit", 0, 59},
+		{"jam", 0, 23},
+		{"hari-bulan", 1, 31},
+		{"bulan", 1, 12},
+		{"hari-minggu", 0, 7},
+	}
+
+	sets := make([]map[int]bool, len(fields))
+	for i, field := range fields {
+		set, err := parseCronField(field, bounds[i].min, bounds[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("field %s tidak valid: %w", bounds[i].name, err)
+		}
+		sets[i] = set
+	}
+
+	// 7 dan 0 sama-sama berarti Minggu
+	if sets[4][7] {
+		sets[4][0] = true
+	}
+
+	return &CronSchedule{
+		minutes:     sets[0],
+		hours:       sets[1],
+		daysOfMonth: sets[2],
+		months:      sets[3],
+		daysOfWeek:  sets[4],
+		anyDOM:      fields[2] == "*",
+		anyDOW:      fields[4] == "*",
+	}, nil
+}
+
+// Matches mengecek apakah waktu t (dibulatkan ke menit) cocok dengan jadwal
+func (c *CronSchedule) Matches(t time.Time) bool {
+	if !c.minutes[t.Minute()] || !c.hours[t.Hour()] || !c.months[int(t.Month())] {
+		return false
+	}
+
+	domMatch := c.daysOfMonth[t.Day()]
+	dowMatch := c.daysOfWeek[int(t.Weekday())]
+
+	// Seperti cron standar: jika hari-bulan dan hari-minggu sama-sama dibatasi,
+	// cukup salah satu yang cocok
+	switch {
+	case c.anyDOM && c.anyDOW:
+		return true
+	case c.anyDOM:
+		return dowMatch
+	case c.anyDOW:
+		return domMatch
+	default:
+		return domMatch || dowMatch
+	}
+}
+
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	set := make(map[int]bool)
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[idx+1:])
+			if err != nil || step < 1 {
+				return nil, fmt.Errorf("step %q tidak valid", part[idx+1:])
+			}
+			part = part[:idx]
+		}
+
+		start, end := min, max
+		switch {
+		case part == "*":
+			// seluruh rentang
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err1, err2 error
+			start, err1 = strconv.Atoi(bounds[0])
+			end, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("rentang %q tidak valid", part)
+			}
+		default:
+			value, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("nilai %q tidak valid", part)
+			}
+			start = value
+			end = value
+			if step > 1 {
+				end = max
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return nil, fmt.Errorf("nilai %q di luar rentang %d-%d", part, min, max)
+		}
+
+		for v := start; v <= end; v += step {
+			set[v] = true
+		}
+	}
+
+	return set, nil
+}
@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"1-x * * * *",
+		"a * * * *",
+		"1,,2 * * * *",
+	}
+
+	for _, expr := range tests {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) tidak mengembalikan error", expr)
+		}
+	}
+}
+
+func TestCronMatches(t *testing.T) {
+	// 2026-03-02 adalah hari Senin
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 3, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		time time.Time
+		want bool
+	}{
+		{"setiap menit", "* * * * *", at(2, 13, 37), true},
+		{"jam tepat", "0 7 * * *", at(2, 7, 0), true},
+		{"jam lain", "0 7 * * *", at(2, 8, 0), false},
+		{"menit lain", "0 7 * * *", at(2, 7, 1), false},
+
+		{"daftar cocok", "0,15,30 * * * *", at(2, 9, 15), true},
+		{"daftar tidak cocok", "0,15,30 * * * *", at(2, 9, 20), false},
+		{"rentang awal", "* 9-17 * * *", at(2, 9, 0), true},
+		{"rentang akhir", "* 9-17 * * *", at(2, 17, 59), true},
+		{"di luar rentang", "* 9-17 * * *", at(2, 18, 0), false},
+		{"step dari bintang", "*/10 * * * *", at(2, 9, 40), true},
+		{"step dari bintang tidak cocok", "*/10 * * * *", at(2, 9, 45), false},
+		{"step dalam rentang", "0-30/15 * * * *", at(2, 9, 30), true},
+		{"step melewati akhir rentang", "0-30/15 * * * *", at(2, 9, 45), false},
+		{"step dari nilai", "5/20 * * * *", at(2, 9, 45), true},
+		{"step dari nilai tidak cocok", "5/20 * * * *", at(2, 9, 0), false},
+		{"daftar campuran", "0 8,12-14/2 * * *", at(2, 14, 0), true},
+		{"daftar campuran tidak cocok", "0 8,12-14/2 * * *", at(2, 13, 0), false},
+
+		{"bulan cocok", "0 7 1 3 *", at(1, 7, 0), true},
+		{"bulan lain", "0 7 1 4 *", at(1, 7, 0), false},
+
+		// Hanya hari-bulan yang dibatasi
+		{"hari-bulan cocok", "0 7 1 * *", at(1, 7, 0), true},
+		{"hari-bulan tidak cocok", "0 7 1 * *", at(2, 7, 0), false},
+		// Hanya hari-minggu yang dibatasi
+		{"hari kerja senin", "0 7 * * 1-5", at(2, 7, 0), true},
+		{"hari kerja minggu", "0 7 * * 1-5", at(8, 7, 0), false},
+		{"minggu sebagai 0", "0 7 * * 0", at(8, 7, 0), true},
+		{"minggu sebagai 7", "0 7 * * 7", at(8, 7, 0), true},
+		// Keduanya dibatasi: cukup salah satu yang cocok
+		{"hari-bulan atau hari-minggu (tanggal)", "0 7 15 * 1", at(15, 7, 0), true},
+		{"hari-bulan atau hari-minggu (senin)", "0 7 15 * 1", at(2, 7, 0), true},
+		{"hari-bulan atau hari-minggu (tidak keduanya)", "0 7 15 * 1", at(3, 7, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			if got := schedule.Matches(tt.time); got != tt.want {
+				t.Fatalf("%q Matches(%s) = %v, want %v", tt.expr, tt.time.Format("Mon 2006-01-02 15:04"), got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package scheduler
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Every menjalankan job secara berkala di goroutine terpisah.
+// Error dari job hanya dicatat ke log agar job berikutnya tetap berjalan.
+func Every(name string, interval time.Duration, job func() error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			run(name, job)
+		}
+	}()
+
+	slog.Info("Scheduler: job dijadwalkan", "job", name, "interval", interval.String())
+}
+
+func run(name string, job func() error) {
+	// Jangan biarkan panic di job mematikan server
+	defer func() {
+		if rec := recover(); rec != nil {
+			slog.Error("Scheduler: job panic", "job", name, "panic", fmt.Sprint(rec))
+		}
+	}()
+
+	if err := job(); err != nil {
+		slog.Error("Scheduler: job gagal", "job", name, "error", err)
+	}
+}
+
+// EveryMinute menjalankan job tepat di awal setiap menit. Waktu yang diberikan ke job
+// sudah dibulatkan ke menit, sehingga cocok untuk mengevaluasi jadwal cron.
+func EveryMinute(name string, job func(minute time.Time) error) {
+	go func() {
+		for {
+			now := time.Now()
+			next := now.Truncate(time.Minute).Add(time.Minute)
+			time.Sleep(next.Sub(now))
+
+			run(name, func() error { return job(next) })
+		}
+	}()
+
+	slog.Info("Scheduler: job dijadwalkan", "job", name, "interval", "1m")
+}
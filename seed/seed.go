@@ -51,9 +51,17 @@ func main() {
 
 	// Seed data
 	seedDepartments(db)
+	seedRolesAndPermissions(db)
 	seedUsers(db)
+	seedUserRoles(db)
+	seedDepartmentManagers(db)
 	seedWorkHours(db)
+	seedLatePolicy(db)
 	seedAttendance(db)
+	seedHolidays(db)
+	seedLeaveRequests(db)
+	seedReportSubscriptions(db)
+	seedSystemSettings(db)
 
 	fmt.Println("🌱 Migrate Fresh & Seeding selesai!")
 }
@@ -62,7 +70,7 @@ func dropTables(db *sql.DB) {
 	fmt.Println("🗑️  Menghapus tabel yang ada...")
 
 	// Drop tables dalam urutan terbalik (karena foreign key constraints)
-	tables := []string{"attendance_tokens", "users", "departments", "work_hours"}
+	tables := []string{"audit_logs", "api_key_permissions", "api_keys", "user_sessions", "recovery_codes", "security_events", "login_attempts", "invitations", "password_reset_tokens", "system_settings", "user_roles", "role_permissions", "permissions", "roles", "attendance_corrections", "report_subscriptions", "late_policy_tiers", "late_policies", "attendance_anomalies", "attendance_token_checks", "leave_requests", "holidays", "attendance_tokens", "users", "departments", "work_hours"}
 	for _, table := range tables {
 		_, err := db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s CASCADE", table))
 		if err != nil {
@@ -94,8 +102,18 @@ func createTables(db *sql.DB) {
 			phone TEXT,
 			position TEXT,
 			department_id INTEGER NOT NULL REFERENCES departments(id) ON DELETE RESTRICT,
-			status TEXT NOT NULL CHECK (status IN ('active', 'inactive')),
+			status TEXT NOT NULL CHECK (status IN ('active', 'inactive', 'pending')),
 			password_hash TEXT NOT NULL,
+			must_change_password BOOLEAN NOT NULL DEFAULT false,
+			password_changed_at TIMESTAMP,
+			email_verified_at TIMESTAMP,
+			failed_login_count INTEGER NOT NULL DEFAULT 0,
+			locked_until TIMESTAMP,
+			totp_secret TEXT,
+			totp_enabled_at TIMESTAMP,
+			totp_last_counter BIGINT,
+			oidc_subject TEXT UNIQUE,
+			auth_source TEXT NOT NULL DEFAULT 'local' CHECK (auth_source IN ('local', 'ldap')),
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		);
 	`)
@@ -103,6 +121,228 @@ func createTables(db *sql.DB) {
 		log.Fatal("Gagal membuat tabel users:", err)
 	}
 
+	// Email dibandingkan tanpa membedakan huruf besar/kecil (lihat controllers.NormalizeEmail)
+	_, err = db.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users (LOWER(email));
+	`)
+	if err != nil {
+		log.Fatal("Gagal membuat index email users:", err)
+	}
+
+	// Manager departemen (ditambahkan setelah tabel users ada)
+	_, err = db.Exec(`
+		ALTER TABLE departments
+		ADD COLUMN IF NOT EXISTS manager_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+	`)
+	if err != nil {
+		log.Fatal("Gagal menambahkan manager_id pada departments:", err)
+	}
+
+	// Tabel roles, permissions dan relasinya (RBAC)
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS roles (
+			id SERIAL PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			description TEXT,
+			inherits_role_id INTEGER REFERENCES roles(id) ON DELETE SET NULL,
+			require_2fa BOOLEAN NOT NULL DEFAULT false,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS permissions (
+			id SERIAL PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			description TEXT
+		);
+
+		CREATE TABLE IF NOT EXISTS role_permissions (
+			role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+			permission_id INTEGER NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+			PRIMARY KEY (role_id, permission_id)
+		);
+
+		CREATE TABLE IF NOT EXISTS user_roles (
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, role_id)
+		);
+	`)
+	if err != nil {
+		log.Fatal("Gagal membuat tabel RBAC:", err)
+	}
+
+	// Tabel password_reset_tokens (token reset password sekali pakai, disimpan sebagai hash SHA-256)
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS password_reset_tokens (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			token_hash TEXT UNIQUE NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			used_at TIMESTAMP,
+			requested_ip TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		log.Fatal("Gagal membuat tabel password_reset_tokens:", err)
+	}
+
+	// Tabel invitations (undangan onboarding user baru, disimpan sebagai hash SHA-256)
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS invitations (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			token_hash TEXT UNIQUE NOT NULL,
+			invited_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			expires_at TIMESTAMP NOT NULL,
+			accepted_at TIMESTAMP,
+			revoked_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		log.Fatal("Gagal membuat tabel invitations:", err)
+	}
+
+	// Tabel login_attempts (riwayat login untuk proteksi brute-force, berlaku lintas instance)
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS login_attempts (
+			id SERIAL PRIMARY KEY,
+			email TEXT NOT NULL,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			ip TEXT NOT NULL,
+			success BOOLEAN NOT NULL,
+			attempted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_login_attempts_ip ON login_attempts (ip, attempted_at);
+		CREATE INDEX IF NOT EXISTS idx_login_attempts_user ON login_attempts (user_id, attempted_at);
+	`)
+	if err != nil {
+		log.Fatal("Gagal membuat tabel login_attempts:", err)
+	}
+
+	// Tabel security_events (jejak audit event keamanan: lock akun, blokir IP, dll)
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS security_events (
+			id SERIAL PRIMARY KEY,
+			event_type TEXT NOT NULL,
+			user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			ip TEXT,
+			details TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		log.Fatal("Gagal membuat tabel security_events:", err)
+	}
+
+	// Tabel recovery_codes (kode cadangan 2FA sekali pakai, disimpan sebagai hash SHA-256)
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS recovery_codes (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			code_hash TEXT NOT NULL,
+			used_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (user_id, code_hash)
+		);
+	`)
+	if err != nil {
+		log.Fatal("Gagal membuat tabel recovery_codes:", err)
+	}
+
+	// Tabel user_sessions (session login di server; cookie hanya berisi token,
+	// database menyimpan hash-nya)
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS user_sessions (
+			id SERIAL PRIMARY KEY,
+			token_hash TEXT NOT NULL UNIQUE,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			data BYTEA NOT NULL,
+			user_agent TEXT,
+			ip TEXT,
+			created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			last_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			expires_at TIMESTAMP NOT NULL,
+			revoked_at TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_user_sessions_user ON user_sessions (user_id) WHERE revoked_at IS NULL;
+	`)
+	if err != nil {
+		log.Fatal("Gagal membuat tabel user_sessions:", err)
+	}
+
+	// Tabel api_keys (personal access token jika user_id diisi, service API key
+	// jika tidak). Key asli tidak disimpan, hanya hash SHA-256 dan prefiksnya.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS api_keys (
+			id SERIAL PRIMARY KEY,
+			name TEXT NOT NULL,
+			key_prefix TEXT NOT NULL,
+			key_hash TEXT NOT NULL UNIQUE,
+			user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+			created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			expires_at TIMESTAMP NOT NULL,
+			last_used_at TIMESTAMP,
+			last_used_ip TEXT,
+			revoked_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS api_key_permissions (
+			api_key_id INTEGER NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+			permission_id INTEGER NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+			PRIMARY KEY (api_key_id, permission_id)
+		);
+	`)
+	if err != nil {
+		log.Fatal("Gagal membuat tabel api_keys:", err)
+	}
+
+	// Tabel audit_logs (jejak perubahan data: siapa, melakukan apa, terhadap data
+	// apa, nilai sebelum dan sesudah). Sengaja tanpa foreign key agar baris audit
+	// tidak ikut berubah/terhapus saat user dihapus. Setiap baris menyimpan hash
+	// baris sebelumnya (hash chain) sehingga perubahan data lama bisa dideteksi;
+	// kolom JSON (bukan JSONB) menyimpan teks persis seperti saat di-hash.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS audit_logs (
+			id BIGSERIAL PRIMARY KEY,
+			actor_id INTEGER,
+			action TEXT NOT NULL,
+			entity_type TEXT NOT NULL,
+			entity_id INTEGER,
+			before_data JSON,
+			after_data JSON,
+			ip TEXT,
+			created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+			prev_hash TEXT NOT NULL,
+			hash TEXT NOT NULL UNIQUE
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_audit_logs_entity ON audit_logs (entity_type, entity_id, created_at);
+		CREATE INDEX IF NOT EXISTS idx_audit_logs_actor ON audit_logs (actor_id, created_at);
+		CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
+	`)
+	if err != nil {
+		log.Fatal("Gagal membuat tabel audit_logs:", err)
+	}
+
+	// Tabel system_settings (pengaturan aplikasi yang dikelola Admin)
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS system_settings (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL,
+			updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		log.Fatal("Gagal membuat tabel system_settings:", err)
+	}
+
 	// Tabel attendance_tokens
 	_, err = db.Exec(`
 		CREATE TABLE IF NOT EXISTS attendance_tokens (
@@ -111,6 +351,9 @@ func createTables(db *sql.DB) {
 			token TEXT UNIQUE NOT NULL,
 			expired_at TIMESTAMP NOT NULL,
 			is_used BOOLEAN DEFAULT false,
+			redeemed_at TIMESTAMP,
+			redeemed_by INTEGER REFERENCES users(id),
+			redeemed_ip TEXT,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		);
 	`)
@@ -118,6 +361,22 @@ func createTables(db *sql.DB) {
 		log.Fatal("Gagal membuat tabel attendance_tokens:", err)
 	}
 
+	// Tabel attendance_token_checks (riwayat pengecekan token untuk deteksi anomali)
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS attendance_token_checks (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER,
+			token TEXT NOT NULL,
+			checked_by INTEGER REFERENCES users(id),
+			ip TEXT,
+			checked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_attendance_token_checks_checked_at ON attendance_token_checks (checked_at);
+	`)
+	if err != nil {
+		log.Fatal("Gagal membuat tabel attendance_token_checks:", err)
+	}
+
 	// Tabel work_hours
 	_, err = db.Exec(`
 		CREATE TABLE IF NOT EXISTS work_hours (
@@ -133,7 +392,127 @@ func createTables(db *sql.DB) {
 		log.Fatal("Gagal membuat tabel work_hours:", err)
 	}
 
-	fmt.Println("✅ Semua tabel siap (departments, users, attendance_tokens, work_hours)")
+	// Tabel late_policies (kebijakan perhitungan keterlambatan, baris terbaru yang berlaku)
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS late_policies (
+			id SERIAL PRIMARY KEY,
+			measure_from TEXT NOT NULL DEFAULT 'tolerance' CHECK (measure_from IN ('start', 'tolerance')),
+			grace_minutes INTEGER NOT NULL DEFAULT 0 CHECK (grace_minutes >= 0),
+			rounding_mode TEXT NOT NULL DEFAULT 'floor' CHECK (rounding_mode IN ('floor', 'ceil', 'nearest')),
+			rounding_unit INTEGER NOT NULL DEFAULT 1 CHECK (rounding_unit > 0),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		log.Fatal("Gagal membuat tabel late_policies:", err)
+	}
+
+	// Tabel late_policy_tiers (kategori keterlambatan per rentang menit)
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS late_policy_tiers (
+			id SERIAL PRIMARY KEY,
+			policy_id INTEGER NOT NULL REFERENCES late_policies(id) ON DELETE CASCADE,
+			label TEXT NOT NULL,
+			min_minutes INTEGER NOT NULL CHECK (min_minutes >= 0),
+			max_minutes INTEGER CHECK (max_minutes IS NULL OR max_minutes > min_minutes)
+		);
+	`)
+	if err != nil {
+		log.Fatal("Gagal membuat tabel late_policy_tiers:", err)
+	}
+
+	// Tabel holidays (hari libur, tidak dihitung absen)
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS holidays (
+			id SERIAL PRIMARY KEY,
+			date DATE UNIQUE NOT NULL,
+			name TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		log.Fatal("Gagal membuat tabel holidays:", err)
+	}
+
+	// Tabel leave_requests (cuti karyawan)
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS leave_requests (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users(id),
+			start_date DATE NOT NULL,
+			end_date DATE NOT NULL,
+			reason TEXT,
+			status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+			review_note TEXT,
+			reviewed_by INTEGER REFERENCES users(id),
+			reviewed_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			CHECK (end_date >= start_date)
+		);
+	`)
+	if err != nil {
+		log.Fatal("Gagal membuat tabel leave_requests:", err)
+	}
+
+	// Tabel attendance_anomalies (temuan deteksi anomali untuk direview HR)
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS attendance_anomalies (
+			id SERIAL PRIMARY KEY,
+			anomaly_type TEXT NOT NULL CHECK (anomaly_type IN ('burst_redemption', 'repeated_token_check', 'tolerance_edge', 'non_working_day')),
+			user_id INTEGER REFERENCES users(id),
+			anomaly_date DATE NOT NULL,
+			fingerprint TEXT UNIQUE NOT NULL,
+			details TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'confirmed', 'dismissed')),
+			review_note TEXT,
+			reviewed_by INTEGER REFERENCES users(id),
+			reviewed_at TIMESTAMP,
+			detected_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		log.Fatal("Gagal membuat tabel attendance_anomalies:", err)
+	}
+
+	// Tabel attendance_corrections (pengajuan koreksi absensi, misalnya lupa check-in)
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS attendance_corrections (
+			id SERIAL PRIMARY KEY,
+			user_id INTEGER NOT NULL REFERENCES users(id),
+			date DATE NOT NULL,
+			requested_check_in TIME NOT NULL,
+			reason TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+			review_note TEXT,
+			reviewed_by INTEGER REFERENCES users(id),
+			reviewed_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		log.Fatal("Gagal membuat tabel attendance_corrections:", err)
+	}
+
+	// Tabel report_subscriptions (laporan yang dikirim otomatis via email)
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS report_subscriptions (
+			id SERIAL PRIMARY KEY,
+			name TEXT NOT NULL,
+			recipients TEXT[] NOT NULL,
+			report_type TEXT NOT NULL CHECK (report_type IN ('daily_summary', 'monthly_report')),
+			cron_expression TEXT NOT NULL,
+			is_active BOOLEAN NOT NULL DEFAULT true,
+			last_run_at TIMESTAMP,
+			failed_run_at TIMESTAMP,
+			created_by INTEGER REFERENCES users(id),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		log.Fatal("Gagal membuat tabel report_subscriptions:", err)
+	}
+
+	fmt.Println("✅ Semua tabel siap (departments, users, attendance_tokens, work_hours, holidays, leave_requests, attendance_token_checks, attendance_anomalies, late_policies, report_subscriptions, attendance_corrections)")
 }
 
 func seedDepartments(db *sql.DB) {
@@ -189,8 +568,8 @@ func seedUsers(db *sql.DB) {
 		}
 
 		_, err = db.Exec(`
-		INSERT INTO users (name, email, phone, position, department_id, status, password_hash)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO users (name, email, phone, position, department_id, status, password_hash, email_verified_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
 		ON CONFLICT (email) DO NOTHING;
 	`, u.Name, u.Email, u.Phone, u.Position, deptID, u.Status, string(hashedPassword))
 
@@ -201,6 +580,127 @@ func seedUsers(db *sql.DB) {
 	fmt.Println("✅ Pengguna disisipkan")
 }
 
+func seedRolesAndPermissions(db *sql.DB) {
+	permissions := []struct {
+		Name        string
+		Description string
+	}{
+		{"attendance.report.read", "Melihat laporan absensi seluruh karyawan"},
+		{"attendance.anomaly.review", "Melihat dan mereview anomali absensi"},
+		{"audit.read", "Melihat audit log perubahan data"},
+		{"attendance.scan", "Mengecek dan menukar token absensi milik karyawan lain (scanner/kiosk)"},
+		{"users.read", "Melihat data karyawan"},
+		{"users.write", "Membuat dan mengubah data karyawan"},
+		{"departments.read", "Melihat daftar departemen"},
+		{"late_policy.write", "Mengubah kebijakan keterlambatan"},
+		{"reports.manage", "Mengatur laporan terjadwal via email"},
+		{"departments.write", "Membuat, mengubah dan menghapus departemen"},
+		{"roles.manage", "Mengatur role dan permission user"},
+		{"settings.write", "Mengubah jam kerja dan pengaturan sistem"},
+		{"team.attendance.read", "Melihat absensi anggota tim (departemen yang dipimpin)"},
+		{"team.requests.approve", "Menyetujui pengajuan cuti dan koreksi absensi"},
+		{"requests.approve.all", "Menyetujui pengajuan cuti dan koreksi absensi seluruh karyawan"},
+	}
+
+	for _, p := range permissions {
+		_, err := db.Exec(`
+			INSERT INTO permissions (name, description)
+			VALUES ($1, $2)
+			ON CONFLICT (name) DO NOTHING;
+		`, p.Name, p.Description)
+		if err != nil {
+			log.Printf("Gagal menyisipkan permission %s: %v", p.Name, err)
+		}
+	}
+
+	// Urutan penting: role yang diwarisi harus dibuat lebih dulu.
+	// Admin mewarisi semua permission HR, HR mewarisi Employee.
+	roles := []struct {
+		Name        string
+		Description string
+		Inherits    string
+		Permissions []string
+	}{
+		{"Employee", "Karyawan", "", nil},
+		{"Manager", "Kepala departemen", "Employee", []string{
+			"team.attendance.read", "team.requests.approve",
+		}},
+		{"HR", "Human Resources", "Employee", []string{
+			"attendance.report.read", "attendance.anomaly.review", "attendance.scan", "audit.read", "users.read", "users.write",
+			"departments.read", "late_policy.write", "reports.manage",
+			"team.requests.approve", "requests.approve.all",
+		}},
+		{"Admin", "System Administrator", "HR", []string{
+			"departments.write", "roles.manage", "settings.write",
+		}},
+	}
+
+	for _, role := range roles {
+		_, err := db.Exec(`
+			INSERT INTO roles (name, description, inherits_role_id)
+			VALUES ($1, $2, (SELECT id FROM roles WHERE name = NULLIF($3, '')))
+			ON CONFLICT (name) DO NOTHING;
+		`, role.Name, role.Description, role.Inherits)
+		if err != nil {
+			log.Printf("Gagal menyisipkan role %s: %v", role.Name, err)
+			continue
+		}
+
+		for _, permission := range role.Permissions {
+			_, err := db.Exec(`
+				INSERT INTO role_permissions (role_id, permission_id)
+				SELECT r.id, p.id FROM roles r, permissions p
+				WHERE r.name = $1 AND p.name = $2
+				ON CONFLICT DO NOTHING;
+			`, role.Name, permission)
+			if err != nil {
+				log.Printf("Gagal menyisipkan permission %s untuk role %s: %v", permission, role.Name, err)
+			}
+		}
+	}
+	fmt.Println("✅ Roles & permissions disisipkan")
+}
+
+func seedUserRoles(db *sql.DB) {
+	// Semua user adalah Employee, lalu role tambahan berdasarkan departemen awal
+	_, err := db.Exec(`
+		INSERT INTO user_roles (user_id, role_id)
+		SELECT u.id, r.id FROM users u, roles r WHERE r.name = 'Employee'
+		ON CONFLICT DO NOTHING;
+
+		INSERT INTO user_roles (user_id, role_id)
+		SELECT u.id, r.id
+		FROM users u
+		JOIN departments d ON d.id = u.department_id
+		JOIN roles r ON (d.name = 'HR' AND r.name = 'HR') OR (d.name = 'Administrator' AND r.name = 'Admin')
+		ON CONFLICT DO NOTHING;
+	`)
+	if err != nil {
+		log.Printf("Gagal menyisipkan user roles: %v", err)
+		return
+	}
+	fmt.Println("✅ User roles disisipkan")
+}
+
+func seedDepartmentManagers(db *sql.DB) {
+	// Ahmad Fauzi menjadi manager departemen IT (tim: Andi Pratama)
+	_, err := db.Exec(`
+		UPDATE departments
+		SET manager_id = (SELECT id FROM users WHERE email = 'ahmad.fauzi@company.com')
+		WHERE name = 'IT';
+
+		INSERT INTO user_roles (user_id, role_id)
+		SELECT u.id, r.id FROM users u, roles r
+		WHERE u.email = 'ahmad.fauzi@company.com' AND r.name = 'Manager'
+		ON CONFLICT DO NOTHING;
+	`)
+	if err != nil {
+		log.Printf("Gagal menyisipkan manager departemen: %v", err)
+		return
+	}
+	fmt.Println("✅ Manager departemen disisipkan")
+}
+
 func seedWorkHours(db *sql.DB) {
 	// Set jam kerja global: 08:00 - 17:00 dengan toleransi sampai 08:15
 	_, err := db.Exec(`
@@ -214,6 +714,43 @@ func seedWorkHours(db *sql.DB) {
 	fmt.Println("✅ Work hours disisipkan (08:00 - 17:00, toleransi sampai 08:15)")
 }
 
+func seedLatePolicy(db *sql.DB) {
+	// Default: sama dengan perilaku lama (dihitung dari tolerance_time, dibulatkan ke bawah per menit)
+	var policyID int
+	err := db.QueryRow(`
+		INSERT INTO late_policies (measure_from, grace_minutes, rounding_mode, rounding_unit)
+		VALUES ('tolerance', 0, 'floor', 1)
+		RETURNING id;
+	`).Scan(&policyID)
+	if err != nil {
+		log.Printf("Gagal menyisipkan late policy: %v", err)
+		return
+	}
+
+	fifteen, thirty, sixty := 15, 30, 60
+	tiers := []struct {
+		Label string
+		Min   int
+		Max   *int
+	}{
+		{"late < 15", 0, &fifteen},
+		{"late 15–30", 15, &thirty},
+		{"late 30–60", 30, &sixty},
+		{"late > 60", 60, nil},
+	}
+
+	for _, t := range tiers {
+		_, err := db.Exec(`
+			INSERT INTO late_policy_tiers (policy_id, label, min_minutes, max_minutes)
+			VALUES ($1, $2, $3, $4);
+		`, policyID, t.Label, t.Min, t.Max)
+		if err != nil {
+			log.Printf("Gagal menyisipkan late policy tier %s: %v", t.Label, err)
+		}
+	}
+	fmt.Println("✅ Late policy disisipkan (dari tolerance_time, floor per menit)")
+}
+
 func seedAttendance(db *sql.DB) {
 	// Seed attendance data for user ID 1 (Ahmad Fauzi) from Jan 1 to Feb 15, 2026
 	// Some days on-time, some late, some absent
@@ -282,6 +819,64 @@ func seedAttendance(db *sql.DB) {
 	fmt.Printf("✅ Attendance data disisipkan untuk user ID 1 (%d records)\n", len(attendanceData))
 }
 
+func seedHolidays(db *sql.DB) {
+	holidays := []struct {
+		Date string
+		Name string
+	}{
+		{"2026-01-01", "Tahun Baru Masehi"},
+		{"2026-01-16", "Isra Mikraj Nabi Muhammad SAW"},
+		{"2026-02-17", "Tahun Baru Imlek"},
+	}
+
+	for _, h := range holidays {
+		_, err := db.Exec(`
+			INSERT INTO holidays (date, name)
+			VALUES ($1, $2)
+			ON CONFLICT (date) DO NOTHING;
+		`, h.Date, h.Name)
+		if err != nil {
+			log.Printf("Gagal menyisipkan hari libur %s: %v", h.Date, err)
+		}
+	}
+	fmt.Printf("✅ Hari libur disisipkan (%d records)\n", len(holidays))
+}
+
+func seedLeaveRequests(db *sql.DB) {
+	// Cuti yang sudah disetujui untuk user ID 2 (Ahmad Fauzi)
+	_, err := db.Exec(`
+		INSERT INTO leave_requests (user_id, start_date, end_date, reason, status)
+		VALUES ($1, $2, $3, $4, $5)
+	`, 2, "2026-02-09", "2026-02-09", "Keperluan keluarga", "approved")
+	if err != nil {
+		log.Printf("Gagal menyisipkan leave request: %v", err)
+		return
+	}
+	fmt.Println("✅ Leave request disisipkan untuk user ID 2")
+}
+
+func seedReportSubscriptions(db *sql.DB) {
+	subscriptions := []struct {
+		Name       string
+		ReportType string
+		Cron       string
+	}{
+		{"Rekap harian untuk HR", "daily_summary", "0 7 * * *"},
+		{"Laporan bulanan untuk HR", "monthly_report", "0 7 1 * *"},
+	}
+
+	for _, sub := range subscriptions {
+		_, err := db.Exec(`
+			INSERT INTO report_subscriptions (name, recipients, report_type, cron_expression)
+			VALUES ($1, ARRAY['rina.wijaya@company.com'], $2, $3);
+		`, sub.Name, sub.ReportType, sub.Cron)
+		if err != nil {
+			log.Printf("Gagal menyisipkan report subscription %s: %v", sub.Name, err)
+		}
+	}
+	fmt.Println("✅ Report subscriptions disisipkan")
+}
+
 func generateRandomToken() string {
 	bytes := make([]byte, 8)
 	if _, err := rand.Read(bytes); err != nil {
@@ -289,3 +884,22 @@ func generateRandomToken() string {
 	}
 	return hex.EncodeToString(bytes)
 }
+
+func seedSystemSettings(db *sql.DB) {
+	settings := map[string]string{
+		"company_name": "PT Contoh Indonesia",
+		"timezone":     "Asia/Jakarta",
+	}
+
+	for key, value := range settings {
+		_, err := db.Exec(`
+			INSERT INTO system_settings (key, value)
+			VALUES ($1, $2)
+			ON CONFLICT (key) DO NOTHING;
+		`, key, value)
+		if err != nil {
+			log.Printf("Gagal menyisipkan setting %s: %v", key, err)
+		}
+	}
+	fmt.Println("✅ System settings disisipkan")
+}
@@ -0,0 +1,132 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret adalah secret ASCII "12345678901234567890" dari RFC 4226/6238 dalam base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// Kode 6 digit adalah 6 digit terakhir dari nilai 8 digit di RFC 6238 Appendix B (SHA1)
+var rfc6238Vectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestGenerateRFC4226Vectors(t *testing.T) {
+	// RFC 4226 Appendix D
+	expected := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+
+	key := []byte("12345678901234567890")
+	for counter, want := range expected {
+		if got := generate(key, int64(counter)); got != want {
+			t.Errorf("generate(counter=%d) = %s, want %s", counter, got, want)
+		}
+	}
+}
+
+func TestValidateRFC6238Vectors(t *testing.T) {
+	for _, v := range rfc6238Vectors {
+		at := time.Unix(v.unix, 0).UTC()
+
+		counter, ok := Validate(v.code, rfcSecret, at)
+		if !ok {
+			t.Errorf("Validate(%s) at %d ditolak", v.code, v.unix)
+			continue
+		}
+		if want := v.unix / 30; counter != want {
+			t.Errorf("Validate(%s) at %d counter = %d, want %d", v.code, v.unix, counter, want)
+		}
+	}
+}
+
+func TestCodeRFC6238Vectors(t *testing.T) {
+	for _, v := range rfc6238Vectors {
+		code, err := Code(rfcSecret, time.Unix(v.unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != v.code {
+			t.Errorf("Code at %d = %s, want %s", v.unix, code, v.code)
+		}
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	at := time.Unix(1111111111, 0)
+	key, err := encoding.DecodeString(rfcSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := at.Unix() / 30
+
+	tests := []struct {
+		name   string
+		offset int64
+		valid  bool
+	}{
+		{"periode sebelumnya", -1, true},
+		{"periode sekarang", 0, true},
+		{"periode berikutnya", 1, true},
+		{"dua periode sebelumnya", -2, false},
+		{"dua periode berikutnya", 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := generate(key, current+tt.offset)
+
+			counter, ok := Validate(code, rfcSecret, at)
+			if ok != tt.valid {
+				t.Fatalf("Validate valid = %v, want %v", ok, tt.valid)
+			}
+			if ok && counter != current+tt.offset {
+				t.Errorf("counter = %d, want %d", counter, current+tt.offset)
+			}
+		})
+	}
+}
+
+// Kode yang sama tetap menghasilkan counter yang sama selama masih dalam skew,
+// sehingga pengecekan totp_last_counter < counter menolak pemakaian ulang
+func TestValidateReplayReturnsSameCounter(t *testing.T) {
+	at := time.Unix(1234567890, 0)
+
+	first, ok := Validate("005924", rfcSecret, at)
+	if !ok {
+		t.Fatal("kode pertama ditolak")
+	}
+
+	second, ok := Validate("005924", rfcSecret, at.Add(period))
+	if !ok {
+		t.Fatal("kode masih dalam skew ditolak")
+	}
+	if second != first {
+		t.Errorf("counter pemakaian ulang = %d, want %d", second, first)
+	}
+}
+
+func TestValidateRejectsMalformedInput(t *testing.T) {
+	at := time.Unix(59, 0)
+
+	for _, code := range []string{"", "28708", "2870820", "abcdef"} {
+		if _, ok := Validate(code, rfcSecret, at); ok {
+			t.Errorf("Validate(%q) diterima", code)
+		}
+	}
+
+	if _, ok := Validate("287082", "bukan-base32!", at); ok {
+		t.Error("secret tidak valid diterima")
+	}
+}
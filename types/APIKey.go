@@ -0,0 +1,39 @@
+package types
+
+import "time"
+
+// APIKey adalah personal access token (terikat ke user) atau service API key
+// (tanpa user) untuk integrasi seperti payroll dan BI
+type APIKey struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`    // awal key, untuk mengenali key tanpa menyimpan key asli
+	UserID      *int       `json:"user_id"`   // nil untuk service API key
+	UserName    *string    `json:"user_name"` // pemilik personal access token
+	Permissions []string   `json:"permissions"`
+	CreatedBy   *int       `json:"created_by"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	LastUsedIP  *string    `json:"last_used_ip"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name"`
+	UserID        *int     `json:"user_id"` // diisi untuk personal access token
+	Permissions   []string `json:"permissions"`
+	ExpiresInDays int      `json:"expires_in_days"` // default 90, maksimal 365
+}
+
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"` // hanya ditampilkan sekali, database hanya menyimpan hash-nya
+}
+
+// APIKeyPrincipal adalah identitas request yang memakai Authorization: Bearer
+type APIKeyPrincipal struct {
+	KeyID       int
+	UserID      int // 0 untuk service API key
+	Permissions []string
+}
@@ -25,11 +25,13 @@ type CheckAttendanceTokenResponse struct {
 	Valid      bool       `json:"valid"`
 	Is_Used    *bool      `json:"is_used"`
 	Expired_At *time.Time `json:"expired_at"`
+	Code       string     `json:"code"` // kode hasil yang stabil, misalnya "token_expired"
 	Message    string     `json:"message,omitempty"`
 }
 
 type SubmitAttendanceResponse struct {
 	Success bool   `json:"success"`
+	Code    string `json:"code"` // kode hasil yang stabil, misalnya "token_already_used"
 	Message string `json:"message"`
 	UserID  int    `json:"user_id"`
 }
@@ -52,6 +54,7 @@ type AbsentUser struct {
 	UserEmail      string `json:"user_email"`
 	DepartmentName string `json:"department_name"`
 	Position       string `json:"position"`
+	AbsentDays     int    `json:"absent_days,omitempty"` // hanya diisi pada rekap bulanan
 }
 
 type TodayAttendanceListResponse struct {
@@ -64,13 +67,38 @@ type TodayAttendanceListResponse struct {
 }
 
 type MonthlyAttendanceListResponse struct {
-	Month       string            `json:"month"`
-	Year        string            `json:"year"`
-	TotalAttend int               `json:"total_attend"`
-	TotalLate   int               `json:"total_late"`
-	TotalAbsent int               `json:"total_absent"`
-	Attendances []TodayAttendance `json:"attendances"`
-	AbsentUsers []AbsentUser      `json:"absent_users"`
+	Month       string                       `json:"month"`
+	Year        string                       `json:"year"`
+	TotalAttend int                          `json:"total_attend"`
+	TotalLate   int                          `json:"total_late"`
+	TotalAbsent int                          `json:"total_absent"` // total hari absen seluruh user
+	WorkingDays []string                     `json:"working_days"` // kolom matriks (YYYY-MM-DD)
+	Matrix      []MonthlyAttendanceMatrixRow `json:"matrix"`
+	Attendances []TodayAttendance            `json:"attendances"`
+	AbsentUsers []AbsentUser                 `json:"absent_users"` // user dengan minimal 1 hari absen
+}
+
+// MonthlyAttendanceMatrixRow adalah satu baris matriks absensi bulanan (satu user)
+type MonthlyAttendanceMatrixRow struct {
+	UserID         int    `json:"user_id"`
+	UserName       string `json:"user_name"`
+	UserEmail      string `json:"user_email"`
+	DepartmentName string `json:"department_name"`
+	Position       string `json:"position"`
+	// tanggal -> "present", "late", "absent", "leave" atau "holiday". Hari yang belum
+	// bisa dinilai absen (sebelum user terdaftar, atau hari ini sebelum jam kerja
+	// selesai) tidak dimasukkan.
+	Days         map[string]string `json:"days"`
+	TotalPresent int               `json:"total_present"`
+	TotalLate    int               `json:"total_late"`
+	TotalAbsent  int               `json:"total_absent"`
+	TotalLeave   int               `json:"total_leave"`
+}
+
+type Holiday struct {
+	ID   int    `json:"id"`
+	Date string `json:"date"`
+	Name string `json:"name"`
 }
 
 type EmployeeMonthlyAttendanceResponse struct {
@@ -82,8 +110,18 @@ type EmployeeMonthlyAttendanceResponse struct {
 	Attendances    []EmployeeAttendance `json:"attendances"`
 }
 
+// MyAttendanceResponse adalah riwayat absensi milik user yang sedang login
+type MyAttendanceResponse struct {
+	EmployeeMonthlyAttendanceResponse
+	Leaves      []LeaveRequest         `json:"leaves"`
+	Holidays    []Holiday              `json:"holidays"`
+	Corrections []AttendanceCorrection `json:"corrections"`
+}
+
 type EmployeeAttendance struct {
-	Date        string `json:"date"`
-	CheckInTime string `json:"check_in_time"`
-	Status      string `json:"status"` // "on-time" or "late"
+	Date         string `json:"date"`
+	CheckInTime  string `json:"check_in_time"`
+	Status       string `json:"status"` // "on-time" or "late"
+	LateMinutes  int    `json:"late_minutes"`
+	LateCategory string `json:"late_category"` // "on-time" atau label tier dari late policy
 }
@@ -0,0 +1,26 @@
+package types
+
+import "time"
+
+type AttendanceAnomaly struct {
+	ID          int        `json:"id"`
+	Type        string     `json:"type"` // "burst_redemption", "repeated_token_check", "tolerance_edge" atau "non_working_day"
+	UserID      *int       `json:"user_id"`
+	UserName    *string    `json:"user_name"`
+	AnomalyDate string     `json:"anomaly_date"`
+	Details     string     `json:"details"`
+	Status      string     `json:"status"` // "pending", "confirmed" atau "dismissed"
+	ReviewNote  *string    `json:"review_note"`
+	ReviewedBy  *int       `json:"reviewed_by"`
+	ReviewedAt  *time.Time `json:"reviewed_at"`
+	DetectedAt  time.Time  `json:"detected_at"`
+}
+
+type ReviewAnomalyRequest struct {
+	Status string `json:"status"` // "confirmed" atau "dismissed"
+	Note   string `json:"note"`
+}
+
+type AnomalyScanResponse struct {
+	NewAnomalies int `json:"new_anomalies"`
+}
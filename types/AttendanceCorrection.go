@@ -0,0 +1,24 @@
+package types
+
+import "time"
+
+// AttendanceCorrection adalah pengajuan koreksi absensi (misalnya lupa check-in)
+type AttendanceCorrection struct {
+	ID               int        `json:"id"`
+	UserID           int        `json:"user_id"`
+	UserName         string     `json:"user_name,omitempty"`
+	Date             string     `json:"date"`
+	RequestedCheckIn string     `json:"requested_check_in"`
+	Reason           string     `json:"reason"`
+	Status           string     `json:"status"` // "pending", "approved" atau "rejected"
+	ReviewNote       *string    `json:"review_note"`
+	ReviewedBy       *int       `json:"reviewed_by"`
+	ReviewedAt       *time.Time `json:"reviewed_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+type CreateAttendanceCorrectionRequest struct {
+	Date             string `json:"date"`               // YYYY-MM-DD
+	RequestedCheckIn string `json:"requested_check_in"` // HH:MM atau HH:MM:SS
+	Reason           string `json:"reason"`
+}
@@ -0,0 +1,78 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditLog adalah satu baris audit log. Before dan After hanya berisi field
+// yang berubah (null jika tidak relevan, misalnya pada login).
+type AuditLog struct {
+	ID         int64           `json:"id"`
+	ActorID    *int            `json:"actor_id"` // null untuk aksi tanpa user (service API key, login gagal)
+	ActorName  *string         `json:"actor_name"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   *int            `json:"entity_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	IP         *string         `json:"ip"`
+	CreatedAt  time.Time       `json:"created_at"`
+	PrevHash   string          `json:"prev_hash"`
+	Hash       string          `json:"hash"`
+}
+
+// AuditLogFilter berisi filter query audit log. Nilai kosong/0 berarti tanpa filter.
+type AuditLogFilter struct {
+	ActorID    int
+	Action     string
+	EntityType string
+	EntityID   int
+	From       *time.Time // inklusif
+	To         *time.Time // eksklusif
+	Limit      int
+	Offset     int
+}
+
+type AuditLogListResponse struct {
+	AuditLogs []AuditLog `json:"audit_logs"`
+	Limit     int        `json:"limit"`
+	Offset    int        `json:"offset"`
+}
+
+// AuditChainVerification adalah hasil verifikasi hash chain audit log
+type AuditChainVerification struct {
+	Valid          bool   `json:"valid"`
+	CheckedEntries int    `json:"checked_entries"` // jumlah baris valid sebelum rantai putus
+	LastID         int64  `json:"last_valid_id,omitempty"`
+	LastHash       string `json:"last_valid_hash,omitempty"`
+	BrokenAtID     *int64 `json:"broken_at_id,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+
+	// Token absensi terpakai yang sudah dicocokkan dengan baris audit-nya, dan
+	// id token yang tidak sesuai (diubah atau ditandai terpakai tanpa audit)
+	CheckedAttendanceTokens   int   `json:"checked_attendance_tokens"`
+	AttendanceTokenMismatches []int `json:"attendance_token_mismatches,omitempty"`
+}
+
+// AuditDigest merangkum audit chain untuk satu hari. Karena setiap baris
+// merujuk hash sebelumnya, LastHash mewakili seluruh riwayat sampai akhir hari.
+type AuditDigest struct {
+	Date        string    `json:"date"` // YYYY-MM-DD
+	Entries     int       `json:"entries"`
+	FirstID     *int64    `json:"first_id"`
+	LastID      *int64    `json:"last_id"`
+	PrevHash    string    `json:"prev_hash"` // hash terakhir sebelum hari ini
+	LastHash    string    `json:"last_hash"` // hash terakhir pada akhir hari ini
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// SignedAuditDigest adalah digest harian yang ditandatangani. Signature dihitung
+// atas byte Digest persis seperti yang dikirim, sehingga bisa diverifikasi di
+// luar sistem dengan PublicKey.
+type SignedAuditDigest struct {
+	Digest    json.RawMessage `json:"digest"`
+	Algorithm string          `json:"algorithm"`  // "Ed25519"
+	PublicKey string          `json:"public_key"` // base64
+	Signature string          `json:"signature"`  // base64
+}
@@ -1,14 +1,20 @@
 package types
 
 type UserAuthInfo struct {
-	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
-	Role  string `json:"role"`
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	Email       string   `json:"email"`
+	Role        string   `json:"role"` // role utama, dipertahankan untuk kompatibilitas
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+
+	MustChangePassword bool `json:"must_change_password"` // true setelah dibuat dengan password awal
+	TwoFactorEnabled   bool `json:"two_factor_enabled"`
 }
 
 type AuthCheckResponse struct {
 	Authenticated bool          `json:"authenticated"`
 	User          *UserAuthInfo `json:"user,omitempty"`
 	IsAttended    bool          `json:"is_attended"`
+	CSRFToken     string        `json:"csrf_token,omitempty"` // kirim lewat header X-CSRF-Token pada POST/PUT/DELETE
 }
@@ -1,6 +1,15 @@
 package types
 
 type Department struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	ID          int     `json:"id"`
+	Name        string  `json:"name"`
+	ManagerID   *int    `json:"manager_id"`
+	ManagerName *string `json:"manager_name"`
+}
+
+// DepartmentRequest dipakai untuk create dan update departemen.
+// ManagerID kosong (null) berarti departemen tidak memiliki manager.
+type DepartmentRequest struct {
+	Name      string `json:"name"`
+	ManagerID *int   `json:"manager_id"`
 }
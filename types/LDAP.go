@@ -0,0 +1,27 @@
+package types
+
+import "time"
+
+// LDAPSyncReport adalah hasil (atau rencana, jika DryRun) sinkronisasi user dari LDAP
+type LDAPSyncReport struct {
+	DryRun      bool             `json:"dry_run"`
+	StartedAt   time.Time        `json:"started_at"`
+	Created     []LDAPSyncChange `json:"created"`
+	Updated     []LDAPSyncChange `json:"updated"`
+	Deactivated []LDAPSyncChange `json:"deactivated"`
+	Skipped     []LDAPSyncChange `json:"skipped"`
+}
+
+type LDAPSyncChange struct {
+	UserID  int                    `json:"user_id,omitempty"` // kosong untuk user yang akan dibuat
+	Email   string                 `json:"email"`
+	Name    string                 `json:"name,omitempty"`
+	DN      string                 `json:"dn,omitempty"`
+	Changes map[string]FieldChange `json:"changes,omitempty"`
+	Reason  string                 `json:"reason,omitempty"`
+}
+
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
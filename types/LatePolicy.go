@@ -0,0 +1,28 @@
+package types
+
+import "time"
+
+type LatePolicy struct {
+	ID           int        `json:"id"`
+	MeasureFrom  string     `json:"measure_from"`  // "start" (work_start_time) atau "tolerance" (tolerance_time)
+	GraceMinutes int        `json:"grace_minutes"` // menit setelah tolerance_time yang masih dianggap tepat waktu
+	RoundingMode string     `json:"rounding_mode"` // "floor", "ceil" atau "nearest"
+	RoundingUnit int        `json:"rounding_unit"` // kelipatan pembulatan dalam menit
+	Tiers        []LateTier `json:"tiers"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// LateTier adalah kategori keterlambatan untuk rentang menit [MinMinutes, MaxMinutes)
+type LateTier struct {
+	Label      string `json:"label"`
+	MinMinutes int    `json:"min_minutes"`
+	MaxMinutes *int   `json:"max_minutes"` // nil berarti tanpa batas atas
+}
+
+type UpdateLatePolicyRequest struct {
+	MeasureFrom  string     `json:"measure_from"`
+	GraceMinutes int        `json:"grace_minutes"`
+	RoundingMode string     `json:"rounding_mode"`
+	RoundingUnit int        `json:"rounding_unit"`
+	Tiers        []LateTier `json:"tiers"`
+}
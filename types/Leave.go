@@ -0,0 +1,29 @@
+package types
+
+import "time"
+
+type LeaveRequest struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	UserName   string     `json:"user_name,omitempty"`
+	StartDate  string     `json:"start_date"`
+	EndDate    string     `json:"end_date"`
+	Reason     *string    `json:"reason"`
+	Status     string     `json:"status"` // "pending", "approved" atau "rejected"
+	ReviewNote *string    `json:"review_note"`
+	ReviewedBy *int       `json:"reviewed_by"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CreateLeaveRequest struct {
+	StartDate string `json:"start_date"` // YYYY-MM-DD
+	EndDate   string `json:"end_date"`   // YYYY-MM-DD
+	Reason    string `json:"reason"`
+}
+
+// ApprovalRequest adalah keputusan approver atas pengajuan cuti atau koreksi absensi
+type ApprovalRequest struct {
+	Status string `json:"status"` // "approved" atau "rejected"
+	Note   string `json:"note"`
+}
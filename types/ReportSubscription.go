@@ -0,0 +1,22 @@
+package types
+
+import "time"
+
+type ReportSubscription struct {
+	ID             int        `json:"id"`
+	Name           string     `json:"name"`
+	Recipients     []string   `json:"recipients"`
+	ReportType     string     `json:"report_type"`     // "daily_summary" atau "monthly_report"
+	CronExpression string     `json:"cron_expression"` // contoh "0 7 * * *"
+	IsActive       bool       `json:"is_active"`
+	LastRunAt      *time.Time `json:"last_run_at"`
+	FailedRunAt    *time.Time `json:"failed_run_at"` // jadwal yang gagal terkirim dan akan dicoba ulang
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type CreateReportSubscriptionRequest struct {
+	Name           string   `json:"name"`
+	Recipients     []string `json:"recipients"`
+	ReportType     string   `json:"report_type"`
+	CronExpression string   `json:"cron_expression"`
+}
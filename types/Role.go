@@ -0,0 +1,29 @@
+package types
+
+type Role struct {
+	ID             int      `json:"id"`
+	Name           string   `json:"name"`
+	Description    *string  `json:"description"`
+	InheritsRoleID *int     `json:"inherits_role_id"` // role yang seluruh permission-nya ikut dimiliki
+	InheritsRole   *string  `json:"inherits_role"`
+	Permissions    []string `json:"permissions"` // permission langsung (belum termasuk warisan)
+	RequireTwoFA   bool     `json:"require_2fa"` // user dengan role ini wajib memakai 2FA
+}
+
+type Permission struct {
+	ID          int     `json:"id"`
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+}
+
+type UpdateRolePermissionsRequest struct {
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateUserRolesRequest struct {
+	Roles []string `json:"roles"`
+}
+
+type UpdateRoleTwoFARequest struct {
+	RequireTwoFA bool `json:"require_2fa"`
+}
@@ -0,0 +1,14 @@
+package types
+
+import "time"
+
+// UserSession adalah session login yang tersimpan di server (tabel user_sessions)
+type UserSession struct {
+	ID         int       `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"` // session yang dipakai request ini
+}
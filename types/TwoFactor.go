@@ -0,0 +1,19 @@
+package types
+
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth://, tampilkan sebagai QR code
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"` // kode TOTP 6 digit atau recovery code
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"` // hanya ditampilkan sekali
+}
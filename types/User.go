@@ -21,6 +21,37 @@ type CreateUserRequest struct {
 	Position     string `json:"position"`
 	DepartmentID int    `json:"department_id"`
 	Status       string `json:"status"`
+	Password     string `json:"password"`    // opsional, kosong = dibuatkan password sementara
+	SendInvite   bool   `json:"send_invite"` // kirim undangan via email, password dibuat oleh user
+}
+
+type CreateUserResponse struct {
+	ID                 int    `json:"id"`
+	Name               string `json:"name"`
+	Email              string `json:"email"`
+	Status             string `json:"status"`
+	TemporaryPassword  string `json:"temporary_password,omitempty"` // hanya ditampilkan sekali
+	MustChangePassword bool   `json:"must_change_password"`
+	InvitationSent     bool   `json:"invitation_sent"`
+}
+
+type AcceptInvitationRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
 }
 
 type EditUserRequest struct {
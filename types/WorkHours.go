@@ -10,3 +10,9 @@ type WorkHours struct {
 	CreatedAt     time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
 }
+
+type UpdateWorkHoursRequest struct {
+	WorkStartTime string `json:"work_start_time"` // HH:MM:SS
+	WorkEndTime   string `json:"work_end_time"`
+	ToleranceTime string `json:"tolerance_time"`
+}
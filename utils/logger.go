@@ -1,46 +1,109 @@
 package utils
 
 import (
-	"fmt"
+	"context"
+	"log/slog"
 	"os"
-	"path/filepath"
-	"time"
+	"regexp"
+	"strings"
 )
 
-// LogToFile menulis log ke file seperti laravel.log
-func LogToFile(message string) error {
-	logDir := "logs"
+// LogFields berisi field yang ikut dicatat di setiap log selama request berjalan.
+// Diisi bertahap: request ID oleh middleware request logger, route setelah
+// routing, dan user ID setelah autentikasi.
+type LogFields struct {
+	RequestID string
+	Route     string
+	UserID    int
+	APIKeyID  int
+}
 
-	// Buat folder logs jika belum ada
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		return fmt.Errorf("gagal membuat folder logs: %w", err)
-	}
+type logFieldsContextKey struct{}
 
-	// Nama file log dengan format: app-YYYY-MM-DD.log
-	logFileName := fmt.Sprintf("app-%s.log", time.Now().Format("2006-01-02"))
-	logFilePath := filepath.Join(logDir, logFileName)
+// ContextWithLogFields menyimpan field log request di context
+func ContextWithLogFields(ctx context.Context, fields *LogFields) context.Context {
+	return context.WithValue(ctx, logFieldsContextKey{}, fields)
+}
+
+// LogFieldsFromContext mengambil field log request, nil jika bukan dalam request
+func LogFieldsFromContext(ctx context.Context) *LogFields {
+	fields, _ := ctx.Value(logFieldsContextKey{}).(*LogFields)
+	return fields
+}
 
-	// Buka atau buat file
-	file, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-	if err != nil {
-		return fmt.Errorf("gagal membuka file log: %w", err)
+// InitLogger memasang logger JSON (log/slog) sebagai logger default. Level diatur
+// lewat LOG_LEVEL (debug, info, warn, error; default info). Pemanggilan package
+// log standar juga diteruskan ke logger ini.
+func InitLogger() {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(os.Getenv("LOG_LEVEL"))); err != nil {
+		level = slog.LevelInfo
 	}
-	defer file.Close()
 
-	// Format log dengan timestamp
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	logEntry := fmt.Sprintf("[%s] %s\n", timestamp, message)
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactAttr,
+	})
+
+	slog.SetDefault(slog.New(contextHandler{handler}))
+}
+
+// contextHandler menambahkan field request (request_id, route, user_id) dari
+// context ke setiap log yang dibuat dengan slog.*Context
+type contextHandler struct {
+	slog.Handler
+}
 
-	// Tulis ke file
-	if _, err := file.WriteString(logEntry); err != nil {
-		return fmt.Errorf("gagal menulis ke file log: %w", err)
+func (h contextHandler) Handle(ctx context.Context, rec slog.Record) error {
+	if fields := LogFieldsFromContext(ctx); fields != nil {
+		rec.AddAttrs(slog.String("request_id", fields.RequestID))
+		if fields.Route != "" {
+			rec.AddAttrs(slog.String("route", fields.Route))
+		}
+		if fields.UserID != 0 {
+			rec.AddAttrs(slog.Int("user_id", fields.UserID))
+		}
+		if fields.APIKeyID != 0 {
+			rec.AddAttrs(slog.Int("api_key_id", fields.APIKeyID))
+		}
 	}
+	return h.Handler.Handle(ctx, rec)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
 
-	return nil
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
 }
 
-// LogEditUser menulis log khusus untuk edit user
-func LogEditUser(userID int, data map[string]interface{}) error {
-	message := fmt.Sprintf("[EDIT USER] User ID: %d, Data: %+v", userID, data)
-	return LogToFile(message)
+var (
+	emailPattern = regexp.MustCompile(`([A-Za-z0-9._%+\-])[A-Za-z0-9._%+\-]*@([A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+	// Nomor telepon: diawali + (kode negara) atau 0, 9-15 digit dengan pemisah spasi/strip
+	phonePattern = regexp.MustCompile(`(?:\+\d{1,3}|\b0)[\s\-]?\d{2,4}[\s\-]?\d{3,4}[\s\-]?\d{2,5}\b`)
+)
+
+// RedactPII menyamarkan alamat email dan nomor telepon di dalam teks:
+// "budi@example.com" menjadi "b***@example.com", "081234567890" menjadi "***890"
+func RedactPII(s string) string {
+	if strings.Contains(s, "@") {
+		s = emailPattern.ReplaceAllString(s, "$1***@$2")
+	}
+	return phonePattern.ReplaceAllStringFunc(s, func(phone string) string {
+		return "***" + phone[len(phone)-3:]
+	})
+}
+
+// redactAttr menjalankan RedactPII untuk pesan log dan semua nilai string/error
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(RedactPII(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			a.Value = slog.StringValue(RedactPII(err.Error()))
+		}
+	}
+	return a
 }
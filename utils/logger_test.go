@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+func TestRedactPII(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"email", "budi@example.com", "b***@example.com"},
+		{"email di kalimat", "login gagal untuk Budi.Santoso+hr@mail.company.co.id dari web", "login gagal untuk B***@mail.company.co.id dari web"},
+		{"dua email", "a@x.io, siti@y.com", "a***@x.io, s***@y.com"},
+		{"telepon 08xx", "081234567890", "***890"},
+		{"telepon 08xx dengan strip", "hubungi 0812-3456-7890 segera", "hubungi ***890 segera"},
+		{"telepon +62", "+6281234567890", "***890"},
+		{"telepon +62 dengan spasi", "telp: +62 812 3456 7890", "telp: ***890"},
+		{"telepon +62 dengan strip", "+62-811-0000-0000", "***000"},
+
+		// Tidak boleh berubah
+		{"tanpa PII", "Attendance submitted", "Attendance submitted"},
+		{"timestamp", "2026-03-02T08:00:01.123456Z", "2026-03-02T08:00:01.123456Z"},
+		{"timestamp dengan spasi", "2026-03-02 08:00:01", "2026-03-02 08:00:01"},
+		{"jam", "08:15:00", "08:15:00"},
+		{"IPv4", "192.168.100.254", "192.168.100.254"},
+		{"IPv4 dengan port", "10.0.0.1:8080", "10.0.0.1:8080"},
+		{"IPv6", "2001:db8::1", "2001:db8::1"},
+		{"request ID hex", "req-0a1b2c3d4e5f6789", "req-0a1b2c3d4e5f6789"},
+		{"request ID UUID", "550e8400-e29b-41d4-a716-446655440000", "550e8400-e29b-41d4-a716-446655440000"},
+		{"angka pendek", "user_id 1024 status 200", "user_id 1024 status 200"},
+		{"durasi", "took 0.0123s", "took 0.0123s"},
+		{"unix timestamp", "expires 1772438400", "expires 1772438400"},
+		{"@ tanpa domain", "@budi", "@budi"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RedactPII(tt.in); got != tt.want {
+				t.Errorf("RedactPII(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactAttr(t *testing.T) {
+	tests := []struct {
+		name string
+		attr slog.Attr
+		want slog.Value
+	}{
+		{"string", slog.String("email", "budi@example.com"), slog.StringValue("b***@example.com")},
+		{"error", slog.Any("error", errors.New("user 081234567890 tidak ditemukan")), slog.StringValue("user ***890 tidak ditemukan")},
+		{"int tidak berubah", slog.Int("user_id", 81234567), slog.IntValue(81234567)},
+		{"string tanpa PII", slog.String("request_id", "550e8400-e29b-41d4-a716-446655440000"), slog.StringValue("550e8400-e29b-41d4-a716-446655440000")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactAttr(nil, tt.attr).Value; !got.Equal(tt.want) {
+				t.Errorf("redactAttr(%v) = %v, want %v", tt.attr, got, tt.want)
+			}
+		})
+	}
+}
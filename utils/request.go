@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ClientIP mengambil alamat IP client dari request.
+// Header X-Forwarded-For hanya dipercaya jika TRUST_PROXY_HEADERS=true
+// (server berada di belakang reverse proxy), karena header ini mudah dipalsukan.
+// Client bisa mengirim X-Forwarded-For sendiri dan proxy hanya menambahkan
+// alamat di belakangnya, jadi yang dipakai adalah entry ke-TRUSTED_PROXY_HOPS
+// dari kanan (default 1: entry terakhir yang ditambahkan proxy terdepan).
+func ClientIP(r *http.Request) string {
+	if os.Getenv("TRUST_PROXY_HEADERS") == "true" {
+		if ip := forwardedClientIP(r.Header.Values("X-Forwarded-For"), trustedProxyHops()); ip != "" {
+			return ip
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func trustedProxyHops() int {
+	hops, err := strconv.Atoi(os.Getenv("TRUSTED_PROXY_HOPS"))
+	if err != nil || hops < 1 {
+		return 1
+	}
+	return hops
+}
+
+// forwardedClientIP mengambil entry ke-hops dari kanan. Jika rantai lebih
+// pendek dari jumlah proxy, semua entry berasal dari proxy tepercaya dan
+// entry paling kiri adalah client.
+func forwardedClientIP(headers []string, hops int) string {
+	var entries []string
+	for _, header := range headers {
+		for _, entry := range strings.Split(header, ",") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return ""
+	}
+
+	index := len(entries) - hops
+	if index < 0 {
+		index = 0
+	}
+	if net.ParseIP(entries[index]) == nil {
+		return ""
+	}
+	return entries[index]
+}
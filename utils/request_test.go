@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		trust     string
+		hops      string
+		forwarded []string
+		want      string
+	}{
+		{"proxy tidak dipercaya", "false", "", []string{"203.0.113.9"}, "192.0.2.1"},
+		{"tanpa header", "true", "", nil, "192.0.2.1"},
+		{"satu proxy", "true", "", []string{"203.0.113.9"}, "203.0.113.9"},
+		{"entry palsu dari client diabaikan", "true", "", []string{"10.0.0.1, 203.0.113.9"}, "203.0.113.9"},
+		{"header ganda", "true", "", []string{"10.0.0.1", "203.0.113.9"}, "203.0.113.9"},
+		{"dua proxy", "true", "2", []string{"10.0.0.1, 203.0.113.9, 198.51.100.7"}, "203.0.113.9"},
+		{"rantai lebih pendek dari jumlah proxy", "true", "3", []string{"203.0.113.9, 198.51.100.7"}, "203.0.113.9"},
+		{"hops tidak valid", "true", "abc", []string{"10.0.0.1, 203.0.113.9"}, "203.0.113.9"},
+		{"bukan alamat IP", "true", "", []string{"203.0.113.9, bukan-ip"}, "192.0.2.1"},
+		{"IPv6", "true", "", []string{"2001:db8::1"}, "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TRUST_PROXY_HEADERS", tt.trust)
+			t.Setenv("TRUSTED_PROXY_HOPS", tt.hops)
+
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = "192.0.2.1:54321"
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+
+			if got := ClientIP(r); got != tt.want {
+				t.Errorf("ClientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}